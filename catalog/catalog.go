@@ -0,0 +1,165 @@
+package catalog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+)
+
+// Tags of the testcases
+const (
+	Safety    = "safety"
+	Liveness  = "liveness"
+	Byzantine = "byzantine"
+)
+
+// BFTSizes are the cluster sizes n=3f+1 of the testcases that partition the replicas in parts of size 1, f and 2f
+var BFTSizes = []int{4, 7, 10}
+
+// Entry describes a testcase constructor of a testcases package
+type Entry struct {
+	// Name used to select the testcase, the package and the constructor, for example "rskip.One"
+	Name        string
+	Description string
+	Tags        []string
+	// Sizes are the supported number of replicas, empty if any number is supported
+	Sizes  []int
+	Params []Param
+	// New creates the testcase, the parameters are validated and the defaults are set
+	New func(Params) (*testlib.TestCase, error)
+}
+
+// HasTag returns true if the testcase has the tag
+func (e *Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports returns true if the testcase can run with n replicas
+func (e *Entry) Supports(n int) bool {
+	if len(e.Sizes) == 0 {
+		return true
+	}
+	for _, size := range e.Sizes {
+		if size == n {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceName is the name of the testcase created with the parameters, unique for every combination of values
+func (e *Entry) InstanceName(params Params) string {
+	if len(params) == 0 {
+		return e.Name
+	}
+	return fmt.Sprintf("%s[%s]", e.Name, params)
+}
+
+// Values returns the values of the parameters of the schema, the parameters that are not set take the default values.
+// Parameters that are not part of the schema are ignored, they might be meant for other testcases.
+func (e *Entry) Values(params Params) (Params, error) {
+	values := make(Params)
+	for _, p := range e.Params {
+		value, ok := params[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		if err := p.validate(value); err != nil {
+			return nil, fmt.Errorf("testcase %s: %s", e.Name, err)
+		}
+		values[p.Name] = value
+	}
+	return values, nil
+}
+
+// Instance creates the testcase with the parameters, named by InstanceName. The timeout is the one set by the
+// constructor. The scheduler identifies testcases by name, every instance of a sweep has a different name.
+func (e *Entry) Instance(params Params) (*testlib.TestCase, error) {
+	values, err := e.Values(params)
+	if err != nil {
+		return nil, err
+	}
+	testcase, err := e.New(values)
+	if err != nil {
+		return nil, err
+	}
+	testcase.Name = e.InstanceName(values)
+	return testcase, nil
+}
+
+type registry struct {
+	entries map[string]*Entry
+	lock    *sync.Mutex
+}
+
+var entries = &registry{
+	entries: make(map[string]*Entry),
+	lock:    new(sync.Mutex),
+}
+
+// Register adds the testcase to the catalog, it is called by the testcases packages in init.
+// Panics if the name is registered twice.
+func Register(e *Entry) {
+	entries.lock.Lock()
+	defer entries.lock.Unlock()
+	if _, ok := entries.entries[e.Name]; ok {
+		panic(fmt.Sprintf("catalog: testcase %s registered twice", e.Name))
+	}
+	entries.entries[e.Name] = e
+}
+
+// All returns the registered testcases sorted by name
+func All() []*Entry {
+	entries.lock.Lock()
+	defer entries.lock.Unlock()
+	result := make([]*Entry, 0, len(entries.entries))
+	for _, e := range entries.entries {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Get returns the testcase registered with the name
+func Get(name string) (*Entry, bool) {
+	entries.lock.Lock()
+	defer entries.lock.Unlock()
+	e, ok := entries.entries[name]
+	return e, ok
+}
+
+// Select returns the testcases whose name matches one of the patterns, exact names or globs such as "rskip.*",
+// and that have all the tags. No patterns select every testcase.
+func Select(testcases []*Entry, patterns []string, tags []string) ([]*Entry, error) {
+	result := make([]*Entry, 0)
+	for _, t := range testcases {
+		matches := len(patterns) == 0
+		for _, p := range patterns {
+			ok, err := path.Match(p, t.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %s", p, err)
+			}
+			if ok {
+				matches = true
+				break
+			}
+		}
+		for _, tag := range tags {
+			if !t.HasTag(tag) {
+				matches = false
+			}
+		}
+		if matches {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package catalog
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+)
+
+func names(testcases []*Entry) []string {
+	result := make([]string, len(testcases))
+	for i, t := range testcases {
+		result[i] = t.Name
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	testcases := []*Entry{
+		{Name: "rskip.One", Tags: []string{Liveness}},
+		{Name: "lockedvalue.One", Tags: []string{Safety}},
+		{Name: "lockedvalue.Three", Tags: []string{Safety, Byzantine}},
+	}
+	cases := []struct {
+		patterns []string
+		tags     []string
+		expected []string
+	}{
+		{nil, nil, []string{"rskip.One", "lockedvalue.One", "lockedvalue.Three"}},
+		{[]string{"rskip.One"}, nil, []string{"rskip.One"}},
+		{[]string{"lockedvalue.*"}, nil, []string{"lockedvalue.One", "lockedvalue.Three"}},
+		{[]string{"*.One"}, []string{Safety}, []string{"lockedvalue.One"}},
+		{nil, []string{Safety, Byzantine}, []string{"lockedvalue.Three"}},
+		{[]string{"sanity.*"}, nil, []string{}},
+	}
+	for _, c := range cases {
+		selected, err := Select(testcases, c.patterns, c.tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := names(selected)
+		if len(got) != len(c.expected) {
+			t.Errorf("patterns %v tags %v: expected %v, got %v", c.patterns, c.tags, c.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.expected[i] {
+				t.Errorf("patterns %v tags %v: expected %v, got %v", c.patterns, c.tags, c.expected, got)
+				break
+			}
+		}
+	}
+
+	if _, err := Select(testcases, []string{"["}, nil); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
+func TestParams(t *testing.T) {
+	params := make(Params)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Var(params, "param", "")
+	if err := flags.Parse([]string{"-param", "height=3", "-param", "step=2s", "-param", "type=Prevote"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if height, err := params.Int("height"); err != nil || height != 3 {
+		t.Errorf("expected height 3, got %d (%v)", height, err)
+	}
+	if _, err := params.Int("round"); err == nil {
+		t.Error("expected error for a parameter that is not set")
+	}
+	if step, err := params.Duration("step"); err != nil || step != 2*time.Second {
+		t.Errorf("expected step 2s, got %s (%v)", step, err)
+	}
+	if _, err := params.Int("type"); err == nil {
+		t.Error("expected error for a non integer parameter")
+	}
+	if err := params.Set("invalid"); err == nil {
+		t.Error("expected error for a parameter without value")
+	}
+	if s := params.String(); s != "height=3,step=2s,type=Prevote" {
+		t.Errorf("unexpected string %s", s)
+	}
+}
+
+func TestInstance(t *testing.T) {
+	var got Params
+	entry := &Entry{
+		Name:  "rskip.One",
+		Sizes: BFTSizes,
+		Params: []Param{
+			{Name: "height", Type: IntParam, Default: "1"},
+			{Name: "round", Type: IntParam, Default: "2"},
+		},
+		New: func(p Params) (*testlib.TestCase, error) {
+			got = p
+			return testlib.NewTestCase("RoundSkipPrevote", 30*time.Second, nil), nil
+		},
+	}
+	testcase, err := entry.Instance(Params{"round": "3", "step": "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testcase.Name != "rskip.One[height=1,round=3]" {
+		t.Errorf("unexpected instance name %s", testcase.Name)
+	}
+	if testcase.Timeout != 30*time.Second {
+		t.Errorf("expected the timeout of the constructor, got %s", testcase.Timeout)
+	}
+	if got.String() != "height=1,round=3" {
+		t.Errorf("expected defaults and values of the schema only, got %s", got)
+	}
+	if _, err := entry.Instance(Params{"height": "one"}); err == nil {
+		t.Error("expected error for an invalid parameter")
+	}
+	if !entry.Supports(7) || entry.Supports(5) {
+		t.Error("expected sizes 3f+1 to be supported")
+	}
+}
+
+func TestGrid(t *testing.T) {
+	grid := make(Grid)
+	for _, v := range []string{"height=1..3", "round=1,2", "seed=7"} {
+		if err := grid.Set(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	points := grid.Expand(Params{"times": "3", "seed": "1"})
+	if len(points) != 6 {
+		t.Fatalf("expected 6 points, got %d", len(points))
+	}
+	expected := []string{
+		"height=1,round=1,seed=7,times=3",
+		"height=1,round=2,seed=7,times=3",
+		"height=2,round=1,seed=7,times=3",
+		"height=2,round=2,seed=7,times=3",
+		"height=3,round=1,seed=7,times=3",
+		"height=3,round=2,seed=7,times=3",
+	}
+	for i, p := range points {
+		if p.String() != expected[i] {
+			t.Errorf("point %d: expected %s, got %s", i, expected[i], p)
+		}
+	}
+
+	for _, v := range []string{"height", "height=3..1", "height=a..2"} {
+		if err := grid.Set(v); err == nil {
+			t.Errorf("expected error for %q", v)
+		}
+	}
+
+	entry := &Entry{Name: "rskip.One", Params: []Param{{Name: "height", Type: IntParam, Default: "1"}}}
+	names := make(map[string]bool)
+	for _, p := range points {
+		values, err := entry.Values(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names[entry.InstanceName(values)] = true
+	}
+	if len(names) != 3 || !names["rskip.One[height=2]"] {
+		t.Errorf("expected one instance per height, got %v", names)
+	}
+}
@@ -0,0 +1,36 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Markdown writes the documentation of the testcases
+func Markdown(w io.Writer, testcases []*Entry) {
+	fmt.Fprintf(w, "# Testcases\n\nGenerated from the catalog with `go run . -doc`, do not edit.\n")
+	for _, e := range testcases {
+		fmt.Fprintf(w, "\n## %s\n\n%s\n\n", e.Name, e.Description)
+		if len(e.Tags) != 0 {
+			fmt.Fprintf(w, "- Tags: %s\n", strings.Join(e.Tags, ", "))
+		}
+		if len(e.Sizes) != 0 {
+			sizes := make([]string, len(e.Sizes))
+			for i, s := range e.Sizes {
+				sizes[i] = fmt.Sprintf("%d", s)
+			}
+			fmt.Fprintf(w, "- Replicas: %s\n", strings.Join(sizes, ", "))
+		}
+		// the timeout is set by the constructor
+		if testcase, err := e.Instance(Params{}); err == nil {
+			fmt.Fprintf(w, "- Timeout: %s\n", testcase.Timeout)
+		}
+		if len(e.Params) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n| Parameter | Type | Default | Description |\n| --- | --- | --- | --- |\n")
+		for _, p := range e.Params {
+			fmt.Fprintf(w, "| `%s` | %s | `%s` | %s |\n", p.Name, p.Type, p.Default, p.Description)
+		}
+	}
+}
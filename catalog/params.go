@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamType is the type of a testcase parameter
+type ParamType string
+
+const (
+	IntParam      ParamType = "int"
+	DurationParam ParamType = "duration"
+	StringParam   ParamType = "string"
+)
+
+// Param describes a parameter of a testcase constructor
+type Param struct {
+	Name        string
+	Type        ParamType
+	Default     string
+	Description string
+}
+
+func (p *Param) validate(value string) error {
+	var err error
+	switch p.Type {
+	case IntParam:
+		_, err = strconv.Atoi(value)
+	case DurationParam:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("parameter %s should be of type %s: %s", p.Name, p.Type, err)
+	}
+	return nil
+}
+
+// Params are the parameter values of a testcase as key=value, it implements flag.Value
+type Params map[string]string
+
+func (p Params) String() string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + p[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p Params) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("parameter should be key=value, got %q", value)
+	}
+	p[kv[0]] = kv[1]
+	return nil
+}
+
+// Int returns the integer parameter
+func (p Params) Int(key string) (int, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("parameter %s not set", key)
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s should be an integer: %s", key, err)
+	}
+	return i, nil
+}
+
+// Duration returns the duration parameter
+func (p Params) Duration(key string) (time.Duration, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("parameter %s not set", key)
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s should be a duration: %s", key, err)
+	}
+	return d, nil
+}
+
+// Get returns the parameter, empty if it is not set
+func (p Params) Get(key string) string {
+	return p[key]
+}
@@ -0,0 +1,82 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Grid are the values of the parameters to sweep over, it implements flag.Value.
+// The values are given as key=v1,v2,v3 or as an integer range key=1..5
+type Grid map[string][]string
+
+func (g Grid) String() string {
+	keys := g.keys()
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + strings.Join(g[k], ",")
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (g Grid) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return fmt.Errorf("sweep should be key=v1,v2 or key=from..to, got %q", value)
+	}
+	if bounds := strings.SplitN(kv[1], "..", 2); len(bounds) == 2 {
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %s", kv[1], err)
+		}
+		to, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return fmt.Errorf("invalid range %q: %s", kv[1], err)
+		}
+		if from > to {
+			return fmt.Errorf("invalid range %q", kv[1])
+		}
+		for i := from; i <= to; i++ {
+			g[kv[0]] = append(g[kv[0]], strconv.Itoa(i))
+		}
+		return nil
+	}
+	g[kv[0]] = append(g[kv[0]], strings.Split(kv[1], ",")...)
+	return nil
+}
+
+func (g Grid) keys() []string {
+	keys := make([]string, 0, len(g))
+	for k := range g {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Expand returns the parameters for every point of the grid, the values of base are kept for the keys not in the grid.
+// The points are ordered by the keys of the grid, the last key varies the fastest.
+func (g Grid) Expand(base Params) []Params {
+	result := []Params{copyParams(base)}
+	for _, k := range g.keys() {
+		next := make([]Params, 0, len(result)*len(g[k]))
+		for _, p := range result {
+			for _, v := range g[k] {
+				point := copyParams(p)
+				point[k] = v
+				next = append(next, point)
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+func copyParams(p Params) Params {
+	result := make(Params, len(p))
+	for k, v := range p {
+		result[k] = v
+	}
+	return result
+}
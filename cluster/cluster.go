@@ -0,0 +1,193 @@
+// Package cluster runs a network of instrumented tendermint nodes as local processes pointed at the testing server
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// stopTimeout is the time given to a node to exit after SIGTERM before it is killed
+const stopTimeout = 10 * time.Second
+
+// Node is a tendermint process of the cluster
+type Node struct {
+	Index int
+	Name  string
+	// Home is the tendermint home directory of the node
+	Home string
+	// LogFile has the standard output and error of the process
+	LogFile string
+
+	cmd    *exec.Cmd
+	log    *os.File
+	exited chan struct{}
+}
+
+func (n *Node) start(binary string, args []string) error {
+	logFile, err := os.Create(n.LogFile)
+	if err != nil {
+		return err
+	}
+	n.cmd = exec.Command(binary, append([]string{"node", "--home", n.Home}, args...)...)
+	n.cmd.Stdout = logFile
+	n.cmd.Stderr = logFile
+	if err := n.cmd.Start(); err != nil {
+		logFile.Close()
+		return fmt.Errorf("could not start %s: %s", n.Name, err)
+	}
+	n.log = logFile
+	n.exited = make(chan struct{})
+	go func(cmd *exec.Cmd, exited chan struct{}) {
+		cmd.Wait()
+		close(exited)
+	}(n.cmd, n.exited)
+	return nil
+}
+
+// Running returns true if the process of the node has been started and has not exited
+func (n *Node) Running() bool {
+	if n.cmd == nil {
+		return false
+	}
+	select {
+	case <-n.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+func (n *Node) stop() {
+	if n.cmd == nil {
+		return
+	}
+	if n.Running() {
+		n.cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-n.exited:
+		case <-time.After(stopTimeout):
+			n.cmd.Process.Kill()
+			<-n.exited
+		}
+	}
+	n.log.Close()
+	n.cmd = nil
+}
+
+// Cluster starts, stops and restarts the nodes for every testcase
+type Cluster struct {
+	config     *Config
+	size       int
+	serverAddr string
+
+	nodes     []*Node
+	generated bool
+	lock      *sync.Mutex
+}
+
+// New creates a cluster of size nodes connecting to the testing server at serverAddr.
+// Nothing is written or started before the first call to Start.
+func New(config *Config, size int, serverAddr string) (*Cluster, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if size < 1 {
+		return nil, fmt.Errorf("invalid number of nodes %d", size)
+	}
+	return &Cluster{
+		config:     config,
+		size:       size,
+		serverAddr: serverAddr,
+		nodes:      make([]*Node, 0),
+		lock:       new(sync.Mutex),
+	}, nil
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// RunDir is the log directory of a testcase, named after the start time of the runner and the testcase
+func (c *Cluster) RunDir(start time.Time, testcase string) string {
+	name := unsafeChars.ReplaceAllString(testcase, "_")
+	return filepath.Join(c.config.LogDir, fmt.Sprintf("run_%s_%s", start.Format("20060102-150405"), name))
+}
+
+// Start starts the nodes with the standard output of node i in runDir/node{i}/node.log.
+// When the nodes are wiped, the homes are generated in runDir/node{i} and kept along with the logs,
+// otherwise the homes are generated once in the home directory of the config.
+func (c *Cluster) Start(runDir string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.nodes) > 0 {
+		return fmt.Errorf("cluster is already running")
+	}
+
+	homeDir := c.config.Home
+	if c.config.Wipe {
+		homeDir = runDir
+	}
+	if c.config.Wipe || !c.generated {
+		if err := c.config.GenerateTestnet(homeDir, c.size, c.serverAddr); err != nil {
+			return fmt.Errorf("could not generate testnet: %s", err)
+		}
+		c.generated = true
+	}
+
+	for i := 0; i < c.size; i++ {
+		logDir := filepath.Join(runDir, NodeName(i))
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			c.stop()
+			return err
+		}
+		node := &Node{
+			Index:   i,
+			Name:    NodeName(i),
+			Home:    filepath.Join(homeDir, NodeName(i)),
+			LogFile: filepath.Join(logDir, "node.log"),
+		}
+		if err := node.start(c.config.Binary, c.config.Args); err != nil {
+			c.stop()
+			return err
+		}
+		c.nodes = append(c.nodes, node)
+	}
+	return nil
+}
+
+// Nodes returns the nodes that are running
+func (c *Cluster) Nodes() []*Node {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*Node{}, c.nodes...)
+}
+
+func (c *Cluster) stop() {
+	var wg sync.WaitGroup
+	for _, n := range c.nodes {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			n.stop()
+		}(n)
+	}
+	wg.Wait()
+	c.nodes = make([]*Node, 0)
+}
+
+// Stop stops the nodes, the nodes that do not exit on SIGTERM are killed
+func (c *Cluster) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stop()
+}
+
+// Restart stops the nodes and starts them again with the logs in runDir
+func (c *Cluster) Restart(runDir string) error {
+	c.Stop()
+	return c.Start(runDir)
+}
@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestGenerateTestnet(t *testing.T) {
+	config := DefaultConfig("tendermint")
+	dirA, dirB := t.TempDir(), t.TempDir()
+	if err := config.GenerateTestnet(dirA, 4, "127.0.0.1:7074"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.GenerateTestnet(dirB, 4, "127.0.0.1:7074"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"genesis.json", "priv_validator_key.json", "node_key.json", "config.toml"} {
+		a, err := ioutil.ReadFile(filepath.Join(dirA, "node2", "config", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dirB, "node2", "config", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file == "config.toml" {
+			// the config has the absolute home directory
+			a = bytes.ReplaceAll(a, []byte(dirA), nil)
+			b = bytes.ReplaceAll(b, []byte(dirB), nil)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s differs between two testnets", file)
+		}
+	}
+
+	genesis, err := ttypes.GenesisDocFromFile(filepath.Join(dirA, "node0", "config", "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(genesis.Validators) != 4 || genesis.ChainID != config.ChainID {
+		t.Errorf("expected 4 validators of chain %s, got %d of %s", config.ChainID, len(genesis.Validators), genesis.ChainID)
+	}
+
+	conf, err := ioutil.ReadFile(filepath.Join(dirA, "node1", "config", "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`master_addr = "127.0.0.1:7074"`,
+		`listen_addr = "127.0.0.1:26668"`,
+		string(NodeKey(config.ChainID, 0).ID()) + "@127.0.0.1:26656",
+	} {
+		if !strings.Contains(string(conf), expected) {
+			t.Errorf("expected %q in the config of node1", expected)
+		}
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "tendermint")
+	script := "#!/bin/sh\necho \"started $@\"\ntrap 'echo stopped; exit 0' TERM\nwhile true; do sleep 0.1; done\n"
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig(binary)
+	config.LogDir = filepath.Join(dir, "logs")
+	c, err := New(config, 2, "127.0.0.1:7074")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runDir := c.RunDir(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), "rskip.One[height=2]")
+	if filepath.Base(runDir) != "run_20210101-000000_rskip.One_height_2_" {
+		t.Errorf("unexpected run directory %s", runDir)
+	}
+	if err := c.Start(runDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(runDir); err == nil {
+		t.Error("expected an error when starting a running cluster")
+	}
+	nodes := c.Nodes()
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+	// wait for the script to install the trap
+	time.Sleep(300 * time.Millisecond)
+	c.Stop()
+	for _, n := range nodes {
+		if n.Running() {
+			t.Errorf("%s still running", n.Name)
+		}
+		out, err := ioutil.ReadFile(n.LogFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(out), "started node --home "+n.Home) || !strings.Contains(string(out), "stopped") {
+			t.Errorf("unexpected output of %s: %s", n.Name, out)
+		}
+		if _, err := os.Stat(filepath.Join(runDir, n.Name, "config", "genesis.json")); err != nil {
+			t.Errorf("expected the home of %s in the run directory: %s", n.Name, err)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	config := &Config{}
+	if err := json.Unmarshal([]byte(`{"binary": "/usr/local/bin/tendermint", "wipe": false}`), config); err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultConfig("/usr/local/bin/tendermint")
+	expected.Wipe = false
+	if config.BasePort != expected.BasePort || config.ChainID != expected.ChainID || config.Wipe ||
+		config.ControllerTemplate != expected.ControllerTemplate || len(config.Args) != len(expected.Args) {
+		t.Errorf("expected %+v, got %+v", expected, config)
+	}
+}
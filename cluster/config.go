@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultControllerTemplate is the section appended to the config.toml of every node to point the instrumented
+// replica at the testing server. The fields are the address of the testing server, the address the replica
+// listens on for the messages of the testing server, the index and the name of the node and its p2p node ID.
+const DefaultControllerTemplate = `
+[controller]
+master_addr = "{{.ServerAddr}}"
+listen_addr = "{{.ListenAddr}}"
+`
+
+// Config is the configuration of a cluster of instrumented tendermint nodes on localhost
+type Config struct {
+	// Binary is the path to the instrumented tendermint binary
+	Binary string `json:"binary"`
+	// Args are passed to the binary after "node --home <home>"
+	Args []string `json:"args"`
+	// ChainID of the generated genesis
+	ChainID string `json:"chain_id"`
+	// Home is the directory of the node homes when they are not wiped between testcases
+	Home string `json:"home"`
+	// LogDir is the directory of the runs, every testcase has a directory run_* with a directory for every node
+	LogDir string `json:"log_dir"`
+	// BasePort is the first port of the nodes, node i listens on BasePort+10*i for p2p,
+	// BasePort+10*i+1 for RPC and BasePort+10*i+2 for the messages of the testing server
+	BasePort int `json:"base_port"`
+	// Wipe generates the homes of the nodes again for every testcase, otherwise the nodes
+	// are restarted with the blockchain of the previous testcases
+	Wipe bool `json:"wipe"`
+	// ControllerTemplate is the text/template of the config.toml section read by the instrumented build
+	ControllerTemplate string `json:"controller_template"`
+}
+
+// DefaultConfig returns the cluster configuration for the binary
+func DefaultConfig(binary string) *Config {
+	return &Config{
+		Binary:             binary,
+		Args:               []string{"--proxy_app=kvstore"},
+		ChainID:            "tendermint-test",
+		Home:               "nodes",
+		LogDir:             "logs",
+		BasePort:           26656,
+		Wipe:               true,
+		ControllerTemplate: DefaultControllerTemplate,
+	}
+}
+
+// UnmarshalJSON keeps the default values of the fields missing from the JSON
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	p := plain(*DefaultConfig(""))
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*c = Config(p)
+	return nil
+}
+
+func (c *Config) validate() error {
+	if c.Binary == "" {
+		return fmt.Errorf("no tendermint binary specified")
+	}
+	if c.ChainID == "" {
+		return fmt.Errorf("no chain id specified")
+	}
+	if c.BasePort <= 0 || c.BasePort > 65535 {
+		return fmt.Errorf("invalid base port %d", c.BasePort)
+	}
+	return nil
+}
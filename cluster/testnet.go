@@ -0,0 +1,152 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/privval"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// genesisTime is fixed so that the genesis, and the hash of the blocks that depend on it, is the same on every run
+var genesisTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ValidatorKey is the private validator key of node i, derived from the chain ID and the index
+func ValidatorKey(chainID string, i int) ed25519.PrivKey {
+	return ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("%s/validator/%d", chainID, i)))
+}
+
+// NodeKey is the p2p key of node i, derived from the chain ID and the index
+func NodeKey(chainID string, i int) *p2p.NodeKey {
+	return &p2p.NodeKey{PrivKey: ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("%s/node/%d", chainID, i)))}
+}
+
+// NodeName is the name of node i, also the name of its home and log directory
+func NodeName(i int) string {
+	return fmt.Sprintf("node%d", i)
+}
+
+// Genesis returns the genesis of a chain with n validators of equal power
+func Genesis(chainID string, n int) (*ttypes.GenesisDoc, error) {
+	genesis := &ttypes.GenesisDoc{
+		ChainID:         chainID,
+		GenesisTime:     genesisTime,
+		ConsensusParams: ttypes.DefaultConsensusParams(),
+		Validators:      make([]ttypes.GenesisValidator, n),
+	}
+	for i := 0; i < n; i++ {
+		pubKey := ValidatorKey(chainID, i).PubKey()
+		genesis.Validators[i] = ttypes.GenesisValidator{
+			Address: pubKey.Address(),
+			PubKey:  pubKey,
+			Power:   1,
+			Name:    NodeName(i),
+		}
+	}
+	if err := genesis.ValidateAndComplete(); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+type controllerParams struct {
+	ServerAddr string
+	ListenAddr string
+	Index      int
+	Name       string
+	NodeID     string
+}
+
+func (c *Config) ports(i int) (p2pPort, rpcPort, controllerPort int) {
+	base := c.BasePort + 10*i
+	return base, base + 1, base + 2
+}
+
+func (c *Config) nodeConfig(home string, i, n int) *cfg.Config {
+	conf := cfg.DefaultConfig()
+	conf.SetRoot(home)
+	conf.Moniker = NodeName(i)
+	p2pPort, rpcPort, _ := c.ports(i)
+	conf.P2P.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", p2pPort)
+	conf.RPC.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", rpcPort)
+	conf.P2P.AddrBookStrict = false
+	conf.P2P.AllowDuplicateIP = true
+	peers := make([]string, 0, n-1)
+	for j := 0; j < n; j++ {
+		if j == i {
+			continue
+		}
+		port, _, _ := c.ports(j)
+		peers = append(peers, fmt.Sprintf("%s@127.0.0.1:%d", NodeKey(c.ChainID, j).ID(), port))
+	}
+	conf.P2P.PersistentPeers = strings.Join(peers, ",")
+	return conf
+}
+
+// GenerateTestnet writes the homes of n nodes in dir, dir/node0 to dir/node{n-1}. The keys and the genesis
+// depend only on the chain ID and the number of nodes, every node is a validator and a persistent peer of the others.
+func (c *Config) GenerateTestnet(dir string, n int, serverAddr string) error {
+	genesis, err := Genesis(c.ChainID, n)
+	if err != nil {
+		return err
+	}
+	controller, err := template.New("controller").Parse(c.ControllerTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid controller template: %s", err)
+	}
+	for i := 0; i < n; i++ {
+		home := filepath.Join(dir, NodeName(i))
+		conf := c.nodeConfig(home, i, n)
+		for _, d := range []string{filepath.Join(home, "config"), filepath.Join(home, "data")} {
+			if err := os.MkdirAll(d, 0755); err != nil {
+				return err
+			}
+		}
+		configFile := filepath.Join(home, "config", "config.toml")
+		cfg.WriteConfigFile(configFile, conf)
+
+		nodeKey := NodeKey(c.ChainID, i)
+		_, _, controllerPort := c.ports(i)
+		var section bytes.Buffer
+		err := controller.Execute(&section, controllerParams{
+			ServerAddr: serverAddr,
+			ListenAddr: fmt.Sprintf("127.0.0.1:%d", controllerPort),
+			Index:      i,
+			Name:       NodeName(i),
+			NodeID:     string(nodeKey.ID()),
+		})
+		if err != nil {
+			return fmt.Errorf("invalid controller template: %s", err)
+		}
+		if err := appendFile(configFile, section.Bytes()); err != nil {
+			return err
+		}
+
+		if err := nodeKey.SaveAs(conf.NodeKeyFile()); err != nil {
+			return err
+		}
+		privval.NewFilePV(ValidatorKey(c.ChainID, i), conf.PrivValidatorKeyFile(), conf.PrivValidatorStateFile()).Save()
+		if err := genesis.SaveAs(conf.GenesisFile()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
@@ -0,0 +1,352 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+type voteKey struct {
+	vType   util.MessageType
+	height  int
+	round   int
+	blockID string
+}
+
+// voteTally counts the distinct validators that voted for a block in a given height, round and vote type
+type voteTally struct {
+	votes map[voteKey]map[string]bool
+	lock  *sync.Mutex
+}
+
+func newVoteTally() *voteTally {
+	return &voteTally{
+		votes: make(map[voteKey]map[string]bool),
+		lock:  new(sync.Mutex),
+	}
+}
+
+// Add records the vote and returns false if the message is not a vote
+func (t *voteTally) Add(tMsg *util.TMessage) bool {
+	val, ok := util.GetVoteValidator(tMsg)
+	if !ok {
+		return false
+	}
+	blockID, ok := util.GetVoteBlockIDS(tMsg)
+	if !ok {
+		return false
+	}
+	height, round := tMsg.HeightRound()
+	key := voteKey{vType: tMsg.Type, height: height, round: round, blockID: blockID}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, ok := t.votes[key]; !ok {
+		t.votes[key] = make(map[string]bool)
+	}
+	t.votes[key][string(val)] = true
+	return true
+}
+
+func (t *voteTally) Count(vType util.MessageType, height, round int, blockID string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.votes[voteKey{vType: vType, height: height, round: round, blockID: blockID}])
+}
+
+// IsQuorum returns true if the validators that voted for the block in the height and round have more than 2/3
+// of the voting power
+func (t *voteTally) IsQuorum(vType util.MessageType, height, round int, blockID string, power *votingPower) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return power.quorum(t.votes[voteKey{vType: vType, height: height, round: round, blockID: blockID}])
+}
+
+// QuorumRound returns the first round of the height in which the validators that voted for the block, together
+// with the validators in with, have more than 2/3 of the voting power
+func (t *voteTally) QuorumRound(vType util.MessageType, height int, blockID string, power *votingPower, with ...string) (int, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	quorumRound := -1
+	for key, vals := range t.votes {
+		if key.vType != vType || key.height != height || key.blockID != blockID {
+			continue
+		}
+		if (quorumRound == -1 || key.round < quorumRound) && power.quorum(vals, with...) {
+			quorumRound = key.round
+		}
+	}
+	return quorumRound, quorumRound != -1
+}
+
+// votingPower has the voting power of the validators by address
+type votingPower struct {
+	powers map[string]int64
+	total  int64
+}
+
+// getVotingPower returns the voting power of the validators of the network. Until all the replicas are
+// registered every replica has a voting power of 1.
+func getVotingPower(c *testlib.Context) *votingPower {
+	power := &votingPower{powers: make(map[string]int64)}
+	if vals, ok := getValidators(c); ok {
+		for _, val := range vals.valSet.Validators {
+			power.powers[string(val.Address)] = val.VotingPower
+		}
+		power.total = vals.valSet.TotalVotingPower()
+		return power
+	}
+	for _, r := range c.Replicas.Iter() {
+		if addr, err := util.GetReplicaAddress(r); err == nil {
+			power.powers[string(addr)] = 1
+		}
+	}
+	power.total = int64(c.Replicas.Cap())
+	return power
+}
+
+// quorum is true if the validators, counted once each, have more than 2/3 of the voting power
+func (p *votingPower) quorum(vals map[string]bool, with ...string) bool {
+	counted := make(map[string]bool, len(vals)+len(with))
+	var sum int64
+	for _, val := range append(keys(vals), with...) {
+		if !counted[val] {
+			counted[val] = true
+			sum += p.powers[val]
+		}
+	}
+	return 3*sum > 2*p.total
+}
+
+func keys(vals map[string]bool) []string {
+	result := make([]string, 0, len(vals))
+	for val := range vals {
+		result = append(result, val)
+	}
+	return result
+}
+
+// rounds returns the rounds of the height in which each validator voted for the block
+func (t *voteTally) rounds(vType util.MessageType, height int, blockID string) map[string][]int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	result := make(map[string][]int)
+	for key, vals := range t.votes {
+		if key.vType != vType || key.height != height || key.blockID != blockID {
+			continue
+		}
+		for val := range vals {
+			result[val] = append(result[val], key.round)
+		}
+	}
+	return result
+}
+
+type heightCommit struct {
+	replica types.ReplicaID
+	blockID string
+}
+
+// Fork is a height at which two replicas committed different blocks
+type Fork struct {
+	Height   int
+	BlockIDs [2]string
+	Replicas [2]types.ReplicaID
+	// Faulty are the replicas whose validators equivocated at the height, they precommitted both blocks in
+	// the same round or prevoted one block after precommitting the other without a polka for it in between
+	Faulty []types.ReplicaID
+}
+
+type agreementState struct {
+	// prevotes and precommits delivered to any replica
+	prevotes   *voteTally
+	precommits *voteTally
+	// precommits delivered to every replica
+	delivered map[types.ReplicaID]*voteTally
+	commits   map[int]*heightCommit
+	forks     []*Fork
+	lock      *sync.Mutex
+}
+
+func getAgreementState(c *testlib.Context) *agreementState {
+	sI, ok := c.Vars.Get("agreementMonitor")
+	if !ok {
+		c.Vars.Set("agreementMonitor", &agreementState{
+			prevotes:   newVoteTally(),
+			precommits: newVoteTally(),
+			delivered:  make(map[types.ReplicaID]*voteTally),
+			commits:    make(map[int]*heightCommit),
+			lock:       new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("agreementMonitor")
+	}
+	return sI.(*agreementState)
+}
+
+// AllowForks makes AgreementMonitor record the forks of the testcase instead of failing it,
+// for testcases where a fork is the expected outcome. The forks are returned by GetForks.
+func AllowForks(c *testlib.Context) {
+	c.Vars.Set("allowForks", true)
+}
+
+// GetForks returns the forks recorded by AgreementMonitor
+func GetForks(c *testlib.Context) []*Fork {
+	state := getAgreementState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	return append([]*Fork{}, state.forks...)
+}
+
+func (s *agreementState) tally(replica types.ReplicaID) *voteTally {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t, ok := s.delivered[replica]
+	if !ok {
+		t = newVoteTally()
+		s.delivered[replica] = t
+	}
+	return t
+}
+
+// isJustified returns true if there is a polka for the block in the rounds [from, to) of the height
+func (s *agreementState) isJustified(height, from, to int, blockID string, power *votingPower) bool {
+	for r := from; r < to; r++ {
+		if s.prevotes.IsQuorum(util.Prevote, height, r, blockID, power) {
+			return true
+		}
+	}
+	return false
+}
+
+// equivocators returns the validators that precommitted a and then voted for b without a justifying polka
+func (s *agreementState) equivocators(height int, a, b string, power *votingPower) map[string]bool {
+	result := make(map[string]bool)
+	precommitsB := s.precommits.rounds(util.Precommit, height, b)
+	prevotesB := s.prevotes.rounds(util.Prevote, height, b)
+	for val, roundsA := range s.precommits.rounds(util.Precommit, height, a) {
+		for _, ra := range roundsA {
+			for _, rb := range precommitsB[val] {
+				if rb == ra {
+					result[val] = true
+				}
+			}
+			for _, rb := range prevotesB[val] {
+				if rb > ra && !s.isJustified(height, ra, rb, b, power) {
+					result[val] = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (s *agreementState) newFork(c *testlib.Context, height int, prev *heightCommit, commit *util.CommitEvent) *Fork {
+	fork := &Fork{
+		Height:   height,
+		BlockIDs: [2]string{prev.blockID, commit.BlockID},
+		Replicas: [2]types.ReplicaID{prev.replica, commit.Replica},
+		Faulty:   make([]types.ReplicaID, 0),
+	}
+	power := getVotingPower(c)
+	vals := s.equivocators(height, prev.blockID, commit.BlockID, power)
+	for val := range s.equivocators(height, commit.BlockID, prev.blockID, power) {
+		vals[val] = true
+	}
+	for val := range vals {
+		if r, ok := util.GetReplicaByAddress(c.Replicas, []byte(val)); ok {
+			fork.Faulty = append(fork.Faulty, r.ID)
+		}
+	}
+	sort.Slice(fork.Faulty, func(i, j int) bool { return fork.Faulty[i] < fork.Faulty[j] })
+	return fork
+}
+
+func safetyViolation(c *testlib.Context, reason string, params log.LogParams) {
+	c.Logger().With(params).Error(reason)
+	util.AddReportLog(c, reason, params)
+	c.Vars.Set("safetyViolation", reason)
+	util.Abort(c)
+}
+
+// AgreementMonitor fails the testcase when two replicas commit different blocks at the same height
+// or when a replica commits a block without precommits for it of more than 2/3 of the voting power.
+// Only the precommits delivered to the committing replica count, together with its own precommit which
+// it does not receive and might send after the commit. A vote changed by the testcase counts as delivered,
+// the original vote does not. A block that has already been committed by another replica needs no quorum,
+// replicas might catch up through the blockchain reactor which is not intercepted.
+func AgreementMonitor(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	state := getAgreementState(c)
+	if e.IsMessageReceive() {
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if ok && tMsg.Type == util.Precommit {
+			state.precommits.Add(tMsg)
+			state.tally(tMsg.To).Add(tMsg)
+		} else if ok && tMsg.Type == util.Prevote {
+			state.prevotes.Add(tMsg)
+		}
+		return []*types.Message{}, false
+	}
+	commit, ok := GetCommit(e, c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+
+	state.lock.Lock()
+	prev, committed := state.commits[commit.Height]
+	if !committed {
+		state.commits[commit.Height] = &heightCommit{replica: commit.Replica, blockID: commit.BlockID}
+	}
+	state.lock.Unlock()
+
+	if committed {
+		if prev.blockID == commit.BlockID {
+			return []*types.Message{}, false
+		}
+		state.lock.Lock()
+		for _, f := range state.forks {
+			if f.Height == commit.Height && f.BlockIDs[1] == commit.BlockID {
+				state.lock.Unlock()
+				return []*types.Message{}, false
+			}
+		}
+		fork := state.newFork(c, commit.Height, prev, commit)
+		state.forks = append(state.forks, fork)
+		state.lock.Unlock()
+		params := log.LogParams{
+			"height":         commit.Height,
+			"replica":        commit.Replica,
+			"block_id":       commit.BlockID,
+			"other_replica":  prev.replica,
+			"other_block_id": prev.blockID,
+			"faulty":         fork.Faulty,
+		}
+		if allow, _ := c.Vars.GetBool("allowForks"); allow {
+			c.Logger().With(params).Info("Fork")
+			util.AddReportLog(c, "Fork", params)
+			return []*types.Message{}, false
+		}
+		safetyViolation(c, "Agreement violated: different blocks committed", params)
+		return []*types.Message{}, false
+	}
+	power := getVotingPower(c)
+	own := make([]string, 0, 1)
+	if replica, ok := c.Replicas.Get(commit.Replica); ok {
+		if addr, err := util.GetReplicaAddress(replica); err == nil {
+			own = append(own, string(addr))
+		}
+	}
+	if _, ok := state.tally(commit.Replica).QuorumRound(util.Precommit, commit.Height, commit.BlockID, power, own...); !ok {
+		safetyViolation(c, "Agreement violated: block committed without a precommit quorum", log.LogParams{
+			"height":   commit.Height,
+			"replica":  commit.Replica,
+			"block_id": commit.BlockID,
+			"quorum":   fmt.Sprintf("> 2/3 of %d", power.total),
+		})
+	}
+	return []*types.Message{}, false
+}
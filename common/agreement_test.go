@@ -0,0 +1,132 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestAgreementMonitor(t *testing.T) {
+	a, b := testkit.BlockID("a"), testkit.BlockID("b")
+	cases := []struct {
+		name string
+		// events returns the events of the testcase, in the order the monitor sees them
+		events   func(k *testkit.Kit) []*types.Event
+		expected bool
+	}{
+		{"commit before the own precommit is sent", func(k *testkit.Kit) []*types.Event {
+			events := deliver(k, 0, 1, a, 1, 2)
+			events = append(events, k.Commit(0, 1, a))
+			_, e := k.SendTo(k.Precommit(0, 1, 0, a), 1)
+			return append(events, e)
+		}, false},
+		{"no quorum", func(k *testkit.Kit) []*types.Event {
+			return append(deliver(k, 0, 1, a, 1), k.Commit(0, 1, a))
+		}, true},
+		{"precommits sent but not delivered", func(k *testkit.Kit) []*types.Event {
+			events := make([]*types.Event, 0)
+			for _, i := range []int{1, 2, 3} {
+				_, e := k.SendTo(k.Precommit(i, 1, 0, a), 0)
+				events = append(events, e)
+			}
+			return append(events, k.Commit(0, 1, a))
+		}, true},
+		{"precommit changed to nil", func(k *testkit.Kit) []*types.Event {
+			events := deliver(k, 0, 1, a, 1)
+			_, e := k.SendTo(k.Precommit(2, 1, 1, a), 0)
+			changed := k.Message(k.Precommit(2, 1, 1, ttypes.BlockID{}), 0)
+			return append(events, e, k.Receive(changed), k.Commit(0, 1, a))
+		}, true},
+		{"precommits of different rounds", func(k *testkit.Kit) []*types.Event {
+			events := append(deliver(k, 0, 0, a, 1), deliver(k, 0, 1, a, 2)...)
+			return append(events, k.Commit(0, 1, a))
+		}, true},
+		{"catch up on a committed block", func(k *testkit.Kit) []*types.Event {
+			events := append(deliver(k, 0, 1, a, 1, 2), k.Commit(0, 1, a))
+			return append(events, k.Commit(3, 1, a))
+		}, false},
+		{"catch up on another block", func(k *testkit.Kit) []*types.Event {
+			events := append(deliver(k, 0, 1, a, 1, 2), k.Commit(0, 1, a))
+			return append(events, k.Commit(3, 1, b))
+		}, true},
+		{"fork", func(k *testkit.Kit) []*types.Event {
+			events := append(deliver(k, 0, 1, a, 2, 3), k.Commit(0, 1, a))
+			events = append(events, deliver(k, 1, 1, b, 2, 3)...)
+			return append(events, k.Commit(1, 1, b))
+		}, true},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range tc.events(k) {
+			AgreementMonitor(e, k.Context)
+		}
+		if k.Aborted() != tc.expected {
+			t.Errorf("%s: expected aborted %v, got %v", tc.name, tc.expected, k.Aborted())
+		}
+	}
+}
+
+// deliver returns the events of the precommits for the block in the round of height 1 of the replicas
+// from, sent and delivered to replica to
+func deliver(k *testkit.Kit, to, round int, blockID ttypes.BlockID, from ...int) []*types.Event {
+	events := make([]*types.Event, 0, 2*len(from))
+	for _, i := range from {
+		m, e := k.SendTo(k.Precommit(i, 1, round, blockID), to)
+		events = append(events, e, k.Receive(m))
+	}
+	return events
+}
+
+func TestAgreementMonitorAllowForks(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AllowForks(k.Context)
+	a, b := testkit.BlockID("a"), testkit.BlockID("b")
+	events := append(deliver(k, 0, 0, a, 2, 3), k.Commit(0, 1, a))
+	events = append(events, deliver(k, 1, 0, b, 2, 3)...)
+	events = append(events, k.Commit(1, 1, b), k.Commit(2, 1, b))
+	for _, e := range events {
+		AgreementMonitor(e, k.Context)
+	}
+	if k.Aborted() {
+		t.Fatal("expected the fork to be recorded without failing the testcase")
+	}
+	forks := GetForks(k.Context)
+	if len(forks) != 1 {
+		t.Fatalf("expected one fork, got %d", len(forks))
+	}
+	// node2 and node3 precommitted both blocks in round 0
+	if forks[0].Height != 1 || !reflect.DeepEqual(forks[0].Faulty, []types.ReplicaID{k.ID(2), k.ID(3)}) {
+		t.Errorf("expected the fork at height 1 attributed to node2 and node3, got %+v", forks[0])
+	}
+}
+
+func TestVotingPower(t *testing.T) {
+	power := &votingPower{powers: map[string]int64{"a": 3, "b": 1, "c": 1, "d": 1}, total: 6}
+	cases := []struct {
+		name     string
+		vals     map[string]bool
+		with     []string
+		expected bool
+	}{
+		{"no votes", map[string]bool{}, nil, false},
+		{"most of the power", map[string]bool{"a": true, "b": true}, nil, false},
+		{"more than 2/3", map[string]bool{"a": true, "b": true}, []string{"c"}, true},
+		{"counted once", map[string]bool{"a": true, "b": true}, []string{"a", "b"}, false},
+		{"unknown validator", map[string]bool{"a": true, "b": true, "e": true}, nil, false},
+		{"all but the largest", map[string]bool{"b": true, "c": true, "d": true}, nil, false},
+	}
+	for _, tc := range cases {
+		if got := power.quorum(tc.vals, tc.with...); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+	sm "github.com/tendermint/tendermint/state"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type blockTime struct {
+	blockID string
+	time    time.Time
+	// false when the time is read from a replica event which has a precision of seconds
+	precise bool
+}
+
+type bftTimeState struct {
+	// precommit timestamps delivered to the replicas, by height, block and validator
+	precommits map[int]map[string]map[string]time.Time
+	blocks     map[int]*blockTime
+	checked    int
+	lock       *sync.Mutex
+}
+
+func getBFTTimeState(c *testlib.Context) *bftTimeState {
+	sI, ok := c.Vars.Get("bftTimeMonitor")
+	if !ok {
+		c.Vars.Set("bftTimeMonitor", &bftTimeState{
+			precommits: make(map[int]map[string]map[string]time.Time),
+			blocks:     make(map[int]*blockTime),
+			lock:       new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("bftTimeMonitor")
+	}
+	return sI.(*bftTimeState)
+}
+
+func (s *bftTimeState) addPrecommit(tMsg *util.TMessage) {
+	val, ok := util.GetVoteValidator(tMsg)
+	if !ok {
+		return
+	}
+	blockID, _ := util.GetVoteBlockIDS(tMsg)
+	voteTime, _ := util.GetVoteTime(tMsg)
+	height := tMsg.Height()
+	if _, ok := s.precommits[height]; !ok {
+		s.precommits[height] = make(map[string]map[string]time.Time)
+	}
+	if _, ok := s.precommits[height][blockID]; !ok {
+		s.precommits[height][blockID] = make(map[string]time.Time)
+	}
+	s.precommits[height][blockID][string(val)] = voteTime
+}
+
+// observedMedian returns the power weighted median of the timestamps of the precommits delivered for the block.
+// It is the time of the next block only if its last commit has the precommits of all the validators, the proposer
+// can commit with any set of more than 2/3 of the precommits. The second return value is false if the precommits
+// of some validators were not delivered.
+func (s *bftTimeState) observedMedian(height int, blockID string, valSet *ttypes.ValidatorSet) (time.Time, bool) {
+	times := s.precommits[height][blockID]
+	commit := &ttypes.Commit{Signatures: make([]ttypes.CommitSig, 0, valSet.Size())}
+	for _, val := range valSet.Validators {
+		t, ok := times[string(val.Address)]
+		if !ok {
+			return time.Time{}, false
+		}
+		commit.Signatures = append(commit.Signatures, ttypes.CommitSig{
+			BlockIDFlag:      ttypes.BlockIDFlagCommit,
+			ValidatorAddress: val.Address,
+			Timestamp:        t,
+		})
+	}
+	return sm.MedianTime(commit, valSet), true
+}
+
+// BFTTimeMonitor checks the time of every committed block. If the block has been reassembled
+// from the observed block parts, its time should be the power weighted median of the precommit
+// timestamps in its `LastCommit`. Otherwise the last commit is unknown and a time that differs from the
+// weighted median of the precommits delivered for the previous block is only logged.
+// Block times should increase with the height.
+// The timestamps of the precommits can be changed arbitrarily by the testcase.
+func BFTTimeMonitor(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	vals, ok := getValidators(c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+	state := getBFTTimeState(c)
+	if e.IsMessageSend() || e.IsMessageReceive() {
+		recordBlocks(e, c)
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if ok && e.IsMessageReceive() && tMsg.Type == util.Precommit {
+			state.lock.Lock()
+			state.addPrecommit(tMsg)
+			state.lock.Unlock()
+		}
+		return []*types.Message{}, false
+	}
+	commit, ok := GetCommit(e, c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if _, ok := state.blocks[commit.Height]; ok {
+		return []*types.Message{}, false
+	}
+	cur := &blockTime{blockID: commit.BlockID, time: commit.Time}
+	params := log.LogParams{
+		"height":   commit.Height,
+		"replica":  commit.Replica,
+		"block_id": commit.BlockID,
+	}
+	if block, ok := GetBlock(c, commit.BlockID); ok {
+		cur.time = block.Time
+		cur.precise = true
+		if commit.Height > 1 {
+			median := sm.MedianTime(block.LastCommit, vals.valSet)
+			if !block.Time.Equal(median) {
+				params["block_time"] = block.Time.String()
+				params["median_time"] = median.String()
+				safetyViolation(c, "BFT time violated: block time is not the weighted median of the last commit", params)
+				return []*types.Message{}, false
+			}
+		}
+	} else if cur.time.IsZero() {
+		c.Logger().With(params).Debug("Block time not available")
+		return []*types.Message{}, false
+	}
+	state.blocks[commit.Height] = cur
+	state.checked++
+
+	prev, ok := state.blocks[commit.Height-1]
+	if !ok {
+		return []*types.Message{}, false
+	}
+	params["block_time"] = cur.time.String()
+	params["previous_block_time"] = prev.time.String()
+	if !cur.precise {
+		// the last commit of the block might not have all the delivered precommits
+		median, ok := state.observedMedian(commit.Height-1, prev.blockID, vals.valSet)
+		if ok && !cur.time.Equal(median.Truncate(time.Second)) {
+			params["median_time"] = median.String()
+			c.Logger().With(params).Info("Block time is not the weighted median of the delivered precommits")
+		}
+	}
+	if cur.precise && prev.precise {
+		ok = cur.time.After(prev.time)
+	} else {
+		ok = !cur.time.Truncate(time.Second).Before(prev.time.Truncate(time.Second))
+	}
+	if !ok {
+		safetyViolation(c, "BFT time violated: block time did not increase", params)
+	}
+	return []*types.Message{}, false
+}
+
+// BFTTimeHolds returns true if the BFTTimeMonitor checked the time of at least one block
+// and found no violation
+func BFTTimeHolds(c *testlib.Context) bool {
+	sI, ok := c.Vars.Get("bftTimeMonitor")
+	if !ok || c.Vars.Exists("safetyViolation") {
+		return false
+	}
+	state := sI.(*bftTimeState)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	return state.checked > 0
+}
+
+// SkewPrecommitTimes changes the timestamp of the precommits sent by every replica by the skew
+// of the replica, simulating replicas with different clocks. The changed precommits are
+// re-signed and delivered.
+func SkewPrecommitTimes(skew func(*testlib.Context, types.ReplicaID) time.Duration) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if !e.IsMessageSend() {
+			return []*types.Message{}, false
+		}
+		message, ok := c.GetMessage(e)
+		if !ok {
+			return []*types.Message{}, false
+		}
+		tMsg, ok := util.GetParsedMessage(message)
+		if !ok || tMsg.Type != util.Precommit {
+			return []*types.Message{}, false
+		}
+		d := skew(c, message.From)
+		if d == 0 {
+			return []*types.Message{}, false
+		}
+		replica, ok := c.Replicas.Get(message.From)
+		if !ok {
+			return []*types.Message{}, false
+		}
+		curTime, _ := util.GetVoteTime(tMsg)
+		newVote, err := util.ChangeVoteTime(replica, tMsg, curTime.Add(d))
+		if err != nil {
+			c.Logger().With(log.LogParams{"error": err}).Warn("Could not change vote time")
+			return []*types.Message{}, false
+		}
+		newMsgB, err := newVote.Marshal()
+		if err != nil {
+			return []*types.Message{}, false
+		}
+		newMsg := c.NewMessage(message, newMsgB)
+		newMsg.Parse(&util.TMessageParser{})
+		return []*types.Message{newMsg}, true
+	}
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// blockRecorder is shared by the monitors that need the blocks reassembled from the messages.
+// Every event is added to the store once, irrespective of the number of monitors.
+type blockRecorder struct {
+	store *util.BlockStore
+	seen  map[uint64]bool
+	lock  *sync.Mutex
+}
+
+func getBlockRecorder(c *testlib.Context) *blockRecorder {
+	rI, ok := c.Vars.Get("blockStore")
+	if !ok {
+		c.Vars.Set("blockStore", &blockRecorder{
+			store: util.NewBlockStore(),
+			seen:  make(map[uint64]bool),
+			lock:  new(sync.Mutex),
+		})
+		rI, _ = c.Vars.Get("blockStore")
+	}
+	return rI.(*blockRecorder)
+}
+
+// recordBlocks adds the proposal or block part of the event to the block store
+func recordBlocks(e *types.Event, c *testlib.Context) {
+	if !e.IsMessageSend() && !e.IsMessageReceive() {
+		return
+	}
+	recorder := getBlockRecorder(c)
+	recorder.lock.Lock()
+	if recorder.seen[e.ID] {
+		recorder.lock.Unlock()
+		return
+	}
+	recorder.seen[e.ID] = true
+	recorder.lock.Unlock()
+
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok || (tMsg.Type != util.Proposal && tMsg.Type != util.BlockPart) {
+		return
+	}
+	if block, ok := recorder.store.Add(tMsg); ok {
+		c.Logger().With(log.LogParams{
+			"height":   block.Height,
+			"block_id": block.Hash().String(),
+		}).Debug("Assembled block")
+	}
+}
+
+// GetBlock returns the block with the hash if it has been reassembled from the observed messages
+func GetBlock(c *testlib.Context, hash string) (*ttypes.Block, bool) {
+	return getBlockRecorder(c).store.Block(hash)
+}
+
+// RecordBlocks reassembles the blocks from the messages for testcases that do not run with the default monitors.
+// The event is not handled.
+func RecordBlocks(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	recordBlocks(e, c)
+	return []*types.Message{}, false
+}
@@ -28,9 +28,26 @@ func addFN(c *testlib.Context) {
 	c.Vars.Set("faults", f)
 }
 
+// NewPartitioner returns the partitioner of the testcase, seeded if the testcase is set up with WithPartitionSeed
+func NewPartitioner(c *testlib.Context) *util.GenericPartitioner {
+	if seed, ok := c.Vars.Get("partitionSeed"); ok {
+		return util.NewSeededPartitioner(c.Replicas, seed.(int64))
+	}
+	return util.NewGenericPartitioner(c.Replicas)
+}
+
+// WithPartitionSeed calls the setup function with the partitions created by NewPartitioner depending only on the seed,
+// so that the instances of a testcase can be repeated with the same partition or run over different partitions
+func WithPartitionSeed(seed int64, setup func(*testlib.Context) error) func(*testlib.Context) error {
+	return func(c *testlib.Context) error {
+		c.Vars.Set("partitionSeed", seed)
+		return setup(c)
+	}
+}
+
 func partition(c *testlib.Context) {
 	f := int((c.Replicas.Cap() - 1) / 3)
-	partitioner := util.NewGenericPartitioner(c.Replicas)
+	partitioner := NewPartitioner(c)
 	partition, _ := partitioner.CreatePartition(
 		[]int{1, f, 2 * f},
 		[]string{"h", "faulty", "rest"},
@@ -9,9 +9,20 @@ import (
 	"github.com/ds-test-framework/tendermint-test/util"
 )
 
+const (
+	// ProposerPart is the proposer of the height and round of the message
+	ProposerPart = "proposer"
+	// NonProposersPart are the replicas other than the proposer of the height and round of the message
+	NonProposersPart = "non-proposers"
+)
+
+// ProposerOf is the label of the part with the proposer of the height and round
+func ProposerOf(height, round int) string {
+	return fmt.Sprintf("proposer(%d,%d)", height, round)
+}
+
 func IsCommit(e *types.Event, _ *testlib.Context) bool {
-	eType, ok := e.Type.(*types.GenericEventType)
-	return ok && eType.T == "Committing block"
+	return util.IsEventType(e, util.CommitEventType)
 }
 
 func IsMessageFromRound(round int) handlers.Condition {
@@ -34,11 +45,7 @@ func IsVoteFromPart(partS string) handlers.Condition {
 			return false
 		}
 
-		partition, ok := getPartition(c)
-		if !ok {
-			return false
-		}
-		part, ok := partition.GetPart(partS)
+		part, ok := getPart(c, partS, m)
 		if !ok {
 			return false
 		}
@@ -63,17 +70,54 @@ func getPartition(c *testlib.Context) (*util.Partition, bool) {
 	return partition, ok
 }
 
+// getPart returns the part with the label from the partition of the testcase.
+// The proposer parts are computed from the validator set for the height and round of the message
+// or the height and round in the label.
+func getPart(c *testlib.Context, label string, m *util.TMessage) (*util.Part, bool) {
+	var height, round int
+	switch label {
+	case ProposerPart, NonProposersPart:
+		height, round = m.HeightRound()
+	default:
+		if _, err := fmt.Sscanf(label, "proposer(%d,%d)", &height, &round); err != nil {
+			partition, ok := getPartition(c)
+			if !ok {
+				return nil, false
+			}
+			return partition.GetPart(label)
+		}
+	}
+	if height < 1 || round < 0 {
+		return nil, false
+	}
+	vals, ok := getValidators(c)
+	if !ok {
+		return nil, false
+	}
+	proposer, ok := util.GetProposerReplica(c.Replicas, vals.valSet, height, round)
+	if !ok {
+		return nil, false
+	}
+	part := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: label}
+	if label != NonProposersPart {
+		part.ReplicaSet.Add(proposer)
+		return part, true
+	}
+	for _, r := range c.Replicas.Iter() {
+		if r.ID != proposer.ID {
+			part.ReplicaSet.Add(r)
+		}
+	}
+	return part, true
+}
+
 func IsFromPart(partS string) handlers.Condition {
 	return func(e *types.Event, c *testlib.Context) bool {
 		m, ok := util.GetMessageFromEvent(e, c)
 		if !ok {
 			return false
 		}
-		partition, ok := getPartition(c)
-		if !ok {
-			return false
-		}
-		part, ok := partition.GetPart(partS)
+		part, ok := getPart(c, partS, m)
 		if !ok {
 			return false
 		}
@@ -87,11 +131,7 @@ func IsToPart(partS string) handlers.Condition {
 		if !ok {
 			return false
 		}
-		partition, ok := getPartition(c)
-		if !ok {
-			return false
-		}
-		part, ok := partition.GetPart(partS)
+		part, ok := getPart(c, partS, m)
 		if !ok {
 			return false
 		}
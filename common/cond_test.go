@@ -0,0 +1,267 @@
+package common
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestConditions(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Partition(map[string][]int{"faulty": {1}, "rest": {0, 2, 3}})
+	proposer := k.Proposer(1, 0)
+	blockID := testkit.BlockID("a")
+	send := func(tMsg *util.TMessage, to int) *types.Event {
+		_, e := k.SendTo(tMsg, to)
+		return e
+	}
+
+	cases := []struct {
+		name     string
+		cond     handlers.Condition
+		event    *types.Event
+		expected bool
+	}{
+		{"prevote from faulty", IsVoteFromFaulty(), send(k.Prevote(1, 1, 0, blockID), 0), true},
+		{"precommit from faulty", IsVoteFromFaulty(), send(k.Precommit(1, 1, 0, blockID), 0), true},
+		{"prevote from rest", IsVoteFromFaulty(), send(k.Prevote(0, 1, 0, blockID), 1), false},
+		{"proposal from faulty", IsVoteFromFaulty(), send(k.Proposal(1, 1, 0, -1, blockID), 0), false},
+		{"vote in rest", IsVoteFromPart("rest"), send(k.Prevote(2, 1, 0, blockID), 1), true},
+		{"unknown part", IsVoteFromPart("honest"), send(k.Prevote(2, 1, 0, blockID), 1), false},
+		{"commit", IsVoteFromFaulty(), k.Commit(1, 1, blockID), false},
+		{"from proposer", IsFromPart(ProposerPart), send(k.Proposal(proposer, 1, 0, -1, blockID), 1), true},
+		{"from non-proposer", IsFromPart(ProposerPart), send(k.Prevote((proposer+1)%4, 1, 0, blockID), proposer), false},
+		{"non-proposers", IsFromPart(NonProposersPart), send(k.Prevote((proposer+1)%4, 1, 0, blockID), proposer), true},
+		{"to proposer of round 1", IsToPart(ProposerOf(1, 1)), send(k.Prevote(2, 1, 0, blockID), k.Proposer(1, 1)), true},
+		{"to faulty", IsToPart("faulty"), send(k.Prevote(2, 1, 0, blockID), 0), false},
+		{"proposal type", IsMessageType(util.Proposal), send(k.Proposal(proposer, 1, 0, -1, blockID), 1), true},
+		{"prevote type", IsMessageType(util.Proposal), send(k.Prevote(2, 1, 0, blockID), 1), false},
+		{"round 1", IsMessageFromRound(1), send(k.Precommit(2, 1, 1, blockID), 1), true},
+		{"round 0", IsMessageFromRound(1), send(k.Precommit(2, 1, 0, blockID), 1), false},
+		{"is commit", IsCommit, k.Commit(0, 1, blockID), true},
+		{"on commit", OnCommit(2), k.Commit(0, 1, blockID), false},
+	}
+	for _, tc := range cases {
+		if got := tc.cond(tc.event, k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestWhen(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, e := k.SendTo(k.Precommit(0, 1, 0, testkit.BlockID("a")), 1)
+	k.Context.Vars.Set("curState", "delayed")
+	for _, state := range []string{"delayed", "other"} {
+		h := When(handlers.InState(state), ChangeVoteToNil)
+		if _, ok := h(e, k.Context); ok != (state == "delayed") {
+			t.Errorf("in state %s: expected handled %v, got %v", state, state == "delayed", ok)
+		}
+	}
+}
+
+func TestChangeVoteToNil(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockID := testkit.BlockID("a")
+
+	_, e := k.SendTo(k.Prevote(1, 1, 0, blockID), 0)
+	messages, ok := ChangeVoteToNil(e, k.Context)
+	if !ok || len(messages) != 1 {
+		t.Fatalf("expected the changed vote, got %v", testkit.IDs(messages))
+	}
+	changed, ok := util.GetParsedMessage(messages[0])
+	if !ok {
+		t.Fatal("could not parse the changed vote")
+	}
+	if id, _ := util.GetVoteBlockIDS(changed); id != "" {
+		t.Errorf("expected a nil vote, got %s", id)
+	}
+
+	_, e = k.SendTo(k.Proposal(1, 1, 0, -1, blockID), 0)
+	if messages, ok := ChangeVoteToNil(e, k.Context); ok || len(messages) != 0 {
+		t.Errorf("expected a proposal to be ignored, got %v", testkit.IDs(messages))
+	}
+}
+
+func TestLockingMonitor(t *testing.T) {
+	blockID := testkit.BlockID("a")
+	cases := []struct {
+		name string
+		// polka are the replicas whose prevotes for the block are delivered to node0 before its precommit
+		polka    []int
+		faulty   bool
+		expected bool
+	}{
+		{"precommit after polka", []int{0, 1, 2}, false, false},
+		{"precommit without polka", []int{0, 1}, false, true},
+		{"faulty precommit without polka", []int{0}, true, false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.faulty {
+			k.Partition(map[string][]int{"faulty": {0}, "rest": {1, 2, 3}})
+		} else {
+			k.Partition(map[string][]int{"faulty": {}, "rest": {0, 1, 2, 3}})
+		}
+		events := make([]*types.Event, 0)
+		for _, i := range tc.polka {
+			if i == 0 {
+				_, e := k.SendTo(k.Prevote(0, 1, 0, blockID), 1)
+				events = append(events, e)
+				continue
+			}
+			m := k.Message(k.Prevote(i, 1, 0, blockID), 0)
+			events = append(events, k.Send(m), k.Receive(m))
+		}
+		_, e := k.SendTo(k.Precommit(0, 1, 0, blockID), 1)
+		events = append(events, e)
+
+		for _, e := range events {
+			LockingMonitor(e, k.Context)
+		}
+		if k.Aborted() != tc.expected {
+			t.Errorf("%s: expected aborted %v, got %v", tc.name, tc.expected, k.Aborted())
+		}
+	}
+}
+
+func TestLockingMonitorUnlock(t *testing.T) {
+	locked, other, prevoted := testkit.BlockID("a"), testkit.BlockID("b"), testkit.BlockID("c")
+	cases := []struct {
+		name string
+		// polka is the block of the polka delivered to node0 in round 1, nil if no polka is delivered
+		polka    *ttypes.BlockID
+		expected bool
+	}{
+		{"no polka", nil, true},
+		{"polka for the locked block", &locked, true},
+		{"polka for another block", &other, false},
+		{"polka for the prevoted block", &prevoted, false},
+		{"polka for nil", &ttypes.BlockID{}, false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Partition(map[string][]int{"faulty": {}, "rest": {0, 1, 2, 3}})
+		events := make([]*types.Event, 0)
+		polka := func(round int, blockID ttypes.BlockID) {
+			for i := 1; i < 4; i++ {
+				m := k.Message(k.Prevote(i, 1, round, blockID), 0)
+				events = append(events, k.Send(m), k.Receive(m))
+			}
+		}
+		polka(0, locked)
+		_, e := k.SendTo(k.Precommit(0, 1, 0, locked), 1)
+		events = append(events, e)
+		if tc.polka != nil {
+			polka(1, *tc.polka)
+		}
+		_, e = k.SendTo(k.Prevote(0, 1, 2, prevoted), 1)
+		events = append(events, e)
+
+		for _, e := range events {
+			LockingMonitor(e, k.Context)
+		}
+		if k.Aborted() != tc.expected {
+			t.Errorf("%s: expected aborted %v, got %v", tc.name, tc.expected, k.Aborted())
+		}
+	}
+}
+
+func TestGetCommit(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockID := testkit.BlockID("a")
+	withoutHeight := func(blockID ttypes.BlockID) *types.Event {
+		return types.NewEvent(k.ID(0), types.NewGenericEventType(map[string]string{
+			"block_id": blockID.Hash.String(),
+		}, string(util.CommitEventType)), "", 0, 0)
+	}
+
+	if _, ok := GetCommit(withoutHeight(blockID), k.Context); ok {
+		t.Error("expected no commit before the block is observed")
+	}
+	k.SendTo(k.Proposal(k.Proposer(3, 0), 3, 0, -1, blockID), 0)
+	cases := []struct {
+		name   string
+		event  *types.Event
+		height int
+		ok     bool
+	}{
+		{"with height", k.Commit(0, 2, blockID), 2, true},
+		{"height of the proposal", withoutHeight(blockID), 3, true},
+		{"unknown block", withoutHeight(testkit.BlockID("b")), 0, false},
+		{"not a commit", k.NewProposal(0, 3, 0, blockID), 0, false},
+	}
+	for _, tc := range cases {
+		commit, ok := GetCommit(tc.event, k.Context)
+		if ok != tc.ok || (ok && commit.Height != tc.height) {
+			t.Errorf("%s: expected %v at height %d, got %v %v", tc.name, tc.ok, tc.height, ok, commit)
+		}
+	}
+}
+
+func TestBFTTimeMonitor(t *testing.T) {
+	blockID := testkit.BlockID("a")
+	genesis := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		// precommits are the replicas whose precommits for the block of height 1 are delivered
+		precommits []int
+		// blockTime is the time of the block of height 2 reported by the replica
+		blockTime time.Time
+		expected  bool
+	}{
+		// the precommits of the testkit are 500ms after the genesis time, their median is reported as the genesis time
+		{"median of the precommits", []int{0, 1, 2, 3}, genesis, false},
+		// the last commit of the block is unknown, it might not have all the delivered precommits
+		{"not the median", []int{0, 1, 2, 3}, genesis.Add(time.Second), false},
+		{"time did not increase", []int{0, 1, 2, 3}, genesis.Add(-time.Second), true},
+		{"unknown last commit", []int{0, 1, 2}, genesis.Add(time.Second), false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events := make([]*types.Event, 0)
+		for _, i := range tc.precommits {
+			m := k.Message(k.Precommit(i, 1, 0, blockID), (i+1)%4)
+			events = append(events, k.Send(m), k.Receive(m))
+		}
+		events = append(events, k.Commit(0, 1, blockID), types.NewEvent(k.ID(0), types.NewGenericEventType(map[string]string{
+			"height":     "2",
+			"block_id":   testkit.BlockID("b").Hash.String(),
+			"block_time": strconv.FormatInt(tc.blockTime.Unix(), 10),
+		}, string(util.CommitEventType)), "", 0, 0))
+
+		for _, e := range events {
+			BFTTimeMonitor(e, k.Context)
+		}
+		if k.Aborted() != tc.expected {
+			t.Errorf("%s: expected aborted %v, got %v", tc.name, tc.expected, k.Aborted())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// CrashRecovery is the outcome of a crash of the replicas of a part
+type CrashRecovery struct {
+	Replicas []types.ReplicaID
+	// CrashHeight is the lowest height committed by the crashed replicas when they crashed
+	CrashHeight int
+	// RecoveryHeight is the highest height committed by the network when the replicas recovered
+	RecoveryHeight int
+	// MissedHeights is the number of heights committed by the network while the replicas were crashed
+	MissedHeights int
+	// Downtime and RecoveryTime are measured with the time of the events, in seconds
+	Downtime time.Duration
+	// RecoveryTime is the time from the recovery until every crashed replica committed the recovery height
+	RecoveryTime time.Duration
+	CaughtUp     bool
+}
+
+type crashState struct {
+	replicas    *util.ReplicaSet
+	crashed     bool
+	recovered   bool
+	crashedAt   time.Time
+	recoveredAt time.Time
+	// highest height committed by every replica
+	committed map[types.ReplicaID]int
+	caughtUp  map[types.ReplicaID]bool
+	result    *CrashRecovery
+	lock      *sync.Mutex
+}
+
+func getCrashState(c *testlib.Context, label string) *crashState {
+	key := "crash_" + label
+	sI, ok := c.Vars.Get(key)
+	if !ok {
+		c.Vars.Set(key, &crashState{
+			committed: make(map[types.ReplicaID]int),
+			caughtUp:  make(map[types.ReplicaID]bool),
+			lock:      new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get(key)
+	}
+	return sI.(*crashState)
+}
+
+func (s *crashState) crash(e *types.Event, c *testlib.Context, label string) bool {
+	partition, ok := getPartition(c)
+	if !ok {
+		return false
+	}
+	part, ok := partition.GetPart(label)
+	if !ok {
+		return false
+	}
+	s.replicas = part.ReplicaSet
+	s.crashed = true
+	s.crashedAt = util.EventTime(e)
+	s.result = &CrashRecovery{Replicas: part.ReplicaSet.Iter(), CrashHeight: -1}
+	for _, r := range s.result.Replicas {
+		if h := s.committed[r]; s.result.CrashHeight == -1 || h < s.result.CrashHeight {
+			s.result.CrashHeight = h
+		}
+	}
+	c.Logger().With(log.LogParams{
+		"part":   label,
+		"height": s.result.CrashHeight,
+	}).Info("Crashed replicas")
+	return true
+}
+
+func (s *crashState) recover(e *types.Event, c *testlib.Context, label string) {
+	s.recovered = true
+	s.recoveredAt = util.EventTime(e)
+	for _, h := range s.committed {
+		if h > s.result.RecoveryHeight {
+			s.result.RecoveryHeight = h
+		}
+	}
+	s.result.MissedHeights = s.result.RecoveryHeight - s.result.CrashHeight
+	s.result.Downtime = s.recoveredAt.Sub(s.crashedAt)
+	c.Logger().With(log.LogParams{
+		"part":           label,
+		"height":         s.result.RecoveryHeight,
+		"missed_heights": s.result.MissedHeights,
+	}).Info("Recovered replicas")
+}
+
+func (s *crashState) isCrashed(tMsg *util.TMessage) bool {
+	return s.crashed && !s.recovered && (s.replicas.Exists(tMsg.From) || s.replicas.Exists(tMsg.To))
+}
+
+// Crash models a crash of the replicas of the part. Every message to and from the replicas is dropped from the
+// first event that satisfies crash until the first event that satisfies recover, then messages flow again.
+// Once recovered, the replicas should catch up to the height committed by the network, through the
+// consensus catch up or the blockchain reactor. The outcome is returned by GetCrashRecovery and checked by CaughtUp.
+func Crash(label string, crash, recover handlers.Condition) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		state := getCrashState(c, label)
+		state.lock.Lock()
+		defer state.lock.Unlock()
+
+		if commit, ok := GetCommit(e, c); ok {
+			if commit.Height > state.committed[commit.Replica] {
+				state.committed[commit.Replica] = commit.Height
+			}
+			if state.recovered && !state.caughtUp[commit.Replica] && state.replicas.Exists(commit.Replica) &&
+				commit.Height >= state.result.RecoveryHeight {
+				state.caughtUp[commit.Replica] = true
+				if len(state.caughtUp) == state.replicas.Size() {
+					state.result.CaughtUp = true
+					state.result.RecoveryTime = util.EventTime(e).Sub(state.recoveredAt)
+				}
+			}
+		}
+		if !state.crashed && crash(e, c) && !state.crash(e, c, label) {
+			return []*types.Message{}, false
+		}
+		if state.crashed && !state.recovered && recover(e, c) {
+			state.recover(e, c, label)
+		}
+
+		if !e.IsMessageSend() {
+			return []*types.Message{}, false
+		}
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if !ok || !state.isCrashed(tMsg) {
+			return []*types.Message{}, false
+		}
+		return []*types.Message{}, true
+	}
+}
+
+// GetCrashRecovery returns the outcome of the crash of the part, false if the part has not crashed
+func GetCrashRecovery(c *testlib.Context, label string) (*CrashRecovery, bool) {
+	state := getCrashState(c, label)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if !state.crashed {
+		return nil, false
+	}
+	result := *state.result
+	return &result, true
+}
+
+// CaughtUp returns true if the replicas of the part crashed, recovered and committed the height
+// reached by the network at the recovery. The missed heights and the recovery time are recorded in the report.
+func CaughtUp(c *testlib.Context, label string) bool {
+	result, ok := GetCrashRecovery(c, label)
+	if !ok {
+		c.Logger().With(log.LogParams{"part": label}).Info("Replicas did not crash")
+		return false
+	}
+	params := log.LogParams{
+		"part":            label,
+		"replicas":        result.Replicas,
+		"crash_height":    result.CrashHeight,
+		"recovery_height": result.RecoveryHeight,
+		"missed_heights":  result.MissedHeights,
+		"downtime":        result.Downtime.String(),
+	}
+	if !result.CaughtUp {
+		c.Logger().With(params).Info("Replicas did not catch up")
+		util.AddReportLog(c, "Replicas did not catch up", params)
+		return false
+	}
+	params["recovery_time"] = result.RecoveryTime.String()
+	c.Logger().With(params).Info("Replicas caught up")
+	util.AddReportLog(c, "Replicas caught up", params)
+	return true
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+)
+
+func TestCrash(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	blockID := testkit.BlockID("a")
+	cases := []struct {
+		name string
+		// caughtUp is the height committed by the crashed replica after the recovery
+		caughtUp int
+		expected bool
+	}{
+		{"commit of the recovery height", 4, true},
+		{"commit of a height below the recovery height", 3, false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Partition(map[string][]int{"h": {3}, "rest": {0, 1, 2}})
+		handler := Crash("h", OnCommit(2), OnCommit(4))
+		// commit returns the commit of the height by the replica, d after the start
+		commit := func(i, height int, d time.Duration) *types.Event {
+			e := k.Commit(i, height, blockID)
+			e.Timestamp = start.Add(d).Unix()
+			return e
+		}
+		// send returns true if the message from replica from to replica to is dropped
+		send := func(from, to int) bool {
+			_, e := k.SendTo(k.Prevote(from, 1, 0, blockID), to)
+			_, handled := handler(e, k.Context)
+			return handled
+		}
+
+		if CaughtUp(k.Context, "h") {
+			t.Fatalf("%s: expected the replicas not to have crashed", tc.name)
+		}
+		events := []*types.Event{commit(3, 1, 0), commit(0, 1, 0), commit(0, 2, time.Second)}
+		for _, e := range events {
+			handler(e, k.Context)
+		}
+		if send(0, 1) || !send(0, 3) || !send(3, 1) {
+			t.Errorf("%s: expected the messages to and from the crashed replica dropped", tc.name)
+		}
+		for h := 3; h <= 4; h++ {
+			handler(commit(1, h, time.Duration(h)*time.Second), k.Context)
+		}
+		if send(0, 3) || send(3, 1) {
+			t.Errorf("%s: expected the messages to and from the recovered replica delivered", tc.name)
+		}
+		handler(commit(3, tc.caughtUp, 10*time.Second), k.Context)
+
+		result, ok := GetCrashRecovery(k.Context, "h")
+		if !ok {
+			t.Fatalf("%s: expected the replicas to have crashed", tc.name)
+		}
+		if result.CrashHeight != 1 || result.RecoveryHeight != 4 || result.MissedHeights != 3 || result.Downtime != 3*time.Second {
+			t.Errorf("%s: expected the crash at height 1 and the recovery at height 4 after 3s, got %+v", tc.name, result)
+		}
+		if got := CaughtUp(k.Context, "h"); got != tc.expected {
+			t.Errorf("%s: expected caught up %v, got %v", tc.name, tc.expected, got)
+		}
+		if tc.expected && result.RecoveryTime != 6*time.Second {
+			t.Errorf("%s: expected the recovery in 6s, got %s", tc.name, result.RecoveryTime)
+		}
+	}
+}
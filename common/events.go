@@ -0,0 +1,92 @@
+package common
+
+import (
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// GetCommit returns the commit event of e with its height. The height is an optional parameter of the commit
+// events, when the replica does not report it the height is the height of the proposal or precommits of the
+// committed block in the message pool. The second return value is false if the height is not known.
+func GetCommit(e *types.Event, c *testlib.Context) (*util.CommitEvent, bool) {
+	commit, ok := util.GetCommitEvent(e)
+	if !ok || commit.Height > 0 {
+		return commit, ok
+	}
+	height, ok := blockHeight(c, commit.BlockID)
+	if !ok {
+		c.Logger().With(log.LogParams{
+			"replica":  commit.Replica,
+			"block_id": commit.BlockID,
+		}).Warn("Unknown height of the committed block")
+		return nil, false
+	}
+	commit.Height = height
+	return commit, true
+}
+
+// blockHeight returns the height of the proposal or precommits of the block in the message pool
+func blockHeight(c *testlib.Context, blockID string) (int, bool) {
+	if !c.Vars.Exists("blockHeights") {
+		c.Vars.Set("blockHeights", make(map[string]int))
+	}
+	heightsI, _ := c.Vars.Get("blockHeights")
+	heights := heightsI.(map[string]int)
+	if height, ok := heights[blockID]; ok {
+		return height, true
+	}
+	for _, m := range c.MessagePool.Iter() {
+		tMsg, ok := util.GetParsedMessage(m)
+		if !ok {
+			continue
+		}
+		var id string
+		switch tMsg.Type {
+		case util.Proposal:
+			id, ok = util.GetProposalBlockIDS(tMsg)
+		case util.Precommit:
+			id, ok = util.GetVoteBlockIDS(tMsg)
+		default:
+			continue
+		}
+		if ok && id == blockID {
+			heights[blockID] = tMsg.Height()
+			return heights[blockID], true
+		}
+	}
+	return 0, false
+}
+
+// OnCommit is true when a replica commits a block at the specified height
+func OnCommit(height int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		commit, ok := GetCommit(e, c)
+		return ok && commit.Height == height
+	}
+}
+
+// OnNewProposal is true when a replica reports a new proposal that satisfies pred
+func OnNewProposal(pred func(*util.NewProposalEvent) bool) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		proposal, ok := util.GetNewProposalEvent(e)
+		return ok && pred(proposal)
+	}
+}
+
+// ValidateEvents reports unknown or malformed replica events in the testcase log and report.
+// The event is not handled.
+func ValidateEvents(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if err := util.ValidateEvent(e); err != nil {
+		params := log.LogParams{
+			"replica": e.Replica,
+			"event":   e.TypeS,
+			"error":   err.Error(),
+		}
+		c.Logger().With(params).Warn("Invalid replica event")
+		util.AddReportLog(c, "Invalid replica event", params)
+	}
+	return []*types.Message{}, false
+}
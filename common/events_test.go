@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func TestValidateEvents(t *testing.T) {
+	c := newTestContext()
+	newEvent := func(id uint64, eType string, params map[string]string) *types.Event {
+		return types.NewEvent("replica0", types.NewGenericEventType(params, eType), eType, id, 0)
+	}
+	events := []*types.Event{
+		newEvent(1, "Committing block", map[string]string{"height": "2", "block_id": "ABCD"}),
+		newEvent(2, "Committing block", map[string]string{"height": "2"}),
+		newEvent(3, "Commiting block", map[string]string{}),
+	}
+	for _, e := range events {
+		if _, handled := ValidateEvents(e, c); handled {
+			t.Errorf("expected event %d not handled", e.ID)
+		}
+	}
+	reportLog := util.GetReportLog(c.Vars)
+	if len(reportLog) != 2 || reportLog[0].Message != "Invalid replica event" || reportLog[1].Params["event"] != "Commiting block" {
+		t.Errorf("expected the malformed and the unknown event in the report log, got %v", reportLog)
+	}
+}
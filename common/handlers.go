@@ -50,7 +50,9 @@ func ChangeVoteToNil(e *types.Event, c *testlib.Context) ([]*types.Message, bool
 	if err != nil {
 		return []*types.Message{}, false
 	}
-	return []*types.Message{c.NewMessage(message, msgB)}, true
+	newMsg := c.NewMessage(message, msgB)
+	newMsg.Parse(&util.TMessageParser{})
+	return []*types.Message{newMsg}, true
 }
 
 func RecordMessage(label string) handlers.HandlerFunc {
@@ -63,3 +65,16 @@ func RecordMessage(label string) handlers.HandlerFunc {
 		return []*types.Message{}, true
 	}
 }
+
+// When calls the handler only if the condition is true, otherwise the event is not handled.
+// Can be used with handlers.InState to enable handlers in specific states.
+// It replaces handlers.If(cond).Then(h) of the scheduler, Then does not keep h and the returned handler
+// calls a nil handler once the condition is true.
+func When(cond handlers.Condition, h handlers.HandlerFunc) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if !cond(e, c) {
+			return []*types.Message{}, false
+		}
+		return h(e, c)
+	}
+}
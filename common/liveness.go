@@ -0,0 +1,181 @@
+package common
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+type livenessResult struct {
+	rounds   int
+	duration time.Duration
+}
+
+type livenessState struct {
+	rounds int
+	bound  time.Duration
+
+	stabilized   bool
+	stabilizedAt time.Time
+	// height that every correct replica should commit after stabilization
+	height     int
+	startRound int
+
+	committed map[types.ReplicaID]int
+	// highest round of the messages sent by a replica in every height
+	sentRounds map[types.ReplicaID]map[int]int
+	results    map[types.ReplicaID]*livenessResult
+	lock       *sync.Mutex
+}
+
+func getLivenessState(c *testlib.Context, rounds int, bound time.Duration) *livenessState {
+	sI, ok := c.Vars.Get("liveness")
+	if !ok {
+		c.Vars.Set("liveness", &livenessState{
+			rounds:     rounds,
+			bound:      bound,
+			committed:  make(map[types.ReplicaID]int),
+			sentRounds: make(map[types.ReplicaID]map[int]int),
+			results:    make(map[types.ReplicaID]*livenessResult),
+			lock:       new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("liveness")
+	}
+	return sI.(*livenessState)
+}
+
+func (s *livenessState) stabilize(e *types.Event, c *testlib.Context) {
+	s.stabilized = true
+	s.stabilizedAt = util.EventTime(e)
+	for replica, h := range s.committed {
+		if h > s.height && !isFaulty(c, replica) {
+			s.height = h
+		}
+	}
+	s.height = s.height + 1
+	for replica, rounds := range s.sentRounds {
+		if isFaulty(c, replica) {
+			continue
+		}
+		if r, ok := rounds[s.height]; ok && r > s.startRound {
+			s.startRound = r
+		}
+	}
+	c.Logger().With(log.LogParams{
+		"height": s.height,
+		"round":  s.startRound,
+	}).Info("Network stabilized")
+}
+
+func isFaulty(c *testlib.Context, replica types.ReplicaID) bool {
+	partition, ok := getPartition(c)
+	if !ok {
+		return false
+	}
+	faulty, ok := partition.GetPart("faulty")
+	return ok && faulty.Contains(replica)
+}
+
+// LivenessMonitor checks that the network makes progress once it is synchronous.
+// The network is considered synchronous from the first event that satisfies stabilized,
+// for example `handlers.InState(label)` of the testcase state machine.
+// From then on, every correct replica should commit the height following the highest height committed by
+// a correct replica within the specified number of rounds and the bound. The bound is measured with the
+// time of the events, in seconds, so that it holds when a trace is evaluated offline.
+// Replicas of the "faulty" part are not considered correct.
+// The result is evaluated by LivenessHolds, the event is not handled.
+func LivenessMonitor(stabilized handlers.Condition, rounds int, bound time.Duration) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		state := getLivenessState(c, rounds, bound)
+		tMsg, isMessage := util.GetMessageFromEvent(e, c)
+		commit, isCommit := GetCommit(e, c)
+		isStable := !state.stabilized && stabilized(e, c)
+
+		state.lock.Lock()
+		defer state.lock.Unlock()
+		if isMessage && e.IsMessageSend() {
+			if _, ok := state.sentRounds[tMsg.From]; !ok {
+				state.sentRounds[tMsg.From] = make(map[int]int)
+			}
+			height, round := tMsg.HeightRound()
+			if cur, ok := state.sentRounds[tMsg.From][height]; !ok || round > cur {
+				state.sentRounds[tMsg.From][height] = round
+			}
+		}
+		if isCommit && commit.Height > state.committed[commit.Replica] {
+			state.committed[commit.Replica] = commit.Height
+		}
+		if isStable {
+			state.stabilize(e, c)
+		}
+		if !state.stabilized || !isCommit || commit.Height != state.height {
+			return []*types.Message{}, false
+		}
+		if _, ok := state.results[commit.Replica]; ok {
+			return []*types.Message{}, false
+		}
+		result := &livenessResult{duration: util.EventTime(e).Sub(state.stabilizedAt)}
+		if r, ok := state.sentRounds[commit.Replica][commit.Height]; ok && r > state.startRound {
+			result.rounds = r - state.startRound
+		}
+		state.results[commit.Replica] = result
+		return []*types.Message{}, false
+	}
+}
+
+// LivenessHolds returns true if every correct replica committed the height following the
+// stabilization within the bounds of the LivenessMonitor. The rounds taken by every replica and
+// the replicas that lagged are recorded in the report.
+func LivenessHolds(c *testlib.Context) bool {
+	sI, ok := c.Vars.Get("liveness")
+	if !ok {
+		c.Logger().Info("Liveness not checked, no liveness monitor")
+		return false
+	}
+	state := sI.(*livenessState)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if !state.stabilized {
+		c.Logger().Info("Liveness not checked, network did not stabilize")
+		util.AddReportLog(c, "Network did not stabilize", log.LogParams{})
+		return false
+	}
+
+	roundsTaken := make(map[string]int)
+	lagging := make([]string, 0)
+	for _, replica := range c.Replicas.Iter() {
+		if isFaulty(c, replica.ID) {
+			continue
+		}
+		result, ok := state.results[replica.ID]
+		if !ok {
+			lagging = append(lagging, string(replica.ID))
+			continue
+		}
+		roundsTaken[string(replica.ID)] = result.rounds
+		if result.rounds > state.rounds || result.duration > state.bound {
+			lagging = append(lagging, string(replica.ID))
+		}
+	}
+	params := log.LogParams{
+		"height":       state.height,
+		"start_round":  state.startRound,
+		"rounds":       state.rounds,
+		"bound":        state.bound.String(),
+		"rounds_taken": roundsTaken,
+		"lagging":      lagging,
+	}
+	if len(lagging) != 0 {
+		c.Logger().With(params).Info("Liveness violated")
+		util.AddReportLog(c, "Liveness violated", params)
+		return false
+	}
+	c.Logger().With(params).Info("Liveness holds")
+	util.AddReportLog(c, "Liveness holds", params)
+	return true
+}
@@ -0,0 +1,103 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+)
+
+func TestLivenessMonitor(t *testing.T) {
+	blockID := testkit.BlockID("a")
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(e *types.Event, d time.Duration) *types.Event {
+		e.Timestamp = start.Add(d).Unix()
+		return e
+	}
+	cases := []struct {
+		name string
+		// events returns the events after the stabilization at start, node3 is faulty and the correct replicas
+		// committed height 1 before
+		events   func(k *testkit.Kit) []*types.Event
+		expected bool
+	}{
+		{"commit within the bounds", func(k *testkit.Kit) []*types.Event {
+			events := make([]*types.Event, 0)
+			for i := 0; i < 3; i++ {
+				_, e := k.SendTo(k.Prevote(i, 2, 1, blockID), (i+1)%3)
+				events = append(events, at(e, time.Second), at(k.Commit(i, 2, blockID), 5*time.Second))
+			}
+			return events
+		}, true},
+		{"commit after the bound", func(k *testkit.Kit) []*types.Event {
+			events := make([]*types.Event, 0)
+			for i := 0; i < 3; i++ {
+				events = append(events, at(k.Commit(i, 2, blockID), time.Duration(10+i)*time.Second))
+			}
+			return events
+		}, false},
+		{"commit after too many rounds", func(k *testkit.Kit) []*types.Event {
+			// the replicas were in round 1 when the network stabilized
+			_, e := k.SendTo(k.Prevote(0, 2, 4, blockID), 1)
+			events := []*types.Event{at(e, time.Second)}
+			for i := 0; i < 3; i++ {
+				events = append(events, at(k.Commit(i, 2, blockID), 5*time.Second))
+			}
+			return events
+		}, false},
+		{"replica without commit", func(k *testkit.Kit) []*types.Event {
+			return []*types.Event{at(k.Commit(0, 2, blockID), time.Second), at(k.Commit(1, 2, blockID), time.Second)}
+		}, false},
+		{"faulty replica without commit", func(k *testkit.Kit) []*types.Event {
+			events := make([]*types.Event, 0)
+			for i := 0; i < 3; i++ {
+				events = append(events, at(k.Commit(i, 2, blockID), time.Second))
+			}
+			return events
+		}, true},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Partition(map[string][]int{"faulty": {3}, "rest": {0, 1, 2}})
+		stable := at(k.Timeout(0, "TimeoutPropose", 2, 0, false), 0)
+		monitor := LivenessMonitor(func(e *types.Event, c *testlib.Context) bool {
+			return e == stable
+		}, 2, 9*time.Second)
+
+		// the faulty replica reports a higher height, the correct replicas should commit height 2
+		events := []*types.Event{at(k.Commit(3, 5, blockID), -time.Minute)}
+		for i := 0; i < 3; i++ {
+			_, e := k.SendTo(k.Prevote(i, 2, 1, blockID), 3)
+			events = append(events, at(k.Commit(i, 1, blockID), -time.Minute), at(e, -time.Second))
+		}
+		events = append(events, stable)
+		for _, e := range append(events, tc.events(k)...) {
+			monitor(e, k.Context)
+		}
+		if got := LivenessHolds(k.Context); got != tc.expected {
+			t.Errorf("%s: expected liveness %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestLivenessHoldsWithoutStabilization(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if LivenessHolds(k.Context) {
+		t.Error("expected liveness not to hold without a liveness monitor")
+	}
+	monitor := LivenessMonitor(func(*types.Event, *testlib.Context) bool { return false }, 2, time.Second)
+	for i := 0; i < 4; i++ {
+		monitor(k.Commit(i, 1, testkit.BlockID("a")), k.Context)
+	}
+	if LivenessHolds(k.Context) {
+		t.Error("expected liveness not to hold when the network did not stabilize")
+	}
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+type replicaLock struct {
+	height  int
+	round   int
+	blockID string
+}
+
+type lockingState struct {
+	// prevotes delivered to every replica, including its own
+	delivered map[types.ReplicaID]*voteTally
+	locks     map[types.ReplicaID]*replicaLock
+	addresses map[types.ReplicaID][]byte
+	lock      *sync.Mutex
+}
+
+func getLockingState(c *testlib.Context) *lockingState {
+	sI, ok := c.Vars.Get("lockingMonitor")
+	if !ok {
+		c.Vars.Set("lockingMonitor", &lockingState{
+			delivered: make(map[types.ReplicaID]*voteTally),
+			locks:     make(map[types.ReplicaID]*replicaLock),
+			addresses: make(map[types.ReplicaID][]byte),
+			lock:      new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("lockingMonitor")
+	}
+	return sI.(*lockingState)
+}
+
+func (s *lockingState) tally(replica types.ReplicaID) *voteTally {
+	t, ok := s.delivered[replica]
+	if !ok {
+		t = newVoteTally()
+		s.delivered[replica] = t
+	}
+	return t
+}
+
+// isOwnVote returns true if the vote is signed by the replica that sends it
+func (s *lockingState) isOwnVote(c *testlib.Context, tMsg *util.TMessage) bool {
+	addr, ok := s.addresses[tMsg.From]
+	if !ok {
+		replica, ok := c.Replicas.Get(tMsg.From)
+		if !ok {
+			return false
+		}
+		var err error
+		addr, err = util.GetReplicaAddress(replica)
+		if err != nil {
+			return false
+		}
+		s.addresses[tMsg.From] = addr
+	}
+	val, ok := util.GetVoteValidator(tMsg)
+	return ok && bytes.Equal(val, addr)
+}
+
+// polkaRound returns the highest round in (from, to) in which a polka for the block was delivered to the replica
+func (s *lockingState) polkaRound(replica types.ReplicaID, height, from, to int, blockID string, n int) (int, bool) {
+	t := s.tally(replica)
+	for r := to - 1; r > from; r-- {
+		if 3*t.Count(util.Prevote, height, r, blockID) > 2*n {
+			return r, true
+		}
+	}
+	return -1, false
+}
+
+// unlocked returns true if a polka for nil or a block other than the locked block was delivered to the replica
+// in a round in (from, to)
+func (s *lockingState) unlocked(replica types.ReplicaID, height, from, to int, lockedID string, n int) bool {
+	t := s.tally(replica)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for key, vals := range t.votes {
+		if key.vType != util.Prevote || key.height != height || key.round <= from || key.round >= to {
+			continue
+		}
+		if key.blockID != lockedID && 3*len(vals) > 2*n {
+			return true
+		}
+	}
+	return false
+}
+
+// LockingMonitor fails the testcase when a correct replica violates the locking rules of tendermint.
+//  1. A replica precommits a block only after a polka (more than 2/3 prevotes) for the block
+//     in the same round was delivered to it.
+//  2. A replica that precommitted a block in a round prevotes a different block in a later round
+//     only if it was unlocked by a polka for nil or any block other than the locked block, delivered to it
+//     in a round in between.
+//
+// Replicas of the "faulty" part are not checked.
+func LockingMonitor(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() && !e.IsMessageReceive() {
+		return []*types.Message{}, false
+	}
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok || (tMsg.Type != util.Prevote && tMsg.Type != util.Precommit) {
+		return []*types.Message{}, false
+	}
+	state := getLockingState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if e.IsMessageReceive() {
+		if tMsg.Type == util.Prevote {
+			state.tally(tMsg.To).Add(tMsg)
+		}
+		return []*types.Message{}, false
+	}
+	if !state.isOwnVote(c, tMsg) {
+		return []*types.Message{}, false
+	}
+	replica := tMsg.From
+	if tMsg.Type == util.Prevote {
+		state.tally(replica).Add(tMsg)
+	}
+	if isFaulty(c, replica) {
+		return []*types.Message{}, false
+	}
+	blockID, _ := util.GetVoteBlockIDS(tMsg)
+	if blockID == "" {
+		return []*types.Message{}, false
+	}
+
+	n := c.Replicas.Cap()
+	height, round := tMsg.HeightRound()
+	params := log.LogParams{
+		"replica":  replica,
+		"height":   height,
+		"round":    round,
+		"block_id": blockID,
+	}
+	lock, locked := state.locks[replica]
+	if locked && height < lock.height {
+		return []*types.Message{}, false
+	} else if locked && height > lock.height {
+		delete(state.locks, replica)
+		locked = false
+	}
+
+	switch tMsg.Type {
+	case util.Precommit:
+		if _, ok := state.polkaRound(replica, height, round-1, round+1, blockID, n); !ok {
+			safetyViolation(c, "Locking violated: precommit without a delivered polka", params)
+			return []*types.Message{}, false
+		}
+		if !locked || round >= lock.round {
+			state.locks[replica] = &replicaLock{height: height, round: round, blockID: blockID}
+		}
+	case util.Prevote:
+		if !locked || lock.blockID == blockID || round <= lock.round {
+			return []*types.Message{}, false
+		}
+		if state.unlocked(replica, height, lock.round, round, lock.blockID, n) {
+			return []*types.Message{}, false
+		}
+		params["locked_round"] = lock.round
+		params["locked_block_id"] = lock.blockID
+		safetyViolation(c, "Locking violated: prevote for a different block while locked", params)
+	}
+	return []*types.Message{}, false
+}
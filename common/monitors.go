@@ -0,0 +1,48 @@
+package common
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+var (
+	// DefaultMonitors observe every event of the testcase before its handler is called
+	DefaultMonitors = []handlers.HandlerFunc{ValidateEvents, AgreementMonitor, ValidityMonitor, LockingMonitor, ProposerMonitor}
+)
+
+type monitoredHandler struct {
+	monitors []handlers.HandlerFunc
+	handler  testlib.Handler
+}
+
+var _ testlib.Handler = &monitoredHandler{}
+
+func (m *monitoredHandler) HandleEvent(e *types.Event, c *testlib.Context) []*types.Message {
+	for _, monitor := range m.monitors {
+		monitor(e, c)
+	}
+	return m.handler.HandleEvent(e, c)
+}
+
+func (m *monitoredHandler) Name() string {
+	return m.handler.Name()
+}
+
+// Unwrap returns the handler of the testcase
+func (m *monitoredHandler) Unwrap() testlib.Handler {
+	return m.handler
+}
+
+// WithMonitors runs the default monitors along with the specified monitors for every testcase.
+// The messages returned by the monitors are ignored, monitors fail the testcase by aborting it.
+func WithMonitors(testcases []*testlib.TestCase, monitors ...handlers.HandlerFunc) []*testlib.TestCase {
+	all := append(append([]handlers.HandlerFunc{}, DefaultMonitors...), monitors...)
+	for _, t := range testcases {
+		t.Handler = &monitoredHandler{
+			monitors: all,
+			handler:  t.Handler,
+		}
+	}
+	return testcases
+}
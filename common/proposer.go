@@ -0,0 +1,81 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+type proposerState struct {
+	// signatures of the proposals that have been checked
+	checked map[string]bool
+	lock    *sync.Mutex
+}
+
+func getProposerState(c *testlib.Context) *proposerState {
+	sI, ok := c.Vars.Get("proposerMonitor")
+	if !ok {
+		c.Vars.Set("proposerMonitor", &proposerState{
+			checked: make(map[string]bool),
+			lock:    new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("proposerMonitor")
+	}
+	return sI.(*proposerState)
+}
+
+// ProposerMonitor fails the testcase when a proposal is signed by a validator other than
+// the proposer computed from the validator set for the height and round of the proposal.
+// Only the proposals sent by the replicas are checked, copies changed by the testcase are received events.
+// Proposals signed by replicas of the "faulty" part are ignored.
+func ProposerMonitor(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok || tMsg.Type != util.Proposal {
+		return []*types.Message{}, false
+	}
+	vals, ok := getValidators(c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+	state := getProposerState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	sig := string(tMsg.Data.GetProposal().Proposal.Signature)
+	if state.checked[sig] {
+		return []*types.Message{}, false
+	}
+	state.checked[sig] = true
+
+	height, round := tMsg.HeightRound()
+	expected := util.GetProposer(vals.valSet, height, round)
+	if util.VerifyProposal(vals.chainID, expected, tMsg) {
+		return []*types.Message{}, false
+	}
+	params := log.LogParams{
+		"height":   height,
+		"round":    round,
+		"replica":  tMsg.From,
+		"expected": expected.Address.String(),
+	}
+	if signer, ok := util.GetProposalSigner(vals.chainID, vals.valSet, tMsg); ok {
+		params["signer"] = signer.Address.String()
+		if r, ok := util.GetReplicaByAddress(c.Replicas, signer.Address); ok {
+			if isFaulty(c, r.ID) {
+				return []*types.Message{}, false
+			}
+			params["signer_replica"] = r.ID
+		}
+	}
+	if r, ok := util.GetProposerReplica(c.Replicas, vals.valSet, height, round); ok {
+		params["expected_replica"] = r.ID
+	}
+	safetyViolation(c, "Proposer selection violated: proposal not signed by the expected proposer", params)
+	return []*types.Message{}, false
+}
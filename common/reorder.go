@@ -0,0 +1,158 @@
+package common
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// Ordering returns the buffered messages in the order they should be delivered
+type Ordering func([]*types.Message) []*types.Message
+
+// Reversed delivers the messages in the reverse order of their sends
+func Reversed(messages []*types.Message) []*types.Message {
+	result := make([]*types.Message, len(messages))
+	for i, m := range messages {
+		result[len(messages)-1-i] = m
+	}
+	return result
+}
+
+// BySender delivers the messages sorted by sender, the messages of a sender in the order of their sends
+func BySender(messages []*types.Message) []*types.Message {
+	result := make([]*types.Message, len(messages))
+	copy(result, messages)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].From < result[j].From
+	})
+	return result
+}
+
+// RandomOrder shuffles the messages, the orders of a testcase depend only on the seed and the buffered messages
+func RandomOrder(seed int64) Ordering {
+	r := rand.New(rand.NewSource(seed))
+	lock := new(sync.Mutex)
+	return func(messages []*types.Message) []*types.Message {
+		result := make([]*types.Message, len(messages))
+		copy(result, messages)
+		lock.Lock()
+		r.Shuffle(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+		lock.Unlock()
+		return result
+	}
+}
+
+// VotesBeforeProposal delivers the votes first followed by the rest of the messages.
+// Proposals and block parts are delivered last.
+func VotesBeforeProposal(messages []*types.Message) []*types.Message {
+	result := make([]*types.Message, len(messages))
+	copy(result, messages)
+	rank := func(m *types.Message) int {
+		tMsg, ok := util.GetParsedMessage(m)
+		if !ok {
+			return 1
+		}
+		switch tMsg.Type {
+		case util.Prevote, util.Precommit:
+			return 0
+		case util.Proposal, util.BlockPart:
+			return 2
+		}
+		return 1
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return rank(result[i]) < rank(result[j])
+	})
+	return result
+}
+
+type messageBuffer struct {
+	messages []*types.Message
+	lock     *sync.Mutex
+}
+
+func newMessageBuffer() *messageBuffer {
+	return &messageBuffer{
+		messages: make([]*types.Message, 0),
+		lock:     new(sync.Mutex),
+	}
+}
+
+func (b *messageBuffer) Add(m *types.Message) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.messages = append(b.messages, m)
+}
+
+func (b *messageBuffer) Size() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.messages)
+}
+
+func (b *messageBuffer) Flush() []*types.Message {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	result := b.messages
+	b.messages = make([]*types.Message, 0)
+	return result
+}
+
+func getMessageBuffer(c *testlib.Context, label string) *messageBuffer {
+	bufKey := "reorderBuffer_" + label
+	bI, ok := c.Vars.Get(bufKey)
+	if !ok {
+		c.Vars.Set(bufKey, newMessageBuffer())
+		bI, _ = c.Vars.Get(bufKey)
+	}
+	return bI.(*messageBuffer)
+}
+
+// BufferSize is true when the reorder buffer with the specified label holds at least n messages
+func BufferSize(label string, n int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		return getMessageBuffer(c, label).Size() >= n
+	}
+}
+
+// ReorderMessages buffers the messages that satisfy cond and delivers them
+// in the order specified by order once release is true.
+// The window is re-armed after every release. When the release is triggered by a message send
+// that is not buffered, that message is delivered after the buffered messages.
+func ReorderMessages(label string, cond, release handlers.Condition, order Ordering) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		buffer := getMessageBuffer(c, label)
+		var current *types.Message = nil
+		buffered := false
+		if e.IsMessageSend() {
+			if message, ok := c.GetMessage(e); ok {
+				if cond(e, c) {
+					buffer.Add(message)
+					buffered = true
+				} else {
+					current = message
+				}
+			}
+		}
+		if buffer.Size() == 0 || !release(e, c) {
+			return []*types.Message{}, buffered
+		}
+		messages := order(buffer.Flush())
+		c.Logger().With(log.LogParams{
+			"label": label,
+			"count": len(messages),
+		}).Info("Releasing reordered messages")
+		if current != nil {
+			messages = append(messages, current)
+		}
+		return messages, true
+	}
+}
@@ -0,0 +1,128 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/config"
+	"github.com/ds-test-framework/scheduler/context"
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// newMessage returns a message of the type from the replica with the id, the orderings only read
+// the sender and the type of the parsed message
+func newMessage(id string, from types.ReplicaID, mType util.MessageType) *types.Message {
+	return &types.Message{
+		From:          from,
+		To:            "replica0",
+		Type:          string(mType),
+		ID:            id,
+		Intercept:     true,
+		ParsedMessage: &util.TMessage{From: from, To: "replica0", Type: mType},
+	}
+}
+
+func ids(messages []*types.Message) []string {
+	result := make([]string, len(messages))
+	for i, m := range messages {
+		result[i] = m.ID
+	}
+	return result
+}
+
+func TestOrderings(t *testing.T) {
+	prevote2 := newMessage("prevote2", "replica2", util.Prevote)
+	proposal := newMessage("proposal", "replica1", util.Proposal)
+	precommit3 := newMessage("precommit3", "replica3", util.Precommit)
+	prevote1 := newMessage("prevote1", "replica1", util.Prevote)
+	messages := []*types.Message{prevote2, proposal, precommit3, prevote1}
+	sent := ids(messages)
+
+	cases := []struct {
+		name     string
+		order    Ordering
+		input    []*types.Message
+		expected []*types.Message
+	}{
+		{"reversed", Reversed, messages, []*types.Message{prevote1, precommit3, proposal, prevote2}},
+		{"by sender", BySender, messages, []*types.Message{proposal, prevote1, prevote2, precommit3}},
+		{"votes before proposal", VotesBeforeProposal, messages, []*types.Message{prevote2, precommit3, prevote1, proposal}},
+		{"empty", Reversed, []*types.Message{}, []*types.Message{}},
+	}
+	for _, tc := range cases {
+		got := tc.order(tc.input)
+		if !reflect.DeepEqual(ids(got), ids(tc.expected)) {
+			t.Errorf("%s: expected %v, got %v", tc.name, ids(tc.expected), ids(got))
+		}
+		if !reflect.DeepEqual(ids(messages), sent) {
+			t.Fatalf("%s: the buffered messages were reordered in place", tc.name)
+		}
+	}
+}
+
+func TestRandomOrder(t *testing.T) {
+	messages := make([]*types.Message, 0)
+	for i := 0; i < 12; i++ {
+		messages = append(messages, newMessage(fmt.Sprintf("prevote%d", i), types.ReplicaID(fmt.Sprintf("replica%d", i%4)), util.Prevote))
+	}
+	first, second := RandomOrder(7), RandomOrder(7)
+	for i := 0; i < 3; i++ {
+		a, b := ids(first(messages)), ids(second(messages))
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("release %d: expected the same order for the same seed, got %v and %v", i, a, b)
+		}
+		seen := make(map[string]bool)
+		for _, id := range a {
+			seen[id] = true
+		}
+		if len(a) != len(messages) || len(seen) != len(messages) {
+			t.Errorf("release %d: expected a permutation of the %d messages, got %v", i, len(messages), a)
+		}
+	}
+}
+
+func newTestContext() *testlib.Context {
+	logger := log.NewLogger(config.LogConfig{Path: os.DevNull, Format: "json"})
+	root := context.NewRootContext(&config.Config{NumReplicas: 4}, logger)
+	testcase := testlib.NewTestCase("reorder", time.Minute, &testlib.DoNothingHandler{})
+	testcase.Logger = logger
+	return testlib.NewContext(root, testcase, testlib.NewTestCaseReport(testcase.Name))
+}
+
+func TestReorderMessages(t *testing.T) {
+	cases := []struct {
+		name    string
+		release handlers.Condition
+		// expected are the messages delivered on every send
+		expected [][]string
+		handled  []bool
+	}{
+		{"release at buffer size", BufferSize("prevotes", 2), [][]string{{}, {"prevote2", "prevote1"}, {}}, []bool{true, true, false}},
+		{"release on precommit", IsMessageType(util.Precommit), [][]string{{}, {}, {"prevote2", "prevote1", "precommit3"}}, []bool{true, true, true}},
+	}
+	for _, tc := range cases {
+		c := newTestContext()
+		h := ReorderMessages("prevotes", IsMessageType(util.Prevote), tc.release, Reversed)
+		sends := []*types.Message{
+			newMessage("prevote1", "replica1", util.Prevote),
+			newMessage("prevote2", "replica2", util.Prevote),
+			newMessage("precommit3", "replica3", util.Precommit),
+		}
+		for i, m := range sends {
+			c.MessagePool.Add(m)
+			e := types.NewEvent(m.From, types.NewMessageSendEventType(m.ID), "", uint64(i), time.Now().Unix())
+			messages, handled := h(e, c)
+			if !reflect.DeepEqual(ids(messages), tc.expected[i]) || handled != tc.handled[i] {
+				t.Errorf("%s: send %d: expected %v handled %v, got %v handled %v",
+					tc.name, i, tc.expected[i], tc.handled[i], ids(messages), handled)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func getCaptured(c *testlib.Context, label string) *messageBuffer {
+	return getMessageBuffer(c, "captured_"+label)
+}
+
+// CaptureMessages records the messages that satisfy cond under label.
+// The event is not handled and the message continues down the cascade.
+func CaptureMessages(label string, cond handlers.Condition) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if !e.IsMessageSend() || !cond(e, c) {
+			return []*types.Message{}, false
+		}
+		message, ok := c.GetMessage(e)
+		if !ok {
+			return []*types.Message{}, false
+		}
+		getCaptured(c, label).Add(message)
+		return []*types.Message{}, false
+	}
+}
+
+// CapturedCount is true once at least n messages have been captured under label
+func CapturedCount(label string, n int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		return getCaptured(c, label).Size() >= n
+	}
+}
+
+// ReplayMessages re-delivers every message captured under label `times` times, unchanged,
+// to its original recipient. The replay happens once, on the first event where trigger is true.
+// A message send that triggers the replay is delivered after the replayed messages.
+func ReplayMessages(label string, trigger handlers.Condition, times int) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		replayedKey := "replayed_" + label
+		if c.Vars.Exists(replayedKey) || !trigger(e, c) {
+			return []*types.Message{}, false
+		}
+		c.Vars.Set(replayedKey, true)
+
+		captured := getCaptured(c, label).Flush()
+		messages := make([]*types.Message, 0, len(captured)*times+1)
+		for i := 0; i < times; i++ {
+			for _, m := range captured {
+				messages = append(messages, replayCopy(c, m))
+			}
+		}
+		c.Logger().With(log.LogParams{
+			"label":    label,
+			"captured": len(captured),
+			"times":    times,
+		}).Info("Replaying captured messages")
+		if e.IsMessageSend() {
+			if message, ok := c.GetMessage(e); ok {
+				messages = append(messages, message)
+			}
+		}
+		return messages, true
+	}
+}
+
+func replayCopy(c *testlib.Context, m *types.Message) *types.Message {
+	replayed := c.NewMessage(m, m.Data)
+	replayed.Parse(&util.TMessageParser{})
+	return replayed
+}
+
+// IsReplayed is true once the messages captured under label have been replayed
+func IsReplayed(label string) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		return c.Vars.Exists("replayed_" + label)
+	}
+}
+
+// HeightReached is true once every replica has sent a message of height h or higher
+func HeightReached(h int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		countKey := fmt.Sprintf("heightCount_%d", h)
+		if !c.Vars.Exists(countKey) {
+			c.Vars.Set(countKey, make(map[types.ReplicaID]bool))
+		}
+		countI, _ := c.Vars.Get(countKey)
+		count := countI.(map[types.ReplicaID]bool)
+
+		if e.IsMessageSend() {
+			message, ok := util.GetMessageFromEvent(e, c)
+			if ok && message.Height() >= h {
+				count[message.From] = true
+				c.Vars.Set(countKey, count)
+			}
+		}
+		return len(count) == c.Replicas.Cap()
+	}
+}
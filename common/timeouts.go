@@ -0,0 +1,172 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// AnyHeight and AnyRound match timeouts irrespective of the height or round
+const (
+	AnyHeight = -1
+	AnyRound  = -1
+)
+
+func matchTimeout(timeout *util.Timeout, t util.TimeoutType, height, round int) bool {
+	return timeout.Type == t &&
+		(height == AnyHeight || timeout.Height == height) &&
+		(round == AnyRound || timeout.Round == round)
+}
+
+// OnTimeoutStart is true when a replica starts a timeout of type t at the height and round
+func OnTimeoutStart(t util.TimeoutType, height, round int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		timeout, ok := util.GetTimeout(e)
+		return ok && !timeout.Ended && matchTimeout(timeout, t, height, round)
+	}
+}
+
+// OnTimeoutEnd is true when a timeout of type t fires at a replica at the height and round
+func OnTimeoutEnd(t util.TimeoutType, height, round int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		timeout, ok := util.GetTimeout(e)
+		return ok && timeout.Ended && matchTimeout(timeout, t, height, round)
+	}
+}
+
+// TimedOutInRound is true when any consensus timeout fires in the specified round
+func TimedOutInRound(round int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		timeout, ok := util.GetTimeout(e)
+		return ok && timeout.Ended && timeout.Type != util.TimeoutCommit && timeout.Round == round
+	}
+}
+
+type timeoutLog struct {
+	fired  map[types.ReplicaID][]*util.Timeout
+	events map[uint64]bool
+	lock   *sync.Mutex
+}
+
+func newTimeoutLog() *timeoutLog {
+	return &timeoutLog{
+		fired:  make(map[types.ReplicaID][]*util.Timeout),
+		events: make(map[uint64]bool),
+		lock:   new(sync.Mutex),
+	}
+}
+
+func (l *timeoutLog) add(e *types.Event) {
+	timeout, ok := util.GetTimeout(e)
+	if !ok || !timeout.Ended {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.events[e.ID] {
+		return
+	}
+	l.events[e.ID] = true
+	l.fired[e.Replica] = append(l.fired[e.Replica], timeout)
+}
+
+func (l *timeoutLog) hasFired(replica types.ReplicaID, t util.TimeoutType, height, round int) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, timeout := range l.fired[replica] {
+		if matchTimeout(timeout, t, height, round) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *timeoutLog) firedInRound(round int) []*util.Timeout {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	result := make([]*util.Timeout, 0)
+	for _, timeouts := range l.fired {
+		for _, timeout := range timeouts {
+			if timeout.Type != util.TimeoutCommit && timeout.Round == round {
+				result = append(result, timeout)
+			}
+		}
+	}
+	return result
+}
+
+func getTimeoutLog(c *testlib.Context) *timeoutLog {
+	lI, ok := c.Vars.Get("timeoutLog")
+	if !ok {
+		c.Vars.Set("timeoutLog", newTimeoutLog())
+		lI, _ = c.Vars.Get("timeoutLog")
+	}
+	return lI.(*timeoutLog)
+}
+
+// RecordTimeouts keeps track of the timeouts that fired at each replica. The event is not handled.
+func RecordTimeouts(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	getTimeoutLog(c).add(e)
+	return []*types.Message{}, false
+}
+
+// NilPrevoteBeforeProposeTimeout is true when a replica of the part prevotes nil
+// before its propose timeout for that height and round fired.
+// The timeouts are the ones recorded by RecordTimeouts, which should run before the condition,
+// for example as a monitor with WithMonitors. Use it to transition to the fail state.
+func NilPrevoteBeforeProposeTimeout(partS string) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		if !e.IsMessageSend() {
+			return false
+		}
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if !ok || tMsg.Type != util.Prevote {
+			return false
+		}
+		replica, ok := c.Replicas.Get(tMsg.From)
+		if !ok || !util.IsVoteFrom(tMsg, replica) {
+			return false
+		}
+		partition, ok := getPartition(c)
+		if !ok {
+			return false
+		}
+		part, ok := partition.GetPart(partS)
+		if !ok || !part.Contains(tMsg.From) {
+			return false
+		}
+		blockID, ok := util.GetVoteBlockID(tMsg)
+		if !ok || len(blockID.Hash) != 0 {
+			return false
+		}
+		height, round := tMsg.HeightRound()
+		if getTimeoutLog(c).hasFired(tMsg.From, util.TimeoutPropose, height, round) {
+			return false
+		}
+		c.Logger().With(log.LogParams{
+			"replica": tMsg.From,
+			"height":  height,
+			"round":   round,
+		}).Info("Nil prevote before propose timeout")
+		return true
+	}
+}
+
+// NoTimeoutsInRound returns true if no consensus timeout recorded by RecordTimeouts fired in the round.
+// Can be used in the assertion of the testcase.
+func NoTimeoutsInRound(c *testlib.Context, round int) bool {
+	fired := getTimeoutLog(c).firedInRound(round)
+	for _, t := range fired {
+		c.Logger().With(log.LogParams{
+			"replica": t.Replica,
+			"type":    t.Type,
+			"height":  t.Height,
+			"round":   t.Round,
+		}).Info("Timeout fired")
+	}
+	return len(fired) == 0
+}
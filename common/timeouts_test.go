@@ -0,0 +1,109 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestTimeoutConditions(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name     string
+		cond     handlers.Condition
+		event    *types.Event
+		expected bool
+	}{
+		{"start", OnTimeoutStart(util.TimeoutPropose, 1, 0), k.Timeout(0, util.TimeoutPropose, 1, 0, false), true},
+		{"start of fired timeout", OnTimeoutStart(util.TimeoutPropose, 1, 0), k.Timeout(0, util.TimeoutPropose, 1, 0, true), false},
+		{"start in other round", OnTimeoutStart(util.TimeoutPropose, 1, 0), k.Timeout(0, util.TimeoutPropose, 1, 1, false), false},
+		{"end", OnTimeoutEnd(util.TimeoutPrevote, 2, 1), k.Timeout(0, util.TimeoutPrevote, 2, 1, true), true},
+		{"end of other type", OnTimeoutEnd(util.TimeoutPrevote, 2, 1), k.Timeout(0, util.TimeoutPrecommit, 2, 1, true), false},
+		{"end at any height", OnTimeoutEnd(util.TimeoutPrevote, AnyHeight, 1), k.Timeout(0, util.TimeoutPrevote, 5, 1, true), true},
+		{"end in any round", OnTimeoutEnd(util.TimeoutPrevote, 2, AnyRound), k.Timeout(0, util.TimeoutPrevote, 2, 3, true), true},
+		{"timed out in round", TimedOutInRound(1), k.Timeout(0, util.TimeoutPrecommit, 1, 1, true), true},
+		{"started in round", TimedOutInRound(1), k.Timeout(0, util.TimeoutPrecommit, 1, 1, false), false},
+		{"commit timeout in round", TimedOutInRound(1), k.Timeout(0, util.TimeoutCommit, 1, 1, true), false},
+		{"not a timeout", TimedOutInRound(0), k.Commit(0, 1, testkit.BlockID("a")), false},
+	}
+	for _, tc := range cases {
+		if got := tc.cond(tc.event, k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestNilPrevoteBeforeProposeTimeout(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Partition(map[string][]int{"h": {0}, "rest": {1, 2, 3}})
+	cond := NilPrevoteBeforeProposeTimeout("h")
+	record := func(e *types.Event) *types.Event {
+		RecordTimeouts(e, k.Context)
+		return e
+	}
+	send := func(tMsg *util.TMessage) *types.Event {
+		_, e := k.SendTo(tMsg, 1)
+		return record(e)
+	}
+
+	// the condition only reads the timeouts recorded by RecordTimeouts
+	if cond(k.Timeout(0, util.TimeoutPropose, 1, 0, true), k.Context) {
+		t.Error("expected a timeout not to satisfy the condition")
+	}
+	cases := []struct {
+		name     string
+		event    *types.Event
+		expected bool
+	}{
+		{"nil prevote before the timeout", send(k.Prevote(0, 1, 0, ttypes.BlockID{})), true},
+		{"prevote for a block", send(k.Prevote(0, 1, 0, testkit.BlockID("a"))), false},
+		{"nil prevote from rest", send(k.Prevote(1, 1, 0, ttypes.BlockID{})), false},
+		{"propose timeout of round 1", record(k.Timeout(0, util.TimeoutPropose, 1, 1, true)), false},
+		{"nil prevote after the timeout", send(k.Prevote(0, 1, 1, ttypes.BlockID{})), false},
+		{"timeout of another replica", record(k.Timeout(1, util.TimeoutPropose, 1, 2, true)), false},
+		{"nil prevote before its own timeout", send(k.Prevote(0, 1, 2, ttypes.BlockID{})), true},
+	}
+	for _, tc := range cases {
+		if got := cond(tc.event, k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestNoTimeoutsInRound(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*types.Event{
+		k.Timeout(0, util.TimeoutPropose, 1, 0, false),
+		k.Timeout(1, util.TimeoutCommit, 1, 0, true),
+		k.Timeout(2, util.TimeoutPrevote, 1, 1, true),
+	}
+	for _, e := range events {
+		if _, handled := RecordTimeouts(e, k.Context); handled {
+			t.Errorf("expected event %d not handled", e.ID)
+		}
+	}
+	// the same event recorded twice, such as by a monitor and a handler, is counted once
+	RecordTimeouts(events[2], k.Context)
+	if !NoTimeoutsInRound(k.Context, 0) {
+		t.Error("expected only a started and a commit timeout in round 0")
+	}
+	if NoTimeoutsInRound(k.Context, 1) {
+		t.Error("expected the prevote timeout of round 1")
+	}
+	if fired := getTimeoutLog(k.Context).firedInRound(1); len(fired) != 1 {
+		t.Errorf("expected one timeout fired in round 1, got %d", len(fired))
+	}
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type validatorInfo struct {
+	valSet  *ttypes.ValidatorSet
+	chainID string
+}
+
+// getValidators returns the validator set and the chain id of the network.
+// They are computed once all the replicas have been registered.
+func getValidators(c *testlib.Context) (*validatorInfo, bool) {
+	if vI, ok := c.Vars.Get("validators"); ok {
+		return vI.(*validatorInfo), true
+	}
+	if c.Replicas.Count() < c.Replicas.Cap() {
+		return nil, false
+	}
+	valSet, err := util.GetValidatorSet(c.Replicas)
+	if err != nil {
+		return nil, false
+	}
+	info := &validatorInfo{valSet: valSet}
+	for _, r := range c.Replicas.Iter() {
+		chainID, err := util.GetChainID(r)
+		if err != nil {
+			return nil, false
+		}
+		info.chainID = chainID
+		break
+	}
+	c.Vars.Set("validators", info)
+	return info, true
+}
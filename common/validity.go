@@ -0,0 +1,122 @@
+package common
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type validityState struct {
+	// proposed blocks per height with the addresses of the expected proposers that signed their proposals
+	proposed map[int]map[string][][]byte
+	// signatures of the proposals that have been verified
+	verified map[string]bool
+	lock     *sync.Mutex
+}
+
+func getValidityState(c *testlib.Context) *validityState {
+	sI, ok := c.Vars.Get("validityMonitor")
+	if !ok {
+		c.Vars.Set("validityMonitor", &validityState{
+			proposed: make(map[int]map[string][][]byte),
+			verified: make(map[string]bool),
+			lock:     new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("validityMonitor")
+	}
+	return sI.(*validityState)
+}
+
+func (s *validityState) addProposal(vals *validatorInfo, tMsg *util.TMessage) {
+	blockID, ok := util.GetProposalBlockID(tMsg)
+	if !ok || blockID.IsZero() {
+		return
+	}
+	sig := string(tMsg.Data.GetProposal().Proposal.Signature)
+	if s.verified[sig] {
+		return
+	}
+	height, round := tMsg.HeightRound()
+	proposer := util.GetProposer(vals.valSet, height, round)
+	if !util.VerifyProposal(vals.chainID, proposer, tMsg) {
+		return
+	}
+	s.verified[sig] = true
+	if _, ok := s.proposed[height]; !ok {
+		s.proposed[height] = make(map[string][][]byte)
+	}
+	hash := blockID.Hash.String()
+	s.proposed[height][hash] = append(s.proposed[height][hash], proposer.Address)
+}
+
+// invalidBlock returns the reason why the block reassembled from the messages is not the valid block of the
+// height proposed by one of the proposers, or false if it is
+func invalidBlock(block *ttypes.Block, height int, proposers [][]byte) (string, bool) {
+	if int(block.Height) != height {
+		return "Validity violated: committed block of a different height", true
+	}
+	if err := block.ValidateBasic(); err != nil {
+		return "Validity violated: committed block is invalid", true
+	}
+	for _, proposer := range proposers {
+		if bytes.Equal(block.ProposerAddress, proposer) {
+			return "", false
+		}
+	}
+	return "Validity violated: committed block was created by another validator than its proposer", true
+}
+
+// ValidityMonitor fails the testcase when a replica commits a block that was never proposed
+// by the expected proposer of the height and round. Proposals are verified against the
+// signature of the proposer and the blocks are reassembled from the block parts seen.
+// When the committed block was reassembled, it must also be of the committed height, pass the basic
+// validation of tendermint and have the proposer of one of its proposals as proposer.
+func ValidityMonitor(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	vals, ok := getValidators(c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+	state := getValidityState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if e.IsMessageSend() || e.IsMessageReceive() {
+		recordBlocks(e, c)
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if ok && tMsg.Type == util.Proposal {
+			state.addProposal(vals, tMsg)
+		}
+		return []*types.Message{}, false
+	}
+
+	commit, ok := GetCommit(e, c)
+	if !ok {
+		return []*types.Message{}, false
+	}
+	params := log.LogParams{
+		"height":   commit.Height,
+		"replica":  commit.Replica,
+		"block_id": commit.BlockID,
+	}
+	proposers, proposed := state.proposed[commit.Height][commit.BlockID]
+	block, assembled := GetBlock(c, commit.BlockID)
+	params["assembled"] = assembled
+	if !proposed {
+		safetyViolation(c, "Validity violated: committed block was not proposed by the expected proposer", params)
+		return []*types.Message{}, false
+	}
+	if !assembled {
+		return []*types.Message{}, false
+	}
+	if reason, invalid := invalidBlock(block, commit.Height, proposers); invalid {
+		params["block_height"] = block.Height
+		params["block_proposer"] = block.ProposerAddress.String()
+		safetyViolation(c, reason, params)
+	}
+	return []*types.Message{}, false
+}
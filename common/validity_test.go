@@ -0,0 +1,86 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestValidityMonitor(t *testing.T) {
+	cases := []struct {
+		name string
+		// events returns the events of the testcase, the last one commits the block at height 1
+		events func(k *testkit.Kit) []*types.Event
+		// violation is the reason of the safety violation, empty if the commit is valid
+		violation string
+	}{
+		{"block of the proposer", func(k *testkit.Kit) []*types.Event {
+			proposer := k.Proposer(1, 0)
+			_, parts, blockID := k.Block(1, proposer)
+			events := propose(k, proposer, 1, 0, blockID, parts)
+			return append(events, k.Commit(0, 1, blockID))
+		}, ""},
+		{"block of the proposer of round 1", func(k *testkit.Kit) []*types.Event {
+			proposer := k.Proposer(1, 1)
+			_, parts, blockID := k.Block(1, proposer)
+			events := propose(k, proposer, 1, 1, blockID, parts)
+			return append(events, k.Commit(0, 1, blockID))
+		}, ""},
+		{"proposed block without parts", func(k *testkit.Kit) []*types.Event {
+			blockID := testkit.BlockID("a")
+			events := propose(k, k.Proposer(1, 0), 1, 0, blockID, nil)
+			return append(events, k.Commit(0, 1, blockID))
+		}, ""},
+		{"block not proposed", func(k *testkit.Kit) []*types.Event {
+			return []*types.Event{k.Commit(0, 1, testkit.BlockID("a"))}
+		}, "Validity violated: committed block was not proposed by the expected proposer"},
+		{"proposal of another validator", func(k *testkit.Kit) []*types.Event {
+			other := (k.Proposer(1, 0) + 1) % 4
+			_, parts, blockID := k.Block(1, other)
+			events := propose(k, other, 1, 0, blockID, parts)
+			return append(events, k.Commit(0, 1, blockID))
+		}, "Validity violated: committed block was not proposed by the expected proposer"},
+		{"block created by another validator", func(k *testkit.Kit) []*types.Event {
+			proposer := k.Proposer(1, 0)
+			_, parts, blockID := k.Block(1, (proposer+1)%4)
+			events := propose(k, proposer, 1, 0, blockID, parts)
+			return append(events, k.Commit(0, 1, blockID))
+		}, "Validity violated: committed block was created by another validator than its proposer"},
+		{"block of another height", func(k *testkit.Kit) []*types.Event {
+			proposer := k.Proposer(1, 0)
+			_, parts, blockID := k.Block(2, proposer)
+			events := propose(k, proposer, 1, 0, blockID, parts)
+			return append(events, k.Commit(0, 1, blockID))
+		}, "Validity violated: committed block of a different height"},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range tc.events(k) {
+			ValidityMonitor(e, k.Context)
+		}
+		violation, _ := k.Context.Vars.GetString("safetyViolation")
+		if violation != tc.violation || k.Aborted() != (tc.violation != "") {
+			t.Errorf("%s: expected violation %q, got %q", tc.name, tc.violation, violation)
+		}
+	}
+}
+
+// propose returns the events of replica i sending the proposal of the block and its parts to node0
+func propose(k *testkit.Kit, i, height, round int, blockID ttypes.BlockID, parts *ttypes.PartSet) []*types.Event {
+	messages := []*util.TMessage{k.Proposal(i, height, round, -1, blockID)}
+	if parts != nil {
+		messages = append(messages, k.BlockParts(i, height, round, parts)...)
+	}
+	events := make([]*types.Event, 0, len(messages))
+	for _, tMsg := range messages {
+		_, e := k.SendTo(tMsg, 0)
+		events = append(events, e)
+	}
+	return events
+}
@@ -0,0 +1,156 @@
+package property
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// Send holds if the event is a message of the type being sent
+func Send(t util.MessageType) Formula {
+	return Atom(fmt.Sprintf("send(%s)", t), func(e *types.Event, c *testlib.Context) bool {
+		return e.IsMessageSend() && common.IsMessageType(t)(e, c)
+	})
+}
+
+// Deliver holds if the event is a message of the type being received
+func Deliver(t util.MessageType) Formula {
+	return Atom(fmt.Sprintf("deliver(%s)", t), func(e *types.Event, c *testlib.Context) bool {
+		return e.IsMessageReceive() && common.IsMessageType(t)(e, c)
+	})
+}
+
+// FromPart holds if the message of the event is from a replica of the part
+func FromPart(label string) Formula {
+	return Atom(fmt.Sprintf("from(%s)", label), common.IsFromPart(label))
+}
+
+// ToPart holds if the message of the event is to a replica of the part
+func ToPart(label string) Formula {
+	return Atom(fmt.Sprintf("to(%s)", label), common.IsToPart(label))
+}
+
+// Height holds if the message of the event is of the height
+func Height(h int) Formula {
+	return Atom(fmt.Sprintf("height(%d)", h), func(e *types.Event, c *testlib.Context) bool {
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		return ok && tMsg.Height() == h
+	})
+}
+
+// Round holds if the message of the event is of the round
+func Round(r int) Formula {
+	return Atom(fmt.Sprintf("round(%d)", r), common.IsMessageFromRound(r))
+}
+
+// Block holds if the proposal or vote of the event is for the block stored with the label in the testcase variables,
+// for example "oldProposal" in the lockedvalue testcases
+func Block(label string) Formula {
+	return Atom(fmt.Sprintf("block(%s)", label), func(e *types.Event, c *testlib.Context) bool {
+		blockID, ok := c.Vars.GetString(label)
+		if !ok {
+			return false
+		}
+		msgBlockID, ok := messageBlockID(e, c)
+		return ok && msgBlockID == blockID
+	})
+}
+
+// Commit holds if a replica commits the height
+func Commit(h int) Formula {
+	return Atom(fmt.Sprintf("commit(%d)", h), common.OnCommit(h))
+}
+
+// Polka holds once more than 2/3 of the replicas have sent a prevote for the labelled block in the same round.
+// The prevotes are recorded by the handler of the property, see Property.Handler.
+func Polka(label string) Formula {
+	return Atom(fmt.Sprintf("polka(%s)", label), func(e *types.Event, c *testlib.Context) bool {
+		blockID, ok := c.Vars.GetString(label)
+		if !ok || !e.IsMessageSend() {
+			return false
+		}
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if !ok || tMsg.Type != util.Prevote {
+			return false
+		}
+		voteBlockID, _ := util.GetVoteBlockIDS(tMsg)
+		if voteBlockID != blockID {
+			return false
+		}
+		return 3*getPrevotes(c).count(tMsg.Height(), tMsg.Round(), blockID) > 2*c.Replicas.Cap()
+	})
+}
+
+type prevoteKey struct {
+	height  int
+	round   int
+	blockID string
+}
+
+// prevotes are the validators that sent a prevote for a block in every height and round
+type prevotes struct {
+	votes map[prevoteKey]map[string]bool
+	lock  *sync.Mutex
+}
+
+func getPrevotes(c *testlib.Context) *prevotes {
+	sI, ok := c.Vars.Get("propertyPrevotes")
+	if !ok {
+		c.Vars.Set("propertyPrevotes", &prevotes{
+			votes: make(map[prevoteKey]map[string]bool),
+			lock:  new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("propertyPrevotes")
+	}
+	return sI.(*prevotes)
+}
+
+// recordPrevote records the prevote sent in the event. Recording the same prevote again has no effect,
+// the handlers of all the properties record it.
+func recordPrevote(e *types.Event, c *testlib.Context) {
+	if !e.IsMessageSend() {
+		return
+	}
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok || tMsg.Type != util.Prevote {
+		return
+	}
+	val, ok := util.GetVoteValidator(tMsg)
+	if !ok {
+		return
+	}
+	blockID, _ := util.GetVoteBlockIDS(tMsg)
+	key := prevoteKey{height: tMsg.Height(), round: tMsg.Round(), blockID: blockID}
+
+	p := getPrevotes(c)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.votes[key]; !ok {
+		p.votes[key] = make(map[string]bool)
+	}
+	p.votes[key][string(val)] = true
+}
+
+func (p *prevotes) count(height, round int, blockID string) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.votes[prevoteKey{height: height, round: round, blockID: blockID}])
+}
+
+func messageBlockID(e *types.Event, c *testlib.Context) (string, bool) {
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok {
+		return "", false
+	}
+	switch tMsg.Type {
+	case util.Proposal:
+		return util.GetProposalBlockIDS(tMsg)
+	case util.Prevote, util.Precommit:
+		return util.GetVoteBlockIDS(tMsg)
+	}
+	return "", false
+}
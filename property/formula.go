@@ -0,0 +1,366 @@
+package property
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+// Formula is a temporal formula over the events of a testcase run.
+// Formulas are evaluated online by progression: every event rewrites the formula
+// into the formula that the rest of the run should satisfy.
+// At the end of the run the remaining formula is evaluated over an empty trace.
+type Formula interface {
+	// Progress returns the formula that should hold after the event
+	Progress(*types.Event, *testlib.Context) Formula
+	// Accepts returns the truth value of the formula at the end of the run
+	Accepts() bool
+	String() string
+}
+
+type constant bool
+
+var (
+	// True holds on every run
+	True Formula = constant(true)
+	// False holds on no run
+	False Formula = constant(false)
+)
+
+func (f constant) Progress(_ *types.Event, _ *testlib.Context) Formula {
+	return f
+}
+
+func (f constant) Accepts() bool {
+	return bool(f)
+}
+
+func (f constant) String() string {
+	if f {
+		return "true"
+	}
+	return "false"
+}
+
+type atom struct {
+	name string
+	cond handlers.Condition
+}
+
+// Atom holds if the current event satisfies the condition. The condition should not change the testcase
+// variables, it is evaluated for every copy of the atom in the formula.
+func Atom(name string, cond handlers.Condition) Formula {
+	return &atom{name: name, cond: cond}
+}
+
+func (a *atom) Progress(e *types.Event, c *testlib.Context) Formula {
+	if a.cond(e, c) {
+		return True
+	}
+	return False
+}
+
+func (a *atom) Accepts() bool {
+	return false
+}
+
+func (a *atom) String() string {
+	return a.name
+}
+
+type not struct {
+	f Formula
+}
+
+// Not holds if f does not hold
+func Not(f Formula) Formula {
+	switch f {
+	case True:
+		return False
+	case False:
+		return True
+	}
+	if n, ok := f.(*not); ok {
+		return n.f
+	}
+	return &not{f: f}
+}
+
+func (n *not) Progress(e *types.Event, c *testlib.Context) Formula {
+	return Not(n.f.Progress(e, c))
+}
+
+func (n *not) Accepts() bool {
+	return !n.f.Accepts()
+}
+
+func (n *not) String() string {
+	return fmt.Sprintf("!(%s)", n.f)
+}
+
+type and struct {
+	fs []Formula
+}
+
+// And holds if all the formulas hold. The same formula is kept once, formulas with the same name are
+// distinct if they are created separately.
+func And(fs ...Formula) Formula {
+	result := make([]Formula, 0, len(fs))
+	seen := make(map[Formula]bool)
+	queue := append([]Formula{}, fs...)
+	for i := 0; i < len(queue); i++ {
+		f := queue[i]
+		if a, ok := f.(*and); ok {
+			queue = append(queue, a.fs...)
+			continue
+		}
+		switch f {
+		case False:
+			return False
+		case True:
+			continue
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		result = append(result, f)
+	}
+	switch len(result) {
+	case 0:
+		return True
+	case 1:
+		return result[0]
+	}
+	return &and{fs: result}
+}
+
+func (a *and) Progress(e *types.Event, c *testlib.Context) Formula {
+	next := make([]Formula, len(a.fs))
+	for i, f := range a.fs {
+		next[i] = f.Progress(e, c)
+		if next[i] == False {
+			return False
+		}
+	}
+	return And(next...)
+}
+
+func (a *and) Accepts() bool {
+	for _, f := range a.fs {
+		if !f.Accepts() {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *and) String() string {
+	return join(a.fs, " && ")
+}
+
+type or struct {
+	fs []Formula
+}
+
+// Or holds if any of the formulas hold. The same formula is kept once, formulas with the same name are
+// distinct if they are created separately.
+func Or(fs ...Formula) Formula {
+	result := make([]Formula, 0, len(fs))
+	seen := make(map[Formula]bool)
+	queue := append([]Formula{}, fs...)
+	for i := 0; i < len(queue); i++ {
+		f := queue[i]
+		if o, ok := f.(*or); ok {
+			queue = append(queue, o.fs...)
+			continue
+		}
+		switch f {
+		case True:
+			return True
+		case False:
+			continue
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		result = append(result, f)
+	}
+	switch len(result) {
+	case 0:
+		return False
+	case 1:
+		return result[0]
+	}
+	return &or{fs: result}
+}
+
+func (o *or) Progress(e *types.Event, c *testlib.Context) Formula {
+	next := make([]Formula, len(o.fs))
+	for i, f := range o.fs {
+		next[i] = f.Progress(e, c)
+		if next[i] == True {
+			return True
+		}
+	}
+	return Or(next...)
+}
+
+func (o *or) Accepts() bool {
+	for _, f := range o.fs {
+		if f.Accepts() {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *or) String() string {
+	return join(o.fs, " || ")
+}
+
+// Implies holds if b holds whenever a holds
+func Implies(a, b Formula) Formula {
+	return Or(Not(a), b)
+}
+
+type next struct {
+	f Formula
+}
+
+// Next holds if f holds from the next event, it does not hold on the last event of the run
+func Next(f Formula) Formula {
+	return &next{f: f}
+}
+
+func (n *next) Progress(_ *types.Event, _ *testlib.Context) Formula {
+	return n.f
+}
+
+func (n *next) Accepts() bool {
+	return false
+}
+
+func (n *next) String() string {
+	return fmt.Sprintf("X(%s)", n.f)
+}
+
+type always struct {
+	f Formula
+}
+
+// Always holds if f holds at every event of the run
+func Always(f Formula) Formula {
+	return &always{f: f}
+}
+
+func (a *always) Progress(e *types.Event, c *testlib.Context) Formula {
+	return And(a.f.Progress(e, c), a)
+}
+
+func (a *always) Accepts() bool {
+	return true
+}
+
+func (a *always) String() string {
+	return fmt.Sprintf("G(%s)", a.f)
+}
+
+type eventually struct {
+	f Formula
+}
+
+// Eventually holds if f holds at some event of the run
+func Eventually(f Formula) Formula {
+	return &eventually{f: f}
+}
+
+func (ev *eventually) Progress(e *types.Event, c *testlib.Context) Formula {
+	return Or(ev.f.Progress(e, c), ev)
+}
+
+func (ev *eventually) Accepts() bool {
+	return false
+}
+
+func (ev *eventually) String() string {
+	return fmt.Sprintf("F(%s)", ev.f)
+}
+
+type until struct {
+	a, b Formula
+	weak bool
+}
+
+// Until holds if a holds at every event until b holds, b should hold eventually
+func Until(a, b Formula) Formula {
+	return &until{a: a, b: b}
+}
+
+// WeakUntil holds if a holds at every event until b holds, b need not hold
+func WeakUntil(a, b Formula) Formula {
+	return &until{a: a, b: b, weak: true}
+}
+
+// NeverBefore holds if a does not hold at any event before b holds
+func NeverBefore(a, b Formula) Formula {
+	return WeakUntil(Not(a), b)
+}
+
+func (u *until) Progress(e *types.Event, c *testlib.Context) Formula {
+	return Or(u.b.Progress(e, c), And(u.a.Progress(e, c), u))
+}
+
+func (u *until) Accepts() bool {
+	return u.weak
+}
+
+func (u *until) String() string {
+	if u.weak {
+		return fmt.Sprintf("(%s) W (%s)", u.a, u.b)
+	}
+	return fmt.Sprintf("(%s) U (%s)", u.a, u.b)
+}
+
+type withEvent struct {
+	name string
+	f    func(*types.Event, *testlib.Context) Formula
+}
+
+// WithEvent creates the formula from the current event, for example to refer to the height of a commit.
+// The name identifies the formula and should be unique.
+//
+//	Always(WithEvent("commit(h) -> F(commit(h+1))", func(e *types.Event, c *testlib.Context) Formula {
+//		commit, ok := util.GetCommitEvent(e)
+//		if !ok {
+//			return True
+//		}
+//		return Next(Eventually(Commit(commit.Height + 1)))
+//	}))
+func WithEvent(name string, f func(*types.Event, *testlib.Context) Formula) Formula {
+	return &withEvent{name: name, f: f}
+}
+
+func (w *withEvent) Progress(e *types.Event, c *testlib.Context) Formula {
+	return w.f(e, c).Progress(e, c)
+}
+
+func (w *withEvent) Accepts() bool {
+	return false
+}
+
+func (w *withEvent) String() string {
+	return w.name
+}
+
+func join(fs []Formula, sep string) string {
+	strs := make([]string, len(fs))
+	for i, f := range fs {
+		strs[i] = "(" + f.String() + ")"
+	}
+	return strings.Join(strs, sep)
+}
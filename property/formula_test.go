@@ -0,0 +1,55 @@
+package property
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+func eventAtom(name string) Formula {
+	return Atom(name, func(e *types.Event, _ *testlib.Context) bool {
+		return e.TypeS == name
+	})
+}
+
+func run(f Formula, trace ...string) bool {
+	for i, t := range trace {
+		e := types.NewEvent("replica", types.NewGenericEventType(map[string]string{}, t), t, uint64(i), 0)
+		f = f.Progress(e, nil)
+	}
+	return f.Accepts()
+}
+
+func TestFormulaProgression(t *testing.T) {
+	a, b := eventAtom("a"), eventAtom("b")
+	cases := []struct {
+		name    string
+		formula Formula
+		trace   []string
+		holds   bool
+	}{
+		{"atom", a, []string{"a", "b"}, true},
+		{"atom on empty trace", a, []string{}, false},
+		{"always", Always(a), []string{"a", "a"}, true},
+		{"always violated", Always(a), []string{"a", "b", "a"}, false},
+		{"eventually", Eventually(b), []string{"a", "a", "b"}, true},
+		{"eventually pending", Eventually(b), []string{"a", "a"}, false},
+		{"until", Until(a, b), []string{"a", "a", "b", "c"}, true},
+		{"until pending", Until(a, b), []string{"a", "a"}, false},
+		{"weak until pending", WeakUntil(a, b), []string{"a", "a"}, true},
+		{"never before", NeverBefore(a, b), []string{"c", "b", "a"}, true},
+		{"never before violated", NeverBefore(a, b), []string{"c", "a", "b"}, false},
+		{"next", Next(b), []string{"a", "b"}, true},
+		{"response", Always(Implies(a, Next(Eventually(b)))), []string{"a", "c", "b", "a", "b"}, true},
+		{"response pending", Always(Implies(a, Next(Eventually(b)))), []string{"a", "b", "a"}, false},
+		{"not always", Not(Always(a)), []string{"a", "b"}, true},
+		{"atoms of the same name", And(a, Atom("a", func(*types.Event, *testlib.Context) bool { return false })), []string{"a"}, false},
+		{"same formula", Or(Always(a), Always(a)), []string{"a", "b"}, false},
+	}
+	for _, c := range cases {
+		if holds := run(c.formula, c.trace...); holds != c.holds {
+			t.Errorf("%s: %s on %v expected %v, got %v", c.name, c.formula, c.trace, c.holds, holds)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package property
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// TraceLength is the number of events kept as the counterexample of a failed property
+var TraceLength = 20
+
+// Property is a named formula that is evaluated online over the events of the testcase
+type Property struct {
+	Name    string
+	Formula Formula
+}
+
+func New(name string, f Formula) *Property {
+	return &Property{Name: name, Formula: f}
+}
+
+type propertyState struct {
+	cur      Formula
+	violated bool
+	// last TraceLength events, trace[next] is the oldest once the buffer is full
+	trace []string
+	next  int
+	lock  *sync.Mutex
+}
+
+func (s *propertyState) record(entry string) {
+	if len(s.trace) < TraceLength {
+		s.trace = append(s.trace, entry)
+		return
+	}
+	s.trace[s.next] = entry
+	s.next = (s.next + 1) % TraceLength
+}
+
+func (s *propertyState) excerpt() []string {
+	result := make([]string, 0, len(s.trace))
+	result = append(result, s.trace[s.next:]...)
+	return append(result, s.trace[:s.next]...)
+}
+
+func (p *Property) getState(c *testlib.Context) *propertyState {
+	key := "property_" + p.Name
+	sI, ok := c.Vars.Get(key)
+	if !ok {
+		c.Vars.Set(key, &propertyState{
+			cur:   p.Formula,
+			trace: make([]string, 0, TraceLength),
+			lock:  new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get(key)
+	}
+	return sI.(*propertyState)
+}
+
+func describe(e *types.Event, c *testlib.Context) string {
+	tMsg, ok := util.GetMessageFromEvent(e, c)
+	if !ok {
+		return fmt.Sprintf("%d %s %s", e.ID, e.Replica, e.TypeS)
+	}
+	height, round := tMsg.HeightRound()
+	desc := fmt.Sprintf("%d %s %s %s %s->%s h=%d r=%d", e.ID, e.Replica, e.TypeS, tMsg.Type, tMsg.From, tMsg.To, height, round)
+	if blockID, ok := messageBlockID(e, c); ok {
+		desc += " block=" + blockID
+	}
+	return desc
+}
+
+// Handler progresses the formula on every event. The event is not handled.
+// A violation is reported as soon as the formula cannot hold anymore.
+// The handler records the prevotes read by the Polka atoms before progressing the formula.
+func (p *Property) Handler() handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		state := p.getState(c)
+		state.lock.Lock()
+		defer state.lock.Unlock()
+		if state.violated {
+			return []*types.Message{}, false
+		}
+		recordPrevote(e, c)
+		state.record(describe(e, c))
+		state.cur = state.cur.Progress(e, c)
+		if state.cur == False {
+			state.violated = true
+			p.report(c, state)
+		}
+		return []*types.Message{}, false
+	}
+}
+
+func (p *Property) report(c *testlib.Context, state *propertyState) {
+	params := log.LogParams{
+		"property": p.Name,
+		"formula":  p.Formula.String(),
+		"trace":    state.excerpt(),
+	}
+	c.Logger().With(params).Info("Property violated")
+	util.AddReportLog(c, "Property violated", params)
+}
+
+// Holds returns true if the property holds on the events of the testcase.
+// Should be called at the end of the testcase, in the assert function.
+func (p *Property) Holds(c *testlib.Context) bool {
+	state := p.getState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if state.violated {
+		return false
+	}
+	if !state.cur.Accepts() {
+		state.violated = true
+		p.report(c, state)
+		return false
+	}
+	return true
+}
+
+// Handlers returns the handlers of the properties, to be added to the testcase handler
+func Handlers(props ...*Property) []handlers.HandlerFunc {
+	result := make([]handlers.HandlerFunc, len(props))
+	for i, p := range props {
+		result[i] = p.Handler()
+	}
+	return result
+}
+
+// Check returns an assert function that is true if all the properties hold
+func Check(props ...*Property) func(*testlib.Context) bool {
+	return func(c *testlib.Context) bool {
+		holds := true
+		for _, p := range props {
+			if !p.Holds(c) {
+				holds = false
+			}
+		}
+		return holds
+	}
+}
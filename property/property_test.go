@@ -0,0 +1,141 @@
+package property
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func TestNoPrecommitBeforePolka(t *testing.T) {
+	blockID := testkit.BlockID("a")
+	cases := []struct {
+		name string
+		// votes returns the prevotes and precommits sent for the block in round 0 of height 1
+		votes    func(k *testkit.Kit) []*util.TMessage
+		expected bool
+	}{
+		{"precommit after the polka", func(k *testkit.Kit) []*util.TMessage {
+			return []*util.TMessage{
+				k.Prevote(0, 1, 0, blockID), k.Prevote(1, 1, 0, blockID), k.Prevote(2, 1, 0, blockID),
+				k.Precommit(0, 1, 0, blockID),
+			}
+		}, true},
+		{"precommit before the polka", func(k *testkit.Kit) []*util.TMessage {
+			return []*util.TMessage{
+				k.Prevote(0, 1, 0, blockID), k.Prevote(1, 1, 0, blockID), k.Precommit(0, 1, 0, blockID),
+				k.Prevote(2, 1, 0, blockID),
+			}
+		}, false},
+		{"the same prevote sent to every replica", func(k *testkit.Kit) []*util.TMessage {
+			return []*util.TMessage{
+				k.Prevote(0, 1, 0, blockID), k.Prevote(0, 1, 0, blockID), k.Prevote(0, 1, 0, blockID),
+				k.Precommit(0, 1, 0, blockID),
+			}
+		}, false},
+		{"polka in another round", func(k *testkit.Kit) []*util.TMessage {
+			return []*util.TMessage{
+				k.Prevote(0, 1, 0, blockID), k.Prevote(1, 1, 0, blockID), k.Prevote(2, 1, 1, blockID),
+				k.Precommit(0, 1, 1, blockID),
+			}
+		}, false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k.Context.Vars.Set("block", blockID.Hash.String())
+		p := NoPrecommitBeforePolka("block")
+		handler := p.Handler()
+		for i, tMsg := range tc.votes(k) {
+			_, e := k.SendTo(tMsg, (i+1)%4)
+			if _, handled := handler(e, k.Context); handled {
+				t.Errorf("%s: expected the event not handled", tc.name)
+			}
+		}
+		if got := p.Holds(k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestCommitProgress(t *testing.T) {
+	blockID := testkit.BlockID("a")
+	cases := []struct {
+		name     string
+		heights  []int
+		expected bool
+	}{
+		{"every height committed", []int{1, 1, 2, 3}, true},
+		{"last height pending", []int{1, 2, 2}, false},
+		{"no commit", []int{}, true},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := CommitProgress(3)
+		handler := p.Handler()
+		for i, h := range tc.heights {
+			handler(k.Commit(i%4, h, blockID), k.Context)
+		}
+		if got := p.Holds(k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+		if violations := len(util.GetReportLog(k.Context.Vars)); violations != 0 && tc.expected {
+			t.Errorf("%s: expected no violation in the report, got %d", tc.name, violations)
+		}
+	}
+}
+
+func TestCounterexample(t *testing.T) {
+	defer func(length int) { TraceLength = length }(TraceLength)
+	TraceLength = 3
+
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New("no precommit", Always(Not(Send(util.Precommit))))
+	handler := p.Handler()
+	events := make([]*types.Event, 0)
+	for i := 0; i < 4; i++ {
+		_, e := k.SendTo(k.Prevote(i, 1, 0, testkit.BlockID("a")), 0)
+		events = append(events, e)
+	}
+	_, e := k.SendTo(k.Precommit(0, 1, 0, testkit.BlockID("a")), 1)
+	events = append(events, e)
+	// the events after the violation are not recorded
+	_, e = k.SendTo(k.Precommit(1, 1, 0, testkit.BlockID("a")), 0)
+	events = append(events, e)
+	for _, e := range events {
+		handler(e, k.Context)
+	}
+	if p.Holds(k.Context) {
+		t.Fatal("expected the property to be violated")
+	}
+
+	reports := util.GetReportLog(k.Context.Vars)
+	if len(reports) != 1 || reports[0].Message != "Property violated" {
+		t.Fatalf("expected one violation in the report, got %v", reports)
+	}
+	trace, ok := reports[0].Params["trace"].([]string)
+	if !ok || len(trace) != TraceLength {
+		t.Fatalf("expected the last %d events as the counterexample, got %v", TraceLength, reports[0].Params["trace"])
+	}
+	ids := make([]string, len(trace))
+	for i, entry := range trace {
+		ids[i] = strings.Fields(entry)[0]
+	}
+	if expected := []string{"3", "4", "5"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected the events %v ending with the violation, got %v", expected, trace)
+	}
+	if !strings.Contains(trace[2], "Precommit node0->node1 h=1 r=0") {
+		t.Errorf("expected the precommit of node0 last, got %q", trace[2])
+	}
+}
@@ -0,0 +1,32 @@
+package property
+
+import (
+	"fmt"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// NoPrecommitBeforePolka holds if no replica precommits the labelled block before a polka for it
+func NoPrecommitBeforePolka(label string) *Property {
+	return New(
+		fmt.Sprintf("NoPrecommitBeforePolka(%s)", label),
+		NeverBefore(And(Send(util.Precommit), Block(label)), Polka(label)),
+	)
+}
+
+// CommitProgress holds if every commit of a height below upTo is followed by a commit of the next height
+func CommitProgress(upTo int) *Property {
+	return New(
+		fmt.Sprintf("CommitProgress(%d)", upTo),
+		Always(WithEvent("commit(h) -> F(commit(h+1))", func(e *types.Event, c *testlib.Context) Formula {
+			commit, ok := common.GetCommit(e, c)
+			if !ok || commit.Height >= upTo {
+				return True
+			}
+			return Next(Eventually(Commit(commit.Height + 1)))
+		})),
+	)
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestcase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitFailure   `xml:"failure,omitempty"`
+	Skipped    *junitSkipped   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitTestsuite struct {
+	XMLName   xml.Name         `xml:"testsuite"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Testcases []*junitTestcase `xml:"testcase"`
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// systemOut describes the final state, the partition and the messages of the testcase
+func systemOut(t *Testcase) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "state: %s\n", t.State)
+	labels := make([]string, 0, len(t.Partition))
+	for label := range t.Partition {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Fprintf(&b, "part %s: %s\n", label, strings.Join(t.Partition[label], ","))
+	}
+	fmt.Fprintf(&b, "messages sent: %d, delivered: %d\n", t.Messages.TotalSent, t.Messages.TotalDelivered)
+	for _, mType := range sortedKeys(t.Messages.Sent) {
+		fmt.Fprintf(&b, "  %s sent: %d, delivered: %d\n", mType, t.Messages.Sent[mType], t.Messages.Delivered[mType])
+	}
+	for _, mType := range sortedKeys(t.Messages.Delivered) {
+		if _, ok := t.Messages.Sent[mType]; !ok {
+			fmt.Fprintf(&b, "  %s sent: 0, delivered: %d\n", mType, t.Messages.Delivered[mType])
+		}
+	}
+	return b.String()
+}
+
+// WriteJUnit writes the reports of the testcases as a JUnit XML testsuite. The testcases are named
+// after their instance and grouped by catalog entry, failures and timeouts are failures of different types
+// and the testcases that did not run are skipped. Tags and parameters are written as properties.
+func WriteJUnit(w io.Writer, name string, testcases []*Testcase) error {
+	suite := &junitTestsuite{
+		Name:      name,
+		Tests:     len(testcases),
+		Testcases: make([]*junitTestcase, 0, len(testcases)),
+	}
+	total := 0.0
+	for _, t := range testcases {
+		total += t.Duration
+		jt := &junitTestcase{
+			Name:      t.Name,
+			Classname: t.Entry,
+			Time:      seconds(t.Duration),
+		}
+		if len(t.Tags) > 0 {
+			jt.Properties = append(jt.Properties, junitProperty{Name: "tags", Value: strings.Join(t.Tags, ",")})
+		}
+		params := make([]string, 0, len(t.Params))
+		for k := range t.Params {
+			params = append(params, k)
+		}
+		sort.Strings(params)
+		for _, k := range params {
+			jt.Properties = append(jt.Properties, junitProperty{Name: "param." + k, Value: t.Params[k]})
+		}
+		switch t.Outcome {
+		case Fail, Timeout:
+			suite.Failures++
+			jt.Failure = &junitFailure{Message: t.Failure, Type: t.Outcome}
+		case NotRun:
+			suite.Skipped++
+			jt.Skipped = &junitSkipped{Message: "testcase did not run"}
+		}
+		if t.Outcome != NotRun {
+			jt.SystemOut = systemOut(t)
+		}
+		suite.Testcases = append(suite.Testcases, jt)
+	}
+	suite.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
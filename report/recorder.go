@@ -0,0 +1,124 @@
+package report
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// record is what is observed of a testcase instance while it runs
+type record struct {
+	entry    *catalog.Entry
+	params   catalog.Params
+	testcase *testlib.TestCase
+
+	vars      *testlib.Vars
+	start     time.Time
+	end       time.Time
+	sent      map[string]int
+	delivered map[string]int
+	lock      *sync.Mutex
+}
+
+func newRecord(entry *catalog.Entry, params catalog.Params, testcase *testlib.TestCase) *record {
+	return &record{
+		entry:     entry,
+		params:    params,
+		testcase:  testcase,
+		sent:      make(map[string]int),
+		delivered: make(map[string]int),
+		lock:      new(sync.Mutex),
+	}
+}
+
+func messageType(m *types.Message) string {
+	tMsg, ok := util.GetParsedMessage(m)
+	if !ok {
+		return string(util.None)
+	}
+	return string(tMsg.Type)
+}
+
+func (r *record) observe(e *types.Event, c *testlib.Context, messages []*types.Message) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.vars = c.Vars
+	if e.IsMessageSend() {
+		m, _ := c.GetMessage(e)
+		r.sent[messageType(m)]++
+	}
+	for _, m := range messages {
+		r.delivered[messageType(m)]++
+	}
+}
+
+type recordedHandler struct {
+	record  *record
+	handler testlib.Handler
+}
+
+var _ testlib.Handler = &recordedHandler{}
+
+func (h *recordedHandler) HandleEvent(e *types.Event, c *testlib.Context) []*types.Message {
+	messages := h.handler.HandleEvent(e, c)
+	h.record.observe(e, c, messages)
+	return messages
+}
+
+func (h *recordedHandler) Name() string {
+	return h.handler.Name()
+}
+
+// Unwrap returns the handler of the testcase
+func (h *recordedHandler) Unwrap() testlib.Handler {
+	return h.handler
+}
+
+// Recorder keeps what the reports of the testing server do not have: the variables of the testcase
+// once it has run, when it started and finished and the number of messages sent and delivered.
+type Recorder struct {
+	records []*record
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		records: make([]*record, 0),
+	}
+}
+
+// Record wraps the handler of the testcase, created by the entry with the parameters, to observe every event.
+// The testcase should already be wrapped with the monitors, the reports are built in the order of the calls.
+func (r *Recorder) Record(entry *catalog.Entry, params catalog.Params, testcase *testlib.TestCase) {
+	rec := newRecord(entry, params, testcase)
+	testcase.Handler = &recordedHandler{
+		record:  rec,
+		handler: testcase.Handler,
+	}
+	r.records = append(r.records, rec)
+}
+
+// Ran records when the testcase with the name was started by the testing server and when it ended or timed out
+func (r *Recorder) Ran(name string, start, end time.Time) {
+	for _, rec := range r.records {
+		if rec.testcase.Name == name {
+			rec.lock.Lock()
+			rec.start, rec.end = start, end
+			rec.lock.Unlock()
+		}
+	}
+}
+
+// Testcases builds the report of every recorded testcase from the reports of the server
+func (r *Recorder) Testcases(reports *testlib.TestCaseReportStore) []*Testcase {
+	result := make([]*Testcase, 0, len(r.records))
+	for _, rec := range r.records {
+		result = append(result, rec.build(reports))
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+// Package report builds machine-readable reports of the testcases that have run, in JSON and JUnit XML
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// Outcomes of a testcase instance
+const (
+	Pass    = "pass"
+	Fail    = "fail"
+	Timeout = "timeout"
+	NotRun  = "not run"
+)
+
+// DefaultVars are the variables of the testcases copied to the report when they are set
+var DefaultVars = []string{
+	"n",
+	"faults",
+	"proposer",
+	"faultyReplica",
+	"partitionSeed",
+	"allowForks",
+	"safetyViolation",
+	"evidence",
+	"CurRound",
+	"roundCount",
+}
+
+// MessageStats counts the messages of a testcase by type. Sent are the messages sent by the replicas,
+// Delivered the messages returned by the handler, including the messages changed or created by the testcase.
+type MessageStats struct {
+	Sent           map[string]int `json:"sent"`
+	Delivered      map[string]int `json:"delivered"`
+	TotalSent      int            `json:"total_sent"`
+	TotalDelivered int            `json:"total_delivered"`
+}
+
+// Testcase is the report of a testcase instance
+type Testcase struct {
+	Name        string            `json:"name"`
+	Entry       string            `json:"entry"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Outcome     string            `json:"outcome"`
+	// Failure explains the outcome when the testcase did not pass
+	Failure string `json:"failure,omitempty"`
+	// State is the final state of the testcase state machine
+	State string `json:"state"`
+	// Duration in seconds of the testcase, from the time it was started to the time it ended or timed out
+	Duration float64 `json:"duration"`
+	// Partition has the replicas of every part
+	Partition map[string][]string    `json:"partition,omitempty"`
+	Vars      map[string]interface{} `json:"vars,omitempty"`
+	// Log has the messages recorded by the monitors and properties, such as the reason of a safety violation
+	// or the counterexample of a property
+	Log      []*util.ReportLog `json:"log,omitempty"`
+	Messages *MessageStats     `json:"messages"`
+}
+
+func copyCounts(counts map[string]int) (map[string]int, int) {
+	result := make(map[string]int, len(counts))
+	total := 0
+	for k, v := range counts {
+		result[k] = v
+		total += v
+	}
+	return result, total
+}
+
+func partitionOf(vars *testlib.Vars) map[string][]string {
+	pI, ok := vars.Get("partition")
+	if !ok {
+		return nil
+	}
+	partition, ok := pI.(*util.Partition)
+	if !ok {
+		return nil
+	}
+	result := make(map[string][]string)
+	for label := range partition.Parts {
+		part, _ := partition.GetPart(label)
+		ids := make([]string, 0, part.Size())
+		for _, id := range part.ReplicaSet.Iter() {
+			ids = append(ids, string(id))
+		}
+		sort.Strings(ids)
+		result[label] = ids
+	}
+	return result
+}
+
+// snapshot copies the variables that can be written in JSON, the other values are internal state of the handlers
+func snapshot(vars *testlib.Vars, keys []string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, key := range keys {
+		value, ok := vars.Get(key)
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case bool, int, int64, string, map[string]int:
+			result[key] = v
+		case time.Duration:
+			result[key] = v.String()
+		case fmt.Stringer:
+			result[key] = v.String()
+		}
+	}
+	return result
+}
+
+func (r *record) build(reports *testlib.TestCaseReportStore) *Testcase {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	t := &Testcase{
+		Name:        r.testcase.Name,
+		Entry:       r.entry.Name,
+		Description: r.entry.Description,
+		Tags:        r.entry.Tags,
+		Params:      r.params,
+		Messages:    &MessageStats{},
+	}
+	t.Messages.Sent, t.Messages.TotalSent = copyCounts(r.sent)
+	t.Messages.Delivered, t.Messages.TotalDelivered = copyCounts(r.delivered)
+
+	report, ok := reports.GetReport(r.testcase.Name)
+	if !ok {
+		t.Outcome = NotRun
+		return t
+	}
+	if !r.start.IsZero() {
+		t.Duration = r.end.Sub(r.start).Seconds()
+	}
+	t.State = stateOf(r.vars)
+	if r.vars != nil {
+		t.Partition = partitionOf(r.vars)
+		t.Vars = snapshot(r.vars, DefaultVars)
+		t.Log = util.GetReportLog(r.vars)
+	}
+	t.Outcome, t.Failure = outcome(report.Assertion, r.vars, r.testcase.Timeout, t.State)
+	return t
+}
+
+func stateOf(vars *testlib.Vars) string {
+	if vars == nil {
+		return ""
+	}
+	state, ok := vars.GetString("curState")
+	if !ok {
+		return handlers.StartStateLabel
+	}
+	return state
+}
+
+// outcome of a testcase that has run. A testcase that did not pass and did not end with util.EndTestCase
+// or util.Abort has timed out.
+func outcome(assertion bool, vars *testlib.Vars, timeout time.Duration, state string) (string, string) {
+	if assertion {
+		return Pass, ""
+	}
+	if vars == nil {
+		return Fail, "no event was handled, the setup might have failed"
+	}
+	if reason, ok := vars.GetString("safetyViolation"); ok {
+		return Fail, reason
+	}
+	if !util.Ended(vars) {
+		return Timeout, fmt.Sprintf("timed out after %s in state %s", timeout, state)
+	}
+	return Fail, fmt.Sprintf("assertion failed in state %s", state)
+}
+
+// WriteJSON writes the reports of the testcases as an indented JSON array
+func WriteJSON(w io.Writer, testcases []*Testcase) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(testcases)
+}
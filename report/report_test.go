@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func newTestRecord(name string, timeout time.Duration) *record {
+	entry := &catalog.Entry{Name: "rskip.One", Tags: []string{catalog.Liveness}}
+	rec := newRecord(entry, catalog.Params{"height": "2"}, testlib.NewTestCase(name, timeout, handlers.NewHandlerCascade()))
+	rec.vars = testlib.NewVarSet()
+	return rec
+}
+
+func TestBuild(t *testing.T) {
+	reports := testlib.NewTestCaseReportStore()
+
+	passed := newTestRecord("passed", 10*time.Second)
+	h := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "h"}
+	h.ReplicaSet.Add(&types.Replica{ID: "replica-1"})
+	rest := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "rest"}
+	rest.ReplicaSet.Add(&types.Replica{ID: "replica-3"})
+	rest.ReplicaSet.Add(&types.Replica{ID: "replica-2"})
+	passed.vars.Set("partition", util.NewPartition(h, rest))
+	passed.vars.Set("curState", handlers.SuccessStateLabel)
+	passed.vars.Set("faults", 1)
+	passed.vars.Set("delayedPrevotes", types.NewMessageStore())
+	passed.sent["Prevote"] = 4
+	passed.delivered["Prevote"] = 3
+	passed.delivered["Proposal"] = 1
+	report := testlib.NewTestCaseReport("passed")
+	report.Assertion = true
+	reports.AddReport(report)
+
+	violated := newTestRecord("violated", 10*time.Second)
+	violated.vars.Set("safetyViolation", "Agreement violated")
+	violated.vars.Set("reportLog", []*util.ReportLog{{Message: "Agreement violated", Params: map[string]interface{}{"height": 2}}})
+	reports.AddReport(testlib.NewTestCaseReport("violated"))
+
+	timedOut := newTestRecord("timedOut", 10*time.Second)
+	timedOut.vars.Set("curState", "delayed")
+	reports.AddReport(testlib.NewTestCaseReport("timedOut"))
+
+	failed := newTestRecord("failed", 10*time.Second)
+	failed.vars.Set("ended", true)
+	reports.AddReport(testlib.NewTestCaseReport("failed"))
+
+	notRun := newTestRecord("notRun", 10*time.Second)
+
+	recorder := &Recorder{records: []*record{passed, violated, timedOut, failed, notRun}}
+	start := time.Now()
+	recorder.Ran("passed", start, start.Add(time.Second))
+	recorder.Ran("timedOut", start, start.Add(10*time.Second))
+	testcases := recorder.Testcases(reports)
+
+	expected := []struct {
+		outcome string
+		state   string
+	}{
+		{Pass, handlers.SuccessStateLabel},
+		{Fail, handlers.StartStateLabel},
+		{Timeout, "delayed"},
+		{Fail, handlers.StartStateLabel},
+		{NotRun, ""},
+	}
+	for i, e := range expected {
+		if testcases[i].Outcome != e.outcome || testcases[i].State != e.state {
+			t.Errorf("%s: expected %s in state %q, got %s in state %q",
+				testcases[i].Name, e.outcome, e.state, testcases[i].Outcome, testcases[i].State)
+		}
+	}
+	if testcases[1].Failure != "Agreement violated" {
+		t.Errorf("expected the safety violation as failure, got %q", testcases[1].Failure)
+	}
+	if len(testcases[1].Log) != 1 || testcases[1].Log[0].Message != "Agreement violated" {
+		t.Errorf("expected the report log of the safety violation, got %v", testcases[1].Log)
+	}
+
+	p := testcases[0]
+	if len(p.Partition) != 2 || len(p.Partition["rest"]) != 2 || p.Partition["rest"][0] != "replica-2" {
+		t.Errorf("unexpected partition %v", p.Partition)
+	}
+	if len(p.Vars) != 1 || p.Vars["faults"] != 1 {
+		t.Errorf("expected only faults in the variables, got %v", p.Vars)
+	}
+	if p.Messages.TotalSent != 4 || p.Messages.TotalDelivered != 4 || p.Messages.Delivered["Proposal"] != 1 {
+		t.Errorf("unexpected message statistics %+v", p.Messages)
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, testcases); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*Testcase
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(testcases) || decoded[2].Outcome != Timeout || decoded[0].Duration != 1 || decoded[2].Duration != 10 || len(decoded[1].Log) != 1 {
+		t.Errorf("unexpected JSON report %s", out.String())
+	}
+
+	out.Reset()
+	if err := WriteJUnit(&out, "tendermint-test", testcases); err != nil {
+		t.Fatal(err)
+	}
+	var suite junitTestsuite
+	if err := xml.Unmarshal(out.Bytes(), &suite); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Tests != 5 || suite.Failures != 3 || suite.Skipped != 1 {
+		t.Errorf("expected 5 tests, 3 failures and 1 skipped, got %d, %d and %d", suite.Tests, suite.Failures, suite.Skipped)
+	}
+	if f := suite.Testcases[2].Failure; f == nil || f.Type != Timeout {
+		t.Errorf("expected a timeout failure, got %+v", f)
+	}
+	if suite.Testcases[0].Classname != "rskip.One" || suite.Testcases[0].Failure != nil {
+		t.Errorf("unexpected testcase %+v", suite.Testcases[0])
+	}
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/cluster"
+	"github.com/ds-test-framework/tendermint-test/report"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// runWithCluster runs the testcases one at a time, each with a testing server of its own and the nodes of the
+// cluster started for it, so that every testcase starts from the genesis when the nodes are wiped. The logs of
+// the nodes are collected in a run directory of the testcase. Returns the reports of the testcases that have run,
+// the time of a testcase recorded with the recorder includes the start of the nodes.
+func runWithCluster(config *Config, instances []*testlib.TestCase, recorder *report.Recorder, termCh chan os.Signal) (*testlib.TestCaseReportStore, error) {
+	c, err := cluster.New(config.Cluster, config.NumReplicas, config.Addr())
+	if err != nil {
+		return nil, err
+	}
+	reports := testlib.NewTestCaseReportStore()
+	start := time.Now()
+	for _, instance := range instances {
+		server, err := testlib.NewTestingServer(
+			config.ServerConfig(),
+			&util.TMessageParser{},
+			[]*testlib.TestCase{instance},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start server: %s", err)
+		}
+		began := time.Now()
+		go server.Start()
+		runDir := c.RunDir(start, instance.Name)
+		if err := c.Start(runDir); err != nil {
+			server.Stop()
+			return nil, err
+		}
+		fmt.Printf("Running %s, logs in %s\n", instance.Name, runDir)
+
+		interrupted := false
+		select {
+		case <-server.Done():
+		case <-termCh:
+			interrupted = true
+		}
+		ended := time.Now()
+		c.Stop()
+		server.Stop()
+		if r, ok := server.ReportStore.GetReport(instance.Name); ok {
+			if recorder != nil {
+				recorder.Ran(instance.Name, began, ended)
+			}
+			reports.AddReport(r)
+		}
+		if interrupted {
+			break
+		}
+	}
+	return reports, nil
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ds-test-framework/scheduler/config"
+	"github.com/ds-test-framework/tendermint-test/cluster"
+)
+
+// Default addresses of the testing server, the address of the host when the replicas are run separately
+// and the local address when the runner runs the replicas on localhost
+const (
+	DefaultServerAddr      = "192.168.1.8:7074"
+	DefaultLocalServerAddr = "127.0.0.1:7074"
+)
+
+// Config is the configuration of the testing server
+type Config struct {
+	// ServerAddr is the address the replicas connect to, see Addr for the default address
+	ServerAddr string `json:"server_addr"`
+	// NumReplicas is the number of replicas in the network
+	NumReplicas int `json:"num_replicas"`
+	// LogDir is the directory of the checker log
+	LogDir string `json:"log_dir"`
+	// LogLevel is one of panic|fatal|error|warn|warning|info|debug|trace
+	LogLevel string `json:"log_level"`
+	// Cluster runs the replicas as local processes when set
+	Cluster *cluster.Config `json:"cluster,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when there is no config file. The server address is not set,
+// it depends on whether the runner runs the replicas, see Addr.
+func DefaultConfig() *Config {
+	return &Config{
+		NumReplicas: 4,
+		LogDir:      "/tmp/tendermint/log",
+		LogLevel:    "info",
+	}
+}
+
+// LoadConfig reads the JSON config file, missing fields keep their default values
+func LoadConfig(path string) (*Config, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %s", err)
+	}
+	c := DefaultConfig()
+	if err := json.Unmarshal(bytes, c); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %s", err)
+	}
+	return c, nil
+}
+
+// Addr is the address of the testing server, ServerAddr when it is set. Otherwise DefaultLocalServerAddr when a
+// cluster is configured, the nodes run on localhost, and DefaultServerAddr when the replicas are run separately.
+func (c *Config) Addr() string {
+	if c.ServerAddr != "" {
+		return c.ServerAddr
+	}
+	if c.Cluster != nil {
+		return DefaultLocalServerAddr
+	}
+	return DefaultServerAddr
+}
+
+// ServerConfig returns the config of the scheduler testing server
+func (c *Config) ServerConfig() *config.Config {
+	return &config.Config{
+		APIServerAddr: c.Addr(),
+		NumReplicas:   c.NumReplicas,
+		Byzantine:     true,
+		LogConfig: config.LogConfig{
+			Path:   filepath.Join(c.LogDir, "checker.log"),
+			Format: "json",
+			Level:  c.LogLevel,
+		},
+	}
+}
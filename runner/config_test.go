@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	c, err := LoadConfig(writeConfig(t, `{"num_replicas": 7, "log_dir": "/tmp/logs"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := DefaultConfig()
+	if c.NumReplicas != 7 || c.LogDir != "/tmp/logs" {
+		t.Errorf("expected the values of the file, got %d replicas and log dir %s", c.NumReplicas, c.LogDir)
+	}
+	if c.ServerAddr != def.ServerAddr || c.LogLevel != def.LogLevel || c.Cluster != nil {
+		t.Errorf("expected the default values of the missing fields, got %+v", c)
+	}
+	server := c.ServerConfig()
+	if server.APIServerAddr != DefaultServerAddr || server.NumReplicas != 7 || server.LogConfig.Path != "/tmp/logs/checker.log" {
+		t.Errorf("unexpected server config %+v", server)
+	}
+
+	c, err = LoadConfig(writeConfig(t, `{"cluster": {"binary": "./build/tendermint"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr() != DefaultLocalServerAddr || c.ServerConfig().APIServerAddr != DefaultLocalServerAddr {
+		t.Errorf("expected the local address with a cluster, got %s", c.Addr())
+	}
+	c, err = LoadConfig(writeConfig(t, `{"server_addr": "10.0.0.1:7074", "cluster": {"binary": "./build/tendermint"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr() != "10.0.0.1:7074" {
+		t.Errorf("expected the address of the file, got %s", c.Addr())
+	}
+
+	if _, err := LoadConfig(writeConfig(t, `{"num_replicas": "seven"}`)); err == nil {
+		t.Error("expected error for an invalid config")
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing config file")
+	}
+}
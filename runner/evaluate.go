@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/report"
+	"github.com/ds-test-framework/tendermint-test/trace"
+)
+
+// runEvaluate evaluates the instances against the recorded events of the testcases of the same name in the
+// trace file, without replicas. The results are written as a matrix to the standard output and the reports
+// to evaluation.json in the log directory, an instance that was not recorded is reported as not run.
+// The path can also be the directory of the logs of a run of the cluster, the events are imported from the
+// logs with trace.ImportLogs. The run has a single testcase named as before the catalog, every instance is
+// evaluated against it.
+func runEvaluate(tracePath string, instances []*testlib.TestCase, recorder *report.Recorder, matrix *Matrix, dir string) error {
+	records, imported, err := readRecords(tracePath)
+	if err != nil {
+		return err
+	}
+	reports := testlib.NewTestCaseReportStore()
+	for _, instance := range instances {
+		if imported {
+			for _, r := range records {
+				r.Testcase = instance.Name
+			}
+		}
+		start := time.Now()
+		r, err := trace.Evaluate(records, instance)
+		if err != nil {
+			fmt.Printf("%s: could not evaluate, %s\n", instance.Name, err)
+			continue
+		}
+		recorder.Ran(instance.Name, start, time.Now())
+		reports.AddReport(r)
+	}
+	testcases := recorder.Testcases(reports)
+	matrix.collect(testcases)
+	matrix.Write(os.Stdout)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, "evaluation.json"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return report.WriteJSON(f, testcases)
+}
+
+// readRecords reads the trace file or imports the logs of the run in the directory, true if the logs were imported
+func readRecords(path string) ([]*trace.Record, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if info.IsDir() {
+		records, err := trace.ImportLogs(path)
+		return records, true, err
+	}
+	records, err := trace.ReadFile(path)
+	return records, false, err
+}
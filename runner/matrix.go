@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/report"
+)
+
+// Result is the outcome of a testcase instance
+type Result struct {
+	Entry    string
+	Params   catalog.Params
+	Instance string
+	Outcome  string
+}
+
+// Matrix has the results of the testcase instances of a run, with a column for every parameter
+type Matrix struct {
+	Keys    []string
+	Results []*Result
+}
+
+func newMatrix() *Matrix {
+	return &Matrix{
+		Keys:    make([]string, 0),
+		Results: make([]*Result, 0),
+	}
+}
+
+func (m *Matrix) add(entry *catalog.Entry, params catalog.Params) {
+	for k := range params {
+		found := false
+		for _, key := range m.Keys {
+			if key == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			m.Keys = append(m.Keys, k)
+		}
+	}
+	sort.Strings(m.Keys)
+	m.Results = append(m.Results, &Result{
+		Entry:    entry.Name,
+		Params:   params,
+		Instance: entry.InstanceName(params),
+		Outcome:  report.NotRun,
+	})
+}
+
+// collect sets the outcome of every instance from the reports of the testcases
+func (m *Matrix) collect(testcases []*report.Testcase) {
+	outcomes := make(map[string]string, len(testcases))
+	for _, t := range testcases {
+		outcomes[t.Name] = t.Outcome
+	}
+	for _, r := range m.Results {
+		outcome, ok := outcomes[r.Instance]
+		if !ok {
+			outcome = report.NotRun
+		}
+		r.Outcome = outcome
+	}
+}
+
+func (m *Matrix) rows() [][]string {
+	rows := make([][]string, 0, len(m.Results)+1)
+	rows = append(rows, append(append([]string{"testcase"}, m.Keys...), "result"))
+	for _, r := range m.Results {
+		row := []string{r.Entry}
+		for _, k := range m.Keys {
+			row = append(row, r.Params[k])
+		}
+		rows = append(rows, append(row, r.Outcome))
+	}
+	return rows
+}
+
+// Write writes the matrix as a table
+func (m *Matrix) Write(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range m.rows() {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+// WriteCSV writes the matrix in CSV, parameters that do not apply to a testcase are empty
+func (m *Matrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(m.rows()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
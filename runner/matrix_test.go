@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/report"
+)
+
+func TestMatrix(t *testing.T) {
+	rskip := &catalog.Entry{Name: "rskip.One"}
+	sanity := &catalog.Entry{Name: "sanity.One"}
+
+	m := newMatrix()
+	m.add(rskip, catalog.Params{"height": "1", "round": "2"})
+	m.add(rskip, catalog.Params{"height": "2", "round": "2"})
+	m.add(sanity, catalog.Params{})
+	m.add(rskip, catalog.Params{"height": "3", "round": "2"})
+
+	m.collect([]*report.Testcase{
+		{Name: "rskip.One[height=1,round=2]", Outcome: report.Pass},
+		{Name: "rskip.One[height=2,round=2]", Outcome: report.Timeout},
+		{Name: "sanity.One", Outcome: report.Fail},
+	})
+
+	var out bytes.Buffer
+	if err := m.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "testcase,height,round,result\n" +
+		"rskip.One,1,2,pass\n" +
+		"rskip.One,2,2,timeout\n" +
+		"sanity.One,,,fail\n" +
+		"rskip.One,3,2,not run\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
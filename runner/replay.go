@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/trace"
+)
+
+// defaultReplayTimeout is the timeout of the replay of a testcase recorded without its timeout
+const defaultReplayTimeout = time.Minute
+
+// ReplayResult is the result of the replay of a recorded testcase
+type ReplayResult struct {
+	Testcase    string              `json:"testcase"`
+	Diverged    bool                `json:"diverged"`
+	Divergences []*trace.Divergence `json:"divergences"`
+}
+
+// selectRecorded returns the testcases of the trace that match one of the patterns, every testcase without patterns
+func selectRecorded(records []*trace.Record, patterns []string) ([]string, error) {
+	result := make([]string, 0)
+	for _, name := range trace.Testcases(records) {
+		matches := len(patterns) == 0
+		for _, p := range patterns {
+			ok, err := path.Match(p, name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %s", p, err)
+			}
+			if ok || p == name {
+				matches = true
+				break
+			}
+		}
+		if matches {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// runReplay replays the testcases of the trace file selected by the patterns with the default monitors.
+// The replay is recorded in replay.jsonl and the divergences from the recording are written to replay.json
+// in the log directory.
+func runReplay(config *Config, tracePath string, patterns []string) error {
+	records, err := trace.ReadFile(tracePath)
+	if err != nil {
+		return err
+	}
+	names, err := selectRecorded(records, patterns)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no testcases selected in %s", tracePath)
+	}
+	replayers := make([]*trace.Replayer, len(names))
+	instances := make([]*testlib.TestCase, len(names))
+	for i, name := range names {
+		replayer, err := trace.NewReplayer(records, name)
+		if err != nil {
+			return err
+		}
+		timeout := replayer.Timeout()
+		if timeout == 0 {
+			timeout = defaultReplayTimeout
+		}
+		replayers[i] = replayer
+		instances[i] = replayer.TestCase(timeout)
+	}
+	common.WithMonitors(instances)
+	closeTrace, err := traceInstances(instances, config.LogDir, "replay.jsonl")
+	if err != nil {
+		return err
+	}
+	defer closeTrace()
+
+	return runInstances(config, instances, nil, func(_ *testlib.TestCaseReportStore) {
+		results := make([]*ReplayResult, len(names))
+		for i, name := range names {
+			results[i] = &ReplayResult{
+				Testcase:    name,
+				Diverged:    replayers[i].Diverged(),
+				Divergences: replayers[i].Divergences(),
+			}
+			if !results[i].Diverged {
+				fmt.Printf("%s: replayed\n", name)
+				continue
+			}
+			for _, d := range results[i].Divergences {
+				if d.Kind != trace.ContentDivergence {
+					fmt.Printf("%s: diverged, %s\n", name, d)
+					break
+				}
+			}
+		}
+		if err := writeReplay(results, config.LogDir); err != nil {
+			fmt.Printf("Could not write replay results: %s\n", err)
+		}
+	})
+}
+
+func writeReplay(results []*ReplayResult, dir string) error {
+	f, err := os.Create(filepath.Join(dir, "replay.json"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
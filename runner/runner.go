@@ -0,0 +1,303 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/cluster"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/report"
+	"github.com/ds-test-framework/tendermint-test/trace"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func splitList(s string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// List writes the names, tags and descriptions of the testcases
+func List(w io.Writer, testcases []*catalog.Entry) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, t := range testcases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, strings.Join(t.Tags, ","), t.Description)
+	}
+	tw.Flush()
+}
+
+// Run parses the command line arguments and runs the selected testcases of the catalog with the default monitors.
+// The flags override the values of the config file.
+//
+//	-config   path to the JSON config file
+//	-addr     address of the testing server
+//	-replicas number of replicas
+//	-logdir   directory of the checker log
+//	-binary   path to the instrumented tendermint binary to run the replicas locally
+//	-list     list the selected testcases and exit
+//	-doc      write the documentation of the selected testcases in markdown and exit
+//	-run      comma separated names or globs of the testcases to run
+//	-tags     comma separated tags that the testcases should have
+//	-param    key=value parameter of the testcases, can be repeated
+//	-sweep    key=v1,v2 or key=from..to values of a parameter to run every testcase with, can be repeated
+//	-replay   path to a trace file, replays the recorded testcases selected by -run instead, see runReplay
+//	-evaluate path to a trace file or the directory of the logs of a run, evaluates the selected testcases against
+//	          their recorded events instead, see runEvaluate
+//
+// Once all the testcases have run, the result of every instance is written as a matrix to the
+// standard output and to results.csv in the log directory, along with the reports of the testcases
+// in report.json and junit.xml. Every event of the testcases is recorded with the decisions of their handlers
+// in trace.jsonl. The server keeps running until it is interrupted.
+//
+// With a cluster config, or -binary, the replicas are run as local processes and every testcase
+// runs with a testing server of its own against nodes started for the testcase, see runWithCluster.
+func Run(args []string) error {
+	flags := flag.NewFlagSet("tendermint-test", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to the JSON config file")
+	addr := flags.String("addr", "", "address of the testing server")
+	replicas := flags.Int("replicas", 0, "number of replicas")
+	logDir := flags.String("logdir", "", "directory of the checker log")
+	binary := flags.String("binary", "", "path to the instrumented tendermint binary to run the replicas locally")
+	list := flags.Bool("list", false, "list the selected testcases and exit")
+	doc := flags.Bool("doc", false, "write the documentation of the selected testcases in markdown and exit")
+	run := flags.String("run", "", "comma separated names or globs of the testcases to run")
+	tags := flags.String("tags", "", "comma separated tags that the testcases should have")
+	params := make(catalog.Params)
+	flags.Var(params, "param", "key=value parameter of the testcases, can be repeated")
+	grid := make(catalog.Grid)
+	flags.Var(grid, "sweep", "key=v1,v2 or key=from..to values of a parameter to run every testcase with, can be repeated")
+	replay := flags.String("replay", "", "path to a trace file, replays the recorded testcases selected by -run")
+	evaluate := flags.String("evaluate", "", "path to a trace file or the directory of the logs of a run, evaluates the selected testcases against their recorded events")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	config := DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if config, err = LoadConfig(*configPath); err != nil {
+			return err
+		}
+	}
+	if *addr != "" {
+		config.ServerAddr = *addr
+	}
+	if *replicas != 0 {
+		config.NumReplicas = *replicas
+	}
+	if *logDir != "" {
+		config.LogDir = *logDir
+	}
+	if *binary != "" {
+		if config.Cluster == nil {
+			config.Cluster = cluster.DefaultConfig(*binary)
+		}
+		config.Cluster.Binary = *binary
+	}
+
+	if *replay != "" {
+		return runReplay(config, *replay, splitList(*run))
+	}
+
+	selected, err := catalog.Select(catalog.All(), splitList(*run), splitList(*tags))
+	if err != nil {
+		return err
+	}
+	if *list {
+		List(os.Stdout, selected)
+		return nil
+	}
+	if *doc {
+		catalog.Markdown(os.Stdout, selected)
+		return nil
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no testcases selected")
+	}
+	instances := make([]*testlib.TestCase, 0)
+	entries := make([]*catalog.Entry, 0)
+	points := make([]catalog.Params, 0)
+	matrix := newMatrix()
+	names := make(map[string]bool)
+	for _, t := range selected {
+		if !t.Supports(config.NumReplicas) {
+			return fmt.Errorf("testcase %s does not support %d replicas", t.Name, config.NumReplicas)
+		}
+		for _, point := range grid.Expand(params) {
+			values, err := t.Values(point)
+			if err != nil {
+				return err
+			}
+			// points that differ only in parameters of other testcases are the same instance
+			if names[t.InstanceName(values)] {
+				continue
+			}
+			names[t.InstanceName(values)] = true
+			instance, err := t.Instance(values)
+			if err != nil {
+				return fmt.Errorf("could not create testcase %s: %s", t.Name, err)
+			}
+			instances = append(instances, instance)
+			entries = append(entries, t)
+			points = append(points, values)
+			matrix.add(t, values)
+		}
+	}
+
+	recorder := report.NewRecorder()
+	for i, instance := range common.WithMonitors(instances) {
+		recorder.Record(entries[i], points[i], instance)
+	}
+	if *evaluate != "" {
+		return runEvaluate(*evaluate, instances, recorder, matrix, config.LogDir)
+	}
+	closeTrace, err := traceInstances(instances, config.LogDir, "trace.jsonl")
+	if err != nil {
+		return err
+	}
+	defer closeTrace()
+	return runInstances(config, instances, recorder, func(reports *testlib.TestCaseReportStore) {
+		writeResults(recorder.Testcases(reports), matrix, config.LogDir)
+	})
+}
+
+// traceInstances records the events of the instances in the trace file of the log directory,
+// the returned function closes the file
+func traceInstances(instances []*testlib.TestCase, dir, name string) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	tracer := trace.NewTracer(f)
+	for _, instance := range instances {
+		tracer.Trace(instance)
+	}
+	return func() {
+		if err := tracer.Err(); err != nil {
+			fmt.Printf("Could not write trace: %s\n", err)
+		}
+		f.Close()
+	}, nil
+}
+
+// runInstances runs the instances and calls done with their reports once they have all run. When an instance
+// was started and when it ended or timed out is recorded with the recorder, if any.
+// The testing server keeps running until it is interrupted, unless the replicas are run by the runner.
+func runInstances(config *Config, instances []*testlib.TestCase, recorder *report.Recorder, done func(*testlib.TestCaseReportStore)) error {
+	termCh := make(chan os.Signal, 1)
+	signal.Notify(termCh, os.Interrupt, syscall.SIGTERM)
+	if config.Cluster != nil {
+		reports, err := runWithCluster(config, instances, recorder, termCh)
+		if err != nil {
+			return err
+		}
+		done(reports)
+		return nil
+	}
+
+	server, err := testlib.NewTestingServer(
+		config.ServerConfig(),
+		&util.TMessageParser{},
+		instances,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start server: %s", err)
+	}
+	go server.Start()
+	if recorder != nil {
+		go watchInstances(server, instances, recorder)
+	}
+	select {
+	case <-server.Done():
+		done(server.ReportStore)
+		<-termCh
+	case <-termCh:
+	}
+	server.Stop()
+	return nil
+}
+
+// watchInstances records when the instances run by the server were started and when they ended or timed out.
+// The server runs the instances one at a time in no particular order and adds the report of an instance once it
+// has ended, the next instance is started then. The first instance is started once the replicas have connected
+// to the server, its time includes the wait for the replicas and the times of the others the restart of the replicas.
+func watchInstances(server *testlib.TestingServer, instances []*testlib.TestCase, recorder *report.Recorder) {
+	start := time.Now()
+	finished := make(map[string]bool)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for len(finished) < len(instances) {
+		select {
+		case <-ticker.C:
+		case <-server.Done():
+		case <-server.QuitCh():
+			return
+		}
+		for _, instance := range instances {
+			if _, ok := server.ReportStore.GetReport(instance.Name); ok && !finished[instance.Name] {
+				end := time.Now()
+				recorder.Ran(instance.Name, start, end)
+				finished[instance.Name] = true
+				start = end
+			}
+		}
+	}
+}
+
+func writeResults(testcases []*report.Testcase, matrix *Matrix, dir string) {
+	matrix.collect(testcases)
+	matrix.Write(os.Stdout)
+	if err := writeMatrix(matrix, dir); err != nil {
+		fmt.Printf("Could not write results: %s\n", err)
+	}
+	if err := writeReports(testcases, dir); err != nil {
+		fmt.Printf("Could not write reports: %s\n", err)
+	}
+}
+
+func writeMatrix(m *Matrix, dir string) error {
+	f, err := os.Create(filepath.Join(dir, "results.csv"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return m.WriteCSV(f)
+}
+
+func writeReports(testcases []*report.Testcase, dir string) error {
+	f, err := os.Create(filepath.Join(dir, "report.json"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := report.WriteJSON(f, testcases); err != nil {
+		return err
+	}
+
+	junit, err := os.Create(filepath.Join(dir, "junit.xml"))
+	if err != nil {
+		return err
+	}
+	defer junit.Close()
+	return report.WriteJUnit(junit, "tendermint-test", testcases)
+}
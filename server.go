@@ -3,54 +3,24 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/ds-test-framework/scheduler/config"
-	"github.com/ds-test-framework/scheduler/testlib"
-	"github.com/ds-test-framework/tendermint-test/testcases/rskip"
-	"github.com/ds-test-framework/tendermint-test/util"
+	"github.com/ds-test-framework/tendermint-test/runner"
+
+	// The testcases packages register their testcases in the catalog
+	_ "github.com/ds-test-framework/tendermint-test/testcases"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/bfttime"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/byzantine"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/crash"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/lockedvalue"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/replay"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/rskip"
+	_ "github.com/ds-test-framework/tendermint-test/testcases/sanity"
 )
 
+// Runs the testcases selected on the command line, run with -h for the flags
 func main() {
-
-	termCh := make(chan os.Signal, 1)
-	signal.Notify(termCh, os.Interrupt, syscall.SIGTERM)
-
-	server, err := testlib.NewTestingServer(
-		&config.Config{
-			APIServerAddr: "192.168.1.8:7074",
-			NumReplicas:   4,
-			LogConfig: config.LogConfig{
-				Path: "/tmp/tendermint/log/checker.log",
-			},
-		},
-		&util.TMessageParser{},
-		[]*testlib.TestCase{
-			// testcases.DummyTestCase(),
-			rskip.OneTestcase(1, 2),
-			// rskip.BlockingTestcase(),
-			// lockedvalue.One(),
-			// lockedvalue.Two(),
-			// lockedvalue.Three(),
-			// sanity.OneTestCase(),
-			// sanity.TwoTestCase(),
-			// sanity.ThreeTestCase(),
-			//sanity.HigherProp(),
-			// bfttime.OneTestCase(),
-		},
-	)
-
-	if err != nil {
-		fmt.Printf("Failed to start server: %s\n", err.Error())
+	if err := runner.Run(os.Args[1:]); err != nil {
+		fmt.Printf("%s\n", err.Error())
 		os.Exit(1)
 	}
-
-	go func() {
-		<-termCh
-		server.Stop()
-	}()
-
-	server.Start()
-
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+// TestDoc checks that TESTCASES.md is the output of `go run . -doc`
+func TestDoc(t *testing.T) {
+	expected, err := ioutil.ReadFile("TESTCASES.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bytes.Buffer
+	catalog.Markdown(&doc, catalog.All())
+	if !bytes.Equal(doc.Bytes(), expected) {
+		t.Error("TESTCASES.md is out of date, regenerate it with go run . -doc > TESTCASES.md")
+	}
+}
@@ -0,0 +1,112 @@
+package sim
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type timer struct {
+	deadline time.Duration
+	fire     func()
+}
+
+// clock is the logical clock of the network. The timeouts of the replicas do not expire with the wall clock, the clock
+// moves to the deadline of the next timeout once the network is idle: no replica has a request to the testing server
+// that is queued or in flight and the testing server has not delivered anything for the idle duration. A timeout
+// then expires only when the testing server has nothing more to deliver, however slow the testing server is, and
+// the timeouts expire in the order of their deadlines.
+type clock struct {
+	now     time.Duration
+	timers  []*timer
+	pending int
+	active  time.Time
+	idle    time.Duration
+
+	lock   *sync.Mutex
+	stopCh chan struct{}
+}
+
+func newClock(idle time.Duration) *clock {
+	return &clock{
+		timers: make([]*timer, 0),
+		active: time.Now(),
+		idle:   idle,
+		lock:   new(sync.Mutex),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// afterFunc calls f once the clock has moved d past the current time
+func (c *clock) afterFunc(d time.Duration, f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.timers = append(c.timers, &timer{deadline: c.now + d, fire: f})
+}
+
+// queued is called when a replica queues a request to the testing server and sent once the request was sent or dropped
+func (c *clock) queued(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending += n
+	c.active = time.Now()
+}
+
+func (c *clock) sent(n int) {
+	c.queued(-n)
+}
+
+// delivered is called when the testing server delivers a message or a directive to a replica
+func (c *clock) delivered() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.active = time.Now()
+}
+
+// next removes the timers of the earliest deadline and moves the clock to it, if the network is idle
+func (c *clock) next() ([]*timer, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.pending > 0 || len(c.timers) == 0 || time.Since(c.active) < c.idle {
+		return nil, false
+	}
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline < c.timers[j].deadline
+	})
+	deadline := c.timers[0].deadline
+	i := 0
+	for i < len(c.timers) && c.timers[i].deadline == deadline {
+		i++
+	}
+	expired := c.timers[:i]
+	c.timers = c.timers[i:]
+	c.now = deadline
+	return expired, true
+}
+
+func (c *clock) run() {
+	ticker := time.NewTicker(c.idle / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.stopCh:
+			return
+		}
+		// timeouts that do not make the replicas send anything, such as the timeouts of the rounds the replicas
+		// have left, leave the network idle and the next timeouts expire right away
+		for {
+			expired, ok := c.next()
+			if !ok {
+				break
+			}
+			for _, t := range expired {
+				t.fire()
+			}
+		}
+	}
+}
+
+func (c *clock) stop() {
+	close(c.stopCh)
+}
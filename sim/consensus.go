@@ -0,0 +1,480 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/ds-test-framework/tendermint-test/util"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type step int
+
+// Steps of a round, with the values of the round steps of tendermint
+const (
+	stepPropose   step = 3
+	stepPrevote   step = 4
+	stepPrecommit step = 6
+	stepCommit    step = 8
+)
+
+type proposal struct {
+	blockID  ttypes.BlockID
+	polRound int
+	parts    *ttypes.PartSet
+	block    *ttypes.Block
+}
+
+type voteKey struct {
+	round int
+	vType prototypes.SignedMsgType
+}
+
+// consensus is a simplified model of the consensus state of tendermint v0.34. A replica
+//   - proposes the valid block if there is one, a new block otherwise
+//   - prevotes the locked block if it is locked, the proposal block if it is complete, nil otherwise
+//   - precommits and locks the block of a polka, unlocks and precommits nil on a polka for nil
+//   - commits a block once it has the block and a precommit quorum for it in any round of the height, and moves
+//     to the next height once the commit timeout expires with the precommits received until then in the last commit
+//   - moves to a later round on 2/3 votes of any kind in that round or when the precommit timeout expires
+//
+// There is no gossip, every proposal, block part and vote is sent once to every other replica through the
+// testing server, the round state is not broadcast and there is no block sync, a replica that misses the
+// block of a height cannot commit it.
+type consensus struct {
+	r *Replica
+
+	height int
+	round  int
+	step   step
+
+	lockedRound int
+	lockedBlock *ttypes.Block
+	lockedID    ttypes.BlockID
+	validRound  int
+	validBlock  *ttypes.Block
+	validID     ttypes.BlockID
+
+	proposals map[int]*proposal
+	// parts received before the proposal of their round
+	parts map[int][]*ttypes.Part
+	votes map[voteKey]map[int]*ttypes.Vote
+	// conditions of a round that trigger an action once
+	triggered map[string]bool
+
+	lastBlockID ttypes.BlockID
+	lastCommit  *ttypes.Commit
+	// round of the commit of the height, during the commit timeout
+	commitRound int
+	// messages of later heights
+	pending []*util.TMessage
+}
+
+func newConsensus(r *Replica) *consensus {
+	// the empty commit of the first height, as in tendermint
+	c := &consensus{r: r, lastCommit: ttypes.NewCommit(0, 0, ttypes.BlockID{}, nil)}
+	c.newHeight(1)
+	return c
+}
+
+func (c *consensus) newHeight(height int) {
+	c.height = height
+	c.round = 0
+	c.step = stepPropose
+	c.lockedRound, c.lockedBlock, c.lockedID = -1, nil, ttypes.BlockID{}
+	c.validRound, c.validBlock, c.validID = -1, nil, ttypes.BlockID{}
+	c.proposals = make(map[int]*proposal)
+	c.parts = make(map[int][]*ttypes.Part)
+	c.votes = make(map[voteKey]map[int]*ttypes.Vote)
+	c.triggered = make(map[string]bool)
+}
+
+func (c *consensus) once(name string, round int) bool {
+	key := name + "/" + strconv.Itoa(round)
+	if c.triggered[key] {
+		return false
+	}
+	c.triggered[key] = true
+	return true
+}
+
+func (c *consensus) quorum() int64 {
+	return c.r.valSet.TotalVotingPower()*2/3 + 1
+}
+
+// tally returns the voting power of the votes of the round for every block, "" is nil, and in total
+func (c *consensus) tally(round int, vType prototypes.SignedMsgType) (map[string]int64, int64) {
+	result := make(map[string]int64)
+	total := int64(0)
+	for index, vote := range c.votes[voteKey{round, vType}] {
+		_, val := c.r.valSet.GetByIndex(int32(index))
+		if val == nil {
+			continue
+		}
+		result[blockKey(vote.BlockID)] += val.VotingPower
+		total += val.VotingPower
+	}
+	return result, total
+}
+
+// majority returns the block with a 2/3 majority of the votes of the round, ok is false if there is none
+func (c *consensus) majority(round int, vType prototypes.SignedMsgType) (ttypes.BlockID, bool) {
+	counts, _ := c.tally(round, vType)
+	for _, vote := range c.votes[voteKey{round, vType}] {
+		if counts[blockKey(vote.BlockID)] >= c.quorum() {
+			return vote.BlockID, true
+		}
+	}
+	return ttypes.BlockID{}, false
+}
+
+func (c *consensus) hasTwoThirdsAny(round int, vType prototypes.SignedMsgType) bool {
+	_, total := c.tally(round, vType)
+	return total >= c.quorum()
+}
+
+func blockKey(blockID ttypes.BlockID) string {
+	return blockID.Key()
+}
+
+// block returns the block with the ID if it was received in a proposal of the height
+func (c *consensus) block(blockID ttypes.BlockID) (*ttypes.Block, bool) {
+	for _, p := range c.proposals {
+		if p.block != nil && p.blockID.Equals(blockID) {
+			return p.block, true
+		}
+	}
+	if c.lockedBlock != nil && c.lockedID.Equals(blockID) {
+		return c.lockedBlock, true
+	}
+	if c.validBlock != nil && c.validID.Equals(blockID) {
+		return c.validBlock, true
+	}
+	return nil, false
+}
+
+func (c *consensus) start() {
+	c.startRound(0)
+	c.check()
+}
+
+func (c *consensus) startRound(round int) {
+	c.round = round
+	c.step = stepPropose
+	c.r.schedule(c.r.config.Timeouts.propose(round), c.height, round, stepPropose)
+
+	proposer := util.GetProposer(c.r.valSet, c.height, round)
+	if bytes.Equal(proposer.Address, c.r.key.PubKey().Address()) {
+		c.propose()
+	}
+}
+
+func (c *consensus) propose() {
+	block, parts, polRound := c.validBlock, (*ttypes.PartSet)(nil), c.validRound
+	if block != nil {
+		parts = block.MakePartSet(ttypes.BlockPartSizeBytes)
+	} else {
+		block, parts = makeBlock(c.r.chainID, c.height, c.lastBlockID, c.lastCommit, c.r.valSet, c.r.key.PubKey().Address())
+		polRound = -1
+	}
+	blockID := ttypes.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+	proposalMsg, err := c.r.signProposal(c.height, c.round, polRound, blockID)
+	if err != nil {
+		c.r.logf("could not sign proposal: %s", err)
+		return
+	}
+	partMsgs, err := blockParts(c.height, c.round, parts)
+	if err != nil {
+		c.r.logf("could not create block parts: %s", err)
+		return
+	}
+	c.proposals[c.round] = &proposal{blockID: blockID, polRound: polRound, parts: parts, block: block}
+	c.r.broadcast(proposalMsg)
+	for _, part := range partMsgs {
+		c.r.broadcast(part)
+	}
+}
+
+func (c *consensus) vote(vType prototypes.SignedMsgType, blockID ttypes.BlockID) {
+	tMsg, vote, err := c.r.signVote(vType, c.height, c.round, blockID)
+	if err != nil {
+		c.r.logf("could not sign vote: %s", err)
+		return
+	}
+	c.addVote(vote, int(vote.ValidatorIndex))
+	c.r.broadcast(tMsg)
+}
+
+// enterPrevote prevotes the locked block, the proposal block or nil
+func (c *consensus) enterPrevote() {
+	c.step = stepPrevote
+	switch p := c.proposals[c.round]; {
+	case c.lockedBlock != nil:
+		c.vote(prototypes.PrevoteType, c.lockedID)
+	case p != nil && p.block != nil:
+		c.vote(prototypes.PrevoteType, p.blockID)
+	default:
+		c.vote(prototypes.PrevoteType, ttypes.BlockID{})
+	}
+}
+
+// enterPrecommit precommits the block of the polka of the round, if any, and updates the lock
+func (c *consensus) enterPrecommit() {
+	c.step = stepPrecommit
+	polka, ok := c.majority(c.round, prototypes.PrevoteType)
+	switch {
+	case !ok:
+		c.vote(prototypes.PrecommitType, ttypes.BlockID{})
+	case polka.IsZero():
+		c.lockedRound, c.lockedBlock, c.lockedID = -1, nil, ttypes.BlockID{}
+		c.vote(prototypes.PrecommitType, ttypes.BlockID{})
+	default:
+		block, ok := c.block(polka)
+		if !ok {
+			c.lockedRound, c.lockedBlock, c.lockedID = -1, nil, ttypes.BlockID{}
+			c.vote(prototypes.PrecommitType, ttypes.BlockID{})
+			return
+		}
+		c.lockedRound, c.lockedBlock, c.lockedID = c.round, block, polka
+		c.vote(prototypes.PrecommitType, polka)
+	}
+}
+
+// onTimeout is called when the timeout of the step started in the height and round expires
+func (c *consensus) onTimeout(height, round int, s step) {
+	if s == stepCommit {
+		if height == c.height && c.step == stepCommit {
+			c.nextHeight()
+		}
+		return
+	}
+	if height != c.height || round != c.round || c.step == stepCommit {
+		return
+	}
+	switch s {
+	case stepPropose:
+		if c.step == stepPropose {
+			c.enterPrevote()
+		}
+	case stepPrevote:
+		if c.step == stepPrevote {
+			c.enterPrecommit()
+		}
+	case stepPrecommit:
+		c.startRound(round + 1)
+	}
+	c.check()
+}
+
+func (c *consensus) addVote(vote *ttypes.Vote, index int) {
+	key := voteKey{int(vote.Round), vote.Type}
+	if _, ok := c.votes[key]; !ok {
+		c.votes[key] = make(map[int]*ttypes.Vote)
+	}
+	if _, ok := c.votes[key][index]; ok {
+		return
+	}
+	c.votes[key][index] = vote
+}
+
+func (c *consensus) addPart(round int, part *ttypes.Part) {
+	p, ok := c.proposals[round]
+	if !ok {
+		c.parts[round] = append(c.parts[round], part)
+		return
+	}
+	if p.block != nil {
+		return
+	}
+	p.parts.AddPart(part)
+	if !p.parts.IsComplete() {
+		return
+	}
+	block, err := util.DecodeBlock(p.parts)
+	if err != nil || !bytes.Equal(block.Hash(), p.blockID.Hash) {
+		return
+	}
+	p.block = block
+	c.r.newProposalEvent(c.height, round, p.blockID, block)
+}
+
+// receive handles a consensus message from another replica
+func (c *consensus) receive(tMsg *util.TMessage) {
+	height, round := tMsg.HeightRound()
+	if tMsg.Type == util.BlockPart {
+		blockPart := tMsg.Data.GetBlockPart()
+		height, round = int(blockPart.Height), int(blockPart.Round)
+	}
+	if height > c.height {
+		c.pending = append(c.pending, tMsg)
+		return
+	}
+	if height < c.height {
+		return
+	}
+
+	switch tMsg.Type {
+	case util.Proposal:
+		prop, ok := c.r.verifyProposal(tMsg)
+		if !ok {
+			return
+		}
+		if _, ok := c.proposals[round]; ok {
+			return
+		}
+		c.proposals[round] = &proposal{
+			blockID:  prop.BlockID,
+			polRound: int(prop.POLRound),
+			parts:    ttypes.NewPartSetFromHeader(prop.BlockID.PartSetHeader),
+		}
+		for _, part := range c.parts[round] {
+			c.addPart(round, part)
+		}
+		delete(c.parts, round)
+	case util.BlockPart:
+		part, err := ttypes.PartFromProto(&tMsg.Data.GetBlockPart().Part)
+		if err != nil {
+			return
+		}
+		c.addPart(round, part)
+	case util.Prevote, util.Precommit:
+		vote, index, ok := c.r.verifyVote(tMsg)
+		if !ok {
+			return
+		}
+		c.addVote(vote, index)
+	default:
+		return
+	}
+	c.check()
+}
+
+// isProposalComplete is true if the block of the proposal of the round has been received
+// and the polka of its POL round, if any, has been seen
+func (c *consensus) isProposalComplete() bool {
+	p, ok := c.proposals[c.round]
+	if !ok || p.block == nil {
+		return false
+	}
+	if p.polRound < 0 {
+		return true
+	}
+	polka, ok := c.majority(p.polRound, prototypes.PrevoteType)
+	return ok && polka.Equals(p.blockID)
+}
+
+// check applies the rules of the consensus until none applies
+func (c *consensus) check() {
+	for c.apply() {
+	}
+}
+
+func (c *consensus) apply() bool {
+	// only the precommits for the last commit are collected once the block is committed
+	if c.step == stepCommit {
+		return false
+	}
+	// commit a block with a precommit quorum in any round
+	for round := range c.proposals {
+		if c.tryCommit(round) {
+			return true
+		}
+	}
+	for key := range c.votes {
+		if key.vType == prototypes.PrecommitType && c.tryCommit(key.round) {
+			return true
+		}
+	}
+
+	// move to a later round with 2/3 votes of any kind
+	for key := range c.votes {
+		if key.round > c.round && c.hasTwoThirdsAny(key.round, key.vType) {
+			c.startRound(key.round)
+			return true
+		}
+	}
+
+	// update the valid block on a polka for a block that has been received
+	if polka, ok := c.majority(c.round, prototypes.PrevoteType); ok && !polka.IsZero() && c.round > c.validRound {
+		if block, ok := c.block(polka); ok {
+			c.validRound, c.validBlock, c.validID = c.round, block, polka
+		}
+	}
+
+	switch c.step {
+	case stepPropose:
+		if c.isProposalComplete() {
+			c.enterPrevote()
+			return true
+		}
+	case stepPrevote:
+		if polka, ok := c.majority(c.round, prototypes.PrevoteType); ok && (polka.IsZero() || c.isProposalComplete()) {
+			c.enterPrecommit()
+			return true
+		}
+		if c.hasTwoThirdsAny(c.round, prototypes.PrevoteType) && c.once("prevoteWait", c.round) {
+			c.r.schedule(c.r.config.Timeouts.prevote(c.round), c.height, c.round, stepPrevote)
+		}
+	}
+	if c.hasTwoThirdsAny(c.round, prototypes.PrecommitType) && c.once("precommitWait", c.round) {
+		c.r.schedule(c.r.config.Timeouts.precommit(c.round), c.height, c.round, stepPrecommit)
+	}
+	return false
+}
+
+func (c *consensus) tryCommit(round int) bool {
+	blockID, ok := c.majority(round, prototypes.PrecommitType)
+	if !ok || blockID.IsZero() {
+		return false
+	}
+	block, ok := c.block(blockID)
+	if !ok {
+		return false
+	}
+	c.commit(round, blockID, block)
+	return true
+}
+
+func (c *consensus) commit(round int, blockID ttypes.BlockID, block *ttypes.Block) {
+	c.step = stepCommit
+	c.commitRound = round
+	c.lastBlockID = blockID
+	c.lastCommit = c.commitOf(round, blockID)
+	c.r.commitEvent(c.height, blockID, block)
+	c.r.committed(c.height, blockID)
+	c.r.schedule(c.r.config.Timeouts.Commit, c.height, round, stepCommit)
+}
+
+// commitOf returns the commit with the precommits of the round for the block
+func (c *consensus) commitOf(round int, blockID ttypes.BlockID) *ttypes.Commit {
+	sigs := make([]ttypes.CommitSig, c.r.valSet.Size())
+	for i := range sigs {
+		sigs[i] = ttypes.NewCommitSigAbsent()
+	}
+	for index, vote := range c.votes[voteKey{round, prototypes.PrecommitType}] {
+		if vote.BlockID.Equals(blockID) {
+			sigs[index] = ttypes.NewCommitSigForBlock(vote.Signature, vote.ValidatorAddress, vote.Timestamp)
+		}
+	}
+	return ttypes.NewCommit(int64(c.height), int32(round), blockID, sigs)
+}
+
+// nextHeight moves to the next height once the commit timeout has expired, the last commit has
+// the precommits received during the timeout
+func (c *consensus) nextHeight() {
+	c.lastCommit = c.commitOf(c.commitRound, c.lastBlockID)
+	c.newHeight(c.height + 1)
+	pending := c.pending
+	c.pending = nil
+	c.startRound(0)
+	for _, tMsg := range pending {
+		c.receive(tMsg)
+	}
+	c.check()
+}
+
+func (c *consensus) String() string {
+	return fmt.Sprintf("height %d round %d step %d", c.height, c.round, c.step)
+}
@@ -0,0 +1,162 @@
+package sim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ds-test-framework/tendermint-test/util"
+	tmsg "github.com/tendermint/tendermint/proto/tendermint/consensus"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	ttypes "github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+	"github.com/tendermint/tendermint/version"
+)
+
+// Channels of the consensus reactor, the parser ignores messages of other channels
+const (
+	dataChannel = 0x21
+	voteChannel = 0x22
+)
+
+// GenesisTime is the time of the first block, the time of the votes and proposals of a height and round
+// are derived from it so that the same schedule produces the same messages
+var GenesisTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// messageTime is the timestamp of the votes and proposals of the height and round.
+// It increases with the height and the round and is after the time of the block of the height.
+func messageTime(height, round int) time.Time {
+	return GenesisTime.Add(time.Duration(height)*time.Second + time.Duration(round)*time.Millisecond)
+}
+
+// blockTime is the weighted median of the precommit times of the last commit as in tendermint,
+// the genesis time for the first height
+func blockTime(lastCommit *ttypes.Commit, valSet *ttypes.ValidatorSet) time.Time {
+	if len(lastCommit.Signatures) == 0 {
+		return GenesisTime
+	}
+	weighted := make([]*tmtime.WeightedTime, 0, len(lastCommit.Signatures))
+	total := int64(0)
+	for _, sig := range lastCommit.Signatures {
+		if sig.Absent() {
+			continue
+		}
+		_, val := valSet.GetByAddress(sig.ValidatorAddress)
+		if val == nil {
+			continue
+		}
+		weighted = append(weighted, tmtime.NewWeightedTime(sig.Timestamp, val.VotingPower))
+		total += val.VotingPower
+	}
+	return tmtime.WeightedMedian(weighted, total)
+}
+
+// makeBlock creates the block of the height proposed by the proposer. The transactions depend only on the height,
+// blocks of the same height proposed by different validators differ in the proposer address.
+func makeBlock(chainID string, height int, lastBlockID ttypes.BlockID, lastCommit *ttypes.Commit,
+	valSet *ttypes.ValidatorSet, proposer []byte) (*ttypes.Block, *ttypes.PartSet) {
+	txs := []ttypes.Tx{ttypes.Tx(fmt.Sprintf("height=%d", height))}
+	block := ttypes.MakeBlock(int64(height), txs, lastCommit, nil)
+	block.Header.Populate(
+		tmversion.Consensus{Block: version.BlockProtocol, App: 0},
+		chainID,
+		blockTime(lastCommit, valSet),
+		lastBlockID,
+		valSet.Hash(),
+		valSet.Hash(),
+		nil, nil, nil,
+		proposer,
+	)
+	return block, block.MakePartSet(ttypes.BlockPartSizeBytes)
+}
+
+func newTMessage(channel uint16, data *tmsg.Message) *util.TMessage {
+	return &util.TMessage{
+		ChannelID: channel,
+		Data:      data,
+	}
+}
+
+func (r *Replica) signProposal(height, round, polRound int, blockID ttypes.BlockID) (*util.TMessage, error) {
+	proposal := ttypes.NewProposal(int64(height), int32(round), int32(polRound), blockID)
+	proposal.Timestamp = messageTime(height, round)
+	proposalP := proposal.ToProto()
+	sig, err := r.key.Sign(ttypes.ProposalSignBytes(r.chainID, proposalP))
+	if err != nil {
+		return nil, err
+	}
+	proposalP.Signature = sig
+	tMsg := newTMessage(dataChannel, &tmsg.Message{
+		Sum: &tmsg.Message_Proposal{Proposal: &tmsg.Proposal{Proposal: *proposalP}},
+	})
+	tMsg.Type = util.Proposal
+	return tMsg, nil
+}
+
+func blockParts(height, round int, parts *ttypes.PartSet) ([]*util.TMessage, error) {
+	result := make([]*util.TMessage, 0, parts.Total())
+	for i := 0; i < int(parts.Total()); i++ {
+		part, err := util.NewBlockPartMessage(newTMessage(dataChannel, nil), height, round, parts.GetPart(i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, part)
+	}
+	return result, nil
+}
+
+func (r *Replica) signVote(vType prototypes.SignedMsgType, height, round int, blockID ttypes.BlockID) (*util.TMessage, *ttypes.Vote, error) {
+	index, _ := r.valSet.GetByAddress(r.key.PubKey().Address())
+	vote := &prototypes.Vote{
+		Type:             vType,
+		Height:           int64(height),
+		Round:            int32(round),
+		BlockID:          blockID.ToProto(),
+		Timestamp:        messageTime(height, round),
+		ValidatorAddress: r.key.PubKey().Address(),
+		ValidatorIndex:   index,
+	}
+	sig, err := r.key.Sign(ttypes.VoteSignBytes(r.chainID, vote))
+	if err != nil {
+		return nil, nil, err
+	}
+	vote.Signature = sig
+	v, err := ttypes.VoteFromProto(vote)
+	if err != nil {
+		return nil, nil, err
+	}
+	tMsg := newTMessage(voteChannel, &tmsg.Message{
+		Sum: &tmsg.Message_Vote{Vote: &tmsg.Vote{Vote: vote}},
+	})
+	tMsg.Type = util.Prevote
+	if vType == prototypes.PrecommitType {
+		tMsg.Type = util.Precommit
+	}
+	return tMsg, v, nil
+}
+
+// verifyProposal returns the block ID and the POL round of the proposal if it is signed by the proposer of its round
+func (r *Replica) verifyProposal(tMsg *util.TMessage) (*ttypes.Proposal, bool) {
+	proposal, err := ttypes.ProposalFromProto(&tMsg.Data.GetProposal().Proposal)
+	if err != nil || proposal.ValidateBasic() != nil {
+		return nil, false
+	}
+	proposer := util.GetProposer(r.valSet, int(proposal.Height), int(proposal.Round))
+	if !util.VerifyProposal(r.chainID, proposer, tMsg) {
+		return nil, false
+	}
+	return proposal, true
+}
+
+// verifyVote returns the vote and the index of the validator if it is signed by a validator
+func (r *Replica) verifyVote(tMsg *util.TMessage) (*ttypes.Vote, int, bool) {
+	vote, err := ttypes.VoteFromProto(tMsg.Data.GetVote().Vote)
+	if err != nil {
+		return nil, 0, false
+	}
+	index, val := r.valSet.GetByAddress(vote.ValidatorAddress)
+	if val == nil || vote.Verify(r.chainID, val.PubKey) != nil {
+		return nil, 0, false
+	}
+	return vote, int(index), true
+}
@@ -0,0 +1,241 @@
+package sim
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/config"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/cluster"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// Timeouts of the steps of a round, every timeout increases by Delta with the round as in tendermint.
+// The timeouts expire on the logical clock of the network, see Config.Idle.
+type Timeouts struct {
+	Propose   time.Duration
+	Prevote   time.Duration
+	Precommit time.Duration
+	Delta     time.Duration
+	// Commit is the time a replica waits for more precommits once it has committed a block, before it
+	// moves to the next height
+	Commit time.Duration
+}
+
+// DefaultTimeouts are the default timeouts of tendermint
+var DefaultTimeouts = Timeouts{
+	Propose:   3 * time.Second,
+	Prevote:   time.Second,
+	Precommit: time.Second,
+	Delta:     500 * time.Millisecond,
+	Commit:    time.Second,
+}
+
+func (t Timeouts) propose(round int) time.Duration {
+	return t.Propose + time.Duration(round)*t.Delta
+}
+
+func (t Timeouts) prevote(round int) time.Duration {
+	return t.Prevote + time.Duration(round)*t.Delta
+}
+
+func (t Timeouts) precommit(round int) time.Duration {
+	return t.Precommit + time.Duration(round)*t.Delta
+}
+
+// DefaultIdle is the idle duration of the network before a timeout expires, much longer than the time
+// the testing server takes to handle the events of the replicas
+const DefaultIdle = 200 * time.Millisecond
+
+// Config of a network of simulated replicas
+type Config struct {
+	// Replicas is the number of replicas
+	Replicas int
+	// ChainID of the network, the keys of the replicas are derived from it as for the nodes of a cluster
+	ChainID string
+	// ServerAddr is the address of the testing server
+	ServerAddr string
+	Timeouts   Timeouts
+	// Idle is how long the testing server should not deliver anything, with no request of the replicas in flight,
+	// before the logical clock of the network moves to the next timeout. The timeouts of the replicas expire only
+	// once the testing server has delivered every message it is going to deliver, whatever the speed of the server.
+	Idle time.Duration
+	// Logf is called with the errors of the replicas, nil discards them
+	Logf func(format string, args ...interface{})
+}
+
+// DefaultConfig returns the config of a network of n replicas talking to the testing server at serverAddr
+func DefaultConfig(n int, serverAddr string) *Config {
+	return &Config{
+		Replicas:   n,
+		ChainID:    "tendermint-test",
+		ServerAddr: serverAddr,
+		Timeouts:   DefaultTimeouts,
+		Idle:       DefaultIdle,
+	}
+}
+
+// Network is a set of simulated replicas with the validator keys of the nodes of a cluster of the same chain
+type Network struct {
+	config   *Config
+	clock    *clock
+	replicas []*Replica
+}
+
+// NewNetwork creates the replicas, they are named and keyed as the nodes of a cluster
+func NewNetwork(config *Config) (*Network, error) {
+	if config.Replicas <= 0 {
+		return nil, fmt.Errorf("invalid number of replicas %d", config.Replicas)
+	}
+	ids := make([]types.ReplicaID, config.Replicas)
+	vals := make([]*ttypes.Validator, config.Replicas)
+	for i := range ids {
+		ids[i] = types.ReplicaID(cluster.NodeName(i))
+		vals[i] = ttypes.NewValidator(cluster.ValidatorKey(config.ChainID, i).PubKey(), 1)
+	}
+	valSet := ttypes.NewValidatorSet(vals)
+
+	idle := config.Idle
+	if idle <= 0 {
+		idle = DefaultIdle
+	}
+	n := &Network{config: config, clock: newClock(idle), replicas: make([]*Replica, config.Replicas)}
+	for i := range ids {
+		peers := make([]types.ReplicaID, 0, len(ids)-1)
+		for j, id := range ids {
+			if j != i {
+				peers = append(peers, id)
+			}
+		}
+		r, err := newReplica(ids[i], cluster.ValidatorKey(config.ChainID, i), config, n.clock, valSet, peers)
+		if err != nil {
+			return nil, err
+		}
+		n.replicas[i] = r
+	}
+	return n, nil
+}
+
+// Replicas of the network
+func (n *Network) Replicas() []*Replica {
+	return n.replicas
+}
+
+// Start starts every replica and the clock of the network
+func (n *Network) Start() error {
+	for _, r := range n.replicas {
+		if err := r.Start(); err != nil {
+			n.stopReplicas()
+			return err
+		}
+	}
+	go n.clock.run()
+	return nil
+}
+
+// Stop stops every replica and the clock of the network
+func (n *Network) Stop() {
+	n.clock.stop()
+	n.stopReplicas()
+}
+
+func (n *Network) stopReplicas() {
+	for _, r := range n.replicas {
+		r.Stop()
+	}
+}
+
+// freeAddr returns a local address that is not in use
+func freeAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return l.Addr().String(), nil
+}
+
+// Run runs the testcases with a testing server on a free local port against a network of n simulated replicas
+// and returns the reports of the testcases. Only errors are logged by the testing server.
+// Returns an error if the testcases do not complete within the timeout.
+func Run(n int, testcases []*testlib.TestCase, timeout time.Duration) (*testlib.TestCaseReportStore, error) {
+	addr, err := freeAddr()
+	if err != nil {
+		return nil, err
+	}
+	return RunWithConfig(DefaultConfig(n, addr), testcases, timeout)
+}
+
+// RunWithConfig is Run with the config of the network, the testing server listens on config.ServerAddr
+func RunWithConfig(c *Config, testcases []*testlib.TestCase, timeout time.Duration) (*testlib.TestCaseReportStore, error) {
+	network, err := NewNetwork(c)
+	if err != nil {
+		return nil, err
+	}
+	server, err := testlib.NewTestingServer(
+		&config.Config{
+			APIServerAddr: c.ServerAddr,
+			NumReplicas:   c.Replicas,
+			Byzantine:     true,
+			LogConfig: config.LogConfig{
+				Path:   os.DevNull,
+				Format: "json",
+				Level:  "error",
+			},
+		},
+		&util.TMessageParser{},
+		testcases,
+	)
+	if err != nil {
+		return nil, err
+	}
+	go server.Start()
+	defer server.Stop()
+	if err := waitForServer(c.ServerAddr, 5*time.Second); err != nil {
+		return nil, err
+	}
+	if err := network.Start(); err != nil {
+		return nil, err
+	}
+	defer network.Stop()
+
+	select {
+	case <-server.Done():
+		return server.ReportStore, nil
+	case <-time.After(timeout):
+		return server.ReportStore, fmt.Errorf("testcases did not complete in %s", timeout)
+	}
+}
+
+// RunTestCase runs the testcase as Run and returns the assertion of its report. Returns an error if the testcase
+// does not complete within the timeout or has no report.
+func RunTestCase(n int, testcase *testlib.TestCase, timeout time.Duration) (bool, error) {
+	reports, err := Run(n, []*testlib.TestCase{testcase}, timeout)
+	if err != nil {
+		return false, err
+	}
+	report, ok := reports.GetReport(testcase.Name)
+	if !ok {
+		return false, fmt.Errorf("no report of the testcase %s", testcase.Name)
+	}
+	return report.Assertion, nil
+}
+
+func waitForServer(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("testing server not listening on %s: %s", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,320 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type request struct {
+	path string
+	body []byte
+	// generation of the replica when the request was created, requests of earlier generations are dropped
+	generation int
+}
+
+// Replica is a simulated tendermint validator. It registers with the testing server, sends its consensus
+// messages and events to the server and receives the messages delivered by the testing server over HTTP,
+// as the instrumented replicas do. The consensus state is reset on the RESTART directive of the server.
+type Replica struct {
+	ID     types.ReplicaID
+	config *Config
+	clock  *clock
+	key    ed25519.PrivKey
+	info   map[string]interface{}
+	// chainID and valSet are the same for every replica of the network
+	chainID string
+	valSet  *ttypes.ValidatorSet
+	peers   []types.ReplicaID
+
+	listener net.Listener
+	server   *http.Server
+	client   *http.Client
+
+	cs         *consensus
+	generation int
+	counter    int
+	commits    map[int]string
+	lock       *sync.Mutex
+
+	outbox  []*request
+	outLock *sync.Mutex
+	outCond *sync.Cond
+	stopped bool
+}
+
+func newReplica(id types.ReplicaID, key ed25519.PrivKey, config *Config, clock *clock, valSet *ttypes.ValidatorSet, peers []types.ReplicaID) (*Replica, error) {
+	info, err := util.NewReplicaInfo(key, config.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replica{
+		ID:      id,
+		config:  config,
+		clock:   clock,
+		key:     key,
+		info:    info,
+		chainID: config.ChainID,
+		valSet:  valSet,
+		peers:   peers,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		commits: make(map[int]string),
+		lock:    new(sync.Mutex),
+		outbox:  make([]*request, 0),
+		outLock: new(sync.Mutex),
+	}
+	r.outCond = sync.NewCond(r.outLock)
+	r.cs = newConsensus(r)
+	return r, nil
+}
+
+// Addr is the address the replica listens on for the messages of the testing server
+func (r *Replica) Addr() string {
+	if r.listener == nil {
+		return ""
+	}
+	return r.listener.Addr().String()
+}
+
+// Start listens for the testing server, registers the replica and starts the consensus at height 1
+func (r *Replica) Start() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	r.listener = listener
+	mux := http.NewServeMux()
+	mux.HandleFunc("/message", r.handleMessage)
+	mux.HandleFunc("/timeout", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	mux.HandleFunc("/directive", r.handleDirective)
+	r.server = &http.Server{Handler: mux}
+	go r.server.Serve(listener)
+	go r.sendLoop()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.register()
+	r.cs.start()
+	return nil
+}
+
+// Stop stops the replica, the requests that have not been sent are dropped
+func (r *Replica) Stop() {
+	r.outLock.Lock()
+	r.stopped = true
+	r.outCond.Broadcast()
+	r.outLock.Unlock()
+	if r.server != nil {
+		r.server.Close()
+	}
+}
+
+// Commits returns the hash of the block committed at every height since the last restart
+func (r *Replica) Commits() map[int]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := make(map[int]string, len(r.commits))
+	for h, id := range r.commits {
+		result[h] = id
+	}
+	return result
+}
+
+func (r *Replica) logf(format string, args ...interface{}) {
+	if r.config.Logf != nil {
+		r.config.Logf("%s: "+format, append([]interface{}{r.ID}, args...)...)
+	}
+}
+
+func (r *Replica) restart() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.outLock.Lock()
+	r.generation++
+	r.clock.sent(len(r.outbox))
+	r.outbox = make([]*request, 0)
+	r.outLock.Unlock()
+
+	r.commits = make(map[int]string)
+	r.cs = newConsensus(r)
+	r.register()
+	r.cs.start()
+}
+
+func (r *Replica) send(path string, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.logf("could not marshal request to %s: %s", path, err)
+		return
+	}
+	r.outLock.Lock()
+	r.clock.queued(1)
+	r.outbox = append(r.outbox, &request{path: path, body: body, generation: r.generation})
+	r.outCond.Signal()
+	r.outLock.Unlock()
+}
+
+// sendLoop posts the requests to the testing server in order, a message is posted before its send event
+func (r *Replica) sendLoop() {
+	for {
+		r.outLock.Lock()
+		for len(r.outbox) == 0 && !r.stopped {
+			r.outCond.Wait()
+		}
+		if r.stopped {
+			r.outLock.Unlock()
+			return
+		}
+		req := r.outbox[0]
+		r.outbox = r.outbox[1:]
+		stale := req.generation != r.generation
+		r.outLock.Unlock()
+		if !stale {
+			r.post(req)
+		}
+		r.clock.sent(1)
+	}
+}
+
+func (r *Replica) post(req *request) {
+	resp, err := r.client.Post("http://"+r.config.ServerAddr+req.path, "application/json", bytes.NewBuffer(req.body))
+	if err != nil {
+		r.logf("could not send %s: %s", req.path, err)
+		return
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+}
+
+func (r *Replica) register() {
+	r.send("/replica", &types.Replica{
+		ID:    r.ID,
+		Ready: true,
+		Info:  r.info,
+		Addr:  r.Addr(),
+	})
+}
+
+type event struct {
+	Replica   types.ReplicaID   `json:"replica"`
+	Type      string            `json:"type"`
+	Params    map[string]string `json:"params"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+func (r *Replica) event(eType string, params map[string]string) {
+	r.send("/event", &event{
+		Replica:   r.ID,
+		Type:      eType,
+		Params:    params,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// broadcast sends the message to every other replica through the testing server
+func (r *Replica) broadcast(tMsg *util.TMessage) {
+	for _, peer := range r.peers {
+		msg := tMsg.Clone().(*util.TMessage)
+		msg.From = r.ID
+		msg.To = peer
+		data, err := msg.Marshal()
+		if err != nil {
+			r.logf("could not marshal message: %s", err)
+			return
+		}
+		r.counter++
+		id := fmt.Sprintf("%s_%s_%d", r.ID, peer, r.counter)
+		r.send("/message", &types.Message{
+			From:      r.ID,
+			To:        peer,
+			Data:      data,
+			Type:      string(msg.Type),
+			ID:        id,
+			Intercept: true,
+		})
+		r.event("MessageSend", map[string]string{"message_id": id})
+	}
+}
+
+// schedule calls the consensus when the timeout of the step expires on the clock of the network,
+// unless the replica restarted
+func (r *Replica) schedule(d time.Duration, height, round int, s step) {
+	generation := r.generation
+	r.clock.afterFunc(d, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if r.generation != generation {
+			return
+		}
+		r.cs.onTimeout(height, round, s)
+	})
+}
+
+func (r *Replica) commitEvent(height int, blockID ttypes.BlockID, block *ttypes.Block) {
+	r.event(string(util.CommitEventType), map[string]string{
+		"height":     strconv.Itoa(height),
+		"block_id":   blockID.Hash.String(),
+		"block_time": strconv.FormatInt(block.Time.Unix(), 10),
+	})
+}
+
+func (r *Replica) committed(height int, blockID ttypes.BlockID) {
+	r.commits[height] = blockID.Hash.String()
+}
+
+func (r *Replica) newProposalEvent(height, round int, blockID ttypes.BlockID, block *ttypes.Block) {
+	r.event(string(util.NewProposalEventType), map[string]string{
+		"height":          strconv.Itoa(height),
+		"round":           strconv.Itoa(round),
+		"blockID":         blockID.Hash.String(),
+		"block_timestamp": strconv.FormatInt(block.Time.Unix(), 10),
+	})
+}
+
+func (r *Replica) handleMessage(w http.ResponseWriter, req *http.Request) {
+	var msg types.Message
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	r.clock.delivered()
+
+	parsed, err := (&util.TMessageParser{}).Parse(msg.Data)
+	if err != nil {
+		return
+	}
+	tMsg := parsed.(*util.TMessage)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.event("MessageReceive", map[string]string{"message_id": msg.ID})
+	if tMsg.Data != nil {
+		r.cs.receive(tMsg)
+	}
+}
+
+func (r *Replica) handleDirective(w http.ResponseWriter, req *http.Request) {
+	var directive struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&directive); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.clock.delivered()
+	if directive.Action == "RESTART" {
+		r.restart()
+	}
+	w.WriteHeader(http.StatusOK)
+}
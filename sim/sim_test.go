@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func deliverAll(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	messageID, _ := e.MessageID()
+	message, ok := c.MessagePool.Get(messageID)
+	if ok {
+		return []*types.Message{message}, true
+	}
+	return []*types.Message{}, true
+}
+
+// commitsHeight is true once every replica has committed the height
+func commitsHeight(height, replicas int) handlers.Condition {
+	committed := make(map[types.ReplicaID]bool)
+	return func(e *types.Event, c *testlib.Context) bool {
+		commit, ok := util.GetCommitEvent(e)
+		if !ok || commit.Height != height {
+			return false
+		}
+		committed[e.Replica] = true
+		return len(committed) == replicas
+	}
+}
+
+// endInSuccess ends the testcase once the state machine is in the success state
+func endInSuccess(sm *handlers.StateMachine) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if sm.InSuccessState() {
+			util.EndTestCase(c)
+		}
+		return []*types.Message{}, false
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the testcase against simulated replicas")
+	}
+	sm := handlers.NewStateMachine()
+	sm.Builder().On(commitsHeight(2, 4), handlers.SuccessStateLabel)
+	h := handlers.NewHandlerCascade(handlers.WithStateMachine(sm))
+	h.AddHandler(endInSuccess(sm))
+	h.AddHandler(deliverAll)
+
+	// the testcase ends once the replicas have committed, the timeout only bounds a run that does not commit
+	testcase := testlib.NewTestCase("Commit", time.Minute, h)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		return sm.InSuccessState()
+	})
+	ok, err := RunTestCase(4, common.WithMonitors([]*testlib.TestCase{testcase})[0], 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected every replica to commit height 2")
+	}
+}
@@ -0,0 +1,32 @@
+package bfttime
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name:        "bfttime.One",
+		Description: "Moves the timestamps of all precommits 24 hours ahead, the time of the block of height 2 should follow them.",
+		Tags:        []string{catalog.Safety},
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return OneTestCase(), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name:        "bfttime.Skew",
+		Description: "Skews the clocks of the replicas by multiples of the step and checks the time of every committed block.",
+		Tags:        []string{catalog.Safety},
+		Params: []catalog.Param{
+			{Name: "step", Type: catalog.DurationParam, Default: "1h", Description: "skew between the clocks of consecutive replicas"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			step, err := p.Duration("step")
+			if err != nil {
+				return nil, err
+			}
+			return TwoTestCase(step), nil
+		},
+	})
+}
@@ -1,7 +1,6 @@
 package bfttime
 
 import (
-	"strconv"
 	"time"
 
 	"github.com/ds-test-framework/scheduler/log"
@@ -13,27 +12,14 @@ import (
 
 func changeVoteFilter(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
 	if !e.IsMessageSend() {
-		switch cEventType := e.Type.(type) {
-		case *types.GenericEventType:
-			if cEventType.T == "NewProposal" {
-				height, ok := cEventType.Params["height"]
-				if ok && height == "2" {
-					timestampI, ok := cEventType.Params["block_timestamp"]
-					if !ok {
-						return []*types.Message{}, false
-					}
-					timestamp, err := strconv.ParseInt(timestampI, 10, 64)
-					if err != nil {
-						return []*types.Message{}, false
-					}
-					c.Logger().With(log.LogParams{
-						"height":    height,
-						"block_id":  cEventType.Params["blockID"],
-						"timestamp": time.Unix(timestamp, 0),
-					}).Info("Received new height proposal")
-					c.Vars.Set("newtimestamp", time.Unix(timestamp, 0))
-				}
-			}
+		proposal, ok := util.GetNewProposalEvent(e)
+		if ok && proposal.Height == 2 {
+			c.Logger().With(log.LogParams{
+				"height":    proposal.Height,
+				"block_id":  proposal.BlockID,
+				"timestamp": proposal.Timestamp,
+			}).Info("Received new height proposal")
+			c.Vars.Set("newtimestamp", proposal.Timestamp)
 		}
 		return []*types.Message{}, false
 	}
@@ -77,13 +63,15 @@ func changeVoteFilter(e *types.Event, c *testlib.Context) ([]*types.Message, boo
 		"to":   message.To,
 		"type": tMsg.Type,
 	}).Info("Changed the vote time")
-	return []*types.Message{c.NewMessage(message, newMsgB)}, true
+	newMsg := c.NewMessage(message, newMsgB)
+	newMsg.Parse(&util.TMessageParser{})
+	return []*types.Message{newMsg}, true
 }
 
 func OneTestCase() *testlib.TestCase {
 
 	testcase := testlib.NewTestCase("BFTTimeOne", 50*time.Second, handlers.NewGenericHandler(changeVoteFilter))
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		newTimestampI, ok := c.Vars.Get("newtimestamp")
 		if !ok {
 			return false
@@ -0,0 +1,50 @@
+package bfttime
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func setSkews(step time.Duration) common.SetupOption {
+	return func(c *testlib.Context) {
+		ids := make([]string, 0)
+		for _, r := range c.Replicas.Iter() {
+			ids = append(ids, string(r.ID))
+		}
+		sort.Strings(ids)
+		skews := make(map[types.ReplicaID]time.Duration)
+		for i, id := range ids {
+			skews[types.ReplicaID(id)] = time.Duration(i) * step
+		}
+		c.Vars.Set("skews", skews)
+	}
+}
+
+func getSkew(c *testlib.Context, replica types.ReplicaID) time.Duration {
+	skewsI, ok := c.Vars.Get("skews")
+	if !ok {
+		return 0
+	}
+	return skewsI.(map[types.ReplicaID]time.Duration)[replica]
+}
+
+// TwoTestCase runs the replicas with clocks that are skewed by multiples of step
+// and checks the time of every committed block with common.BFTTimeMonitor
+func TwoTestCase(step time.Duration) *testlib.TestCase {
+	handler := handlers.NewHandlerCascade()
+	handler.AddHandler(common.BFTTimeMonitor)
+	handler.AddHandler(common.SkewPrecommitTimes(getSkew))
+
+	testcase := testlib.NewTestCase(fmt.Sprintf("BFTTimeSkew%s", step), 50*time.Second, handler)
+	util.SetupFunc(testcase, common.Setup(setSkews(step)))
+	util.AssertFn(testcase, common.BFTTimeHolds)
+
+	return testcase
+}
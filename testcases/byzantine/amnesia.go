@@ -0,0 +1,270 @@
+package byzantine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// The faulty validators precommit the block of lockRound and vote for a different block in amnesiaRound
+const (
+	lockRound    = 0
+	amnesiaRound = 1
+)
+
+var amnesiaTx = ttypes.Tx("amnesia=proposal")
+
+// amnesiaSetup makes the proposer of the amnesia round faulty along with faults-1 other replicas
+// and splits the honest replicas in two halves. honestA is the larger half and commits in lockRound.
+// When there are more than f faulty replicas the fork is expected and recorded by the agreement monitor.
+func amnesiaSetup(faults int) func(*testlib.Context) error {
+	return func(c *testlib.Context) error {
+		n := c.Replicas.Cap()
+		f := (n - 1) / 3
+		if faults < 1 || n-faults < 2 {
+			return fmt.Errorf("testcase needs at least one faulty and two honest replicas, have %d faulty of %d", faults, n)
+		}
+		valSet, err := util.GetValidatorSet(c.Replicas)
+		if err != nil {
+			return err
+		}
+		proposer, ok := util.GetProposerReplica(c.Replicas, valSet, attackHeight, amnesiaRound)
+		if !ok {
+			return fmt.Errorf("no replica for the proposer of round %d", amnesiaRound)
+		}
+
+		others := make([]*types.Replica, 0)
+		for _, r := range c.Replicas.Iter() {
+			if r.ID != proposer.ID {
+				others = append(others, r)
+			}
+		}
+		sort.Slice(others, func(i, j int) bool { return others[i].ID < others[j].ID })
+
+		faulty := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "faulty"}
+		honestA := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "honestA"}
+		honestB := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "honestB"}
+		faulty.ReplicaSet.Add(proposer)
+		for _, r := range others[:faults-1] {
+			faulty.ReplicaSet.Add(r)
+		}
+		honest := others[faults-1:]
+		for i, r := range honest {
+			if i < (len(honest)+1)/2 {
+				honestA.ReplicaSet.Add(r)
+			} else {
+				honestB.ReplicaSet.Add(r)
+			}
+		}
+		partition := util.NewPartition(faulty, honestA, honestB)
+		c.Logger().With(log.LogParams{"partition": partition.String()}).Info("Created partition")
+
+		c.Vars.Set("n", n)
+		c.Vars.Set("faults", faults)
+		c.Vars.Set("maxFaults", f)
+		c.Vars.Set("partition", partition)
+		c.Vars.Set("proposer", string(proposer.ID))
+		if faults > f {
+			common.AllowForks(c)
+		}
+		return nil
+	}
+}
+
+// hideFirstBlock keeps the block of lockRound away from honestB. The proposals and block parts
+// of the height to honestB are dropped, the proposal of the faulty proposer in amnesiaRound is
+// replaced by a different block with an additional transaction.
+func hideFirstBlock(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	message, _ := c.GetMessage(e)
+	tMsg, ok := util.GetParsedMessage(message)
+	if !ok || (tMsg.Type != util.Proposal && tMsg.Type != util.BlockPart) || tMsg.Height() != attackHeight {
+		return []*types.Message{}, false
+	}
+
+	state := getCompetingState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if tMsg.Type == util.Proposal && tMsg.Round() == lockRound && state.blockIDA == nil {
+		state.blockIDA, _ = util.GetProposalBlockID(tMsg)
+	}
+	_, _, honestB := getParts(c)
+	if !honestB.Contains(message.To) {
+		return []*types.Message{}, false
+	}
+	if tMsg.Type == util.BlockPart || tMsg.Round() != amnesiaRound || !isProposer(c, message.From) {
+		return []*types.Message{}, true
+	}
+
+	state.buildSecondBlock(c, amnesiaTx)
+	if state.blockIDB == nil {
+		c.Logger().Warn("Second block not available to propose")
+		return []*types.Message{}, true
+	}
+	proposer, ok := c.Replicas.Get(message.From)
+	if !ok {
+		return []*types.Message{}, true
+	}
+	proposal, err := util.ChangeProposalBlock(proposer, tMsg, *state.blockIDB)
+	if err != nil {
+		c.Logger().With(log.LogParams{"error": err}).Error("Could not change proposal")
+		return []*types.Message{}, true
+	}
+	messages := make([]*types.Message, 0)
+	newMsg, err := newMessage(c, message, proposal)
+	if err != nil {
+		return []*types.Message{}, true
+	}
+	messages = append(messages, newMsg)
+	for i := 0; i < int(state.partsB.Total()); i++ {
+		part, err := util.NewBlockPartMessage(proposal, attackHeight, amnesiaRound, state.partsB.GetPart(i))
+		if err != nil {
+			continue
+		}
+		newMsg, err := newMessage(c, message, part)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, newMsg)
+	}
+	c.Logger().With(log.LogParams{"to": message.To}).Info("Sent amnesia proposal")
+	return messages, true
+}
+
+// hideCommit drops the precommits of honestA in lockRound to the other replicas,
+// only honestA commits the block of lockRound
+func hideCommit(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	message, _ := c.GetMessage(e)
+	tMsg, ok := util.GetParsedMessage(message)
+	if !ok || tMsg.Type != util.Precommit || tMsg.Height() != attackHeight || tMsg.Round() != lockRound {
+		return []*types.Message{}, false
+	}
+	_, honestA, _ := getParts(c)
+	val, _ := util.GetVoteValidator(tMsg)
+	if honestA.ContainsVal(val) && !honestA.Contains(message.To) {
+		return []*types.Message{}, true
+	}
+	return []*types.Message{}, false
+}
+
+// forgetLock changes the votes of the faulty validators in amnesiaRound to honestB to the second block.
+// The faulty validators precommitted the first block in lockRound and there is no polka for the second block.
+func forgetLock(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	message, _ := c.GetMessage(e)
+	tMsg, ok := util.GetParsedMessage(message)
+	if !ok || (tMsg.Type != util.Prevote && tMsg.Type != util.Precommit) {
+		return []*types.Message{}, false
+	}
+	if tMsg.Height() != attackHeight || tMsg.Round() != amnesiaRound {
+		return []*types.Message{}, false
+	}
+	faulty, _, honestB := getParts(c)
+	val, _ := util.GetVoteValidator(tMsg)
+	if !faulty.ContainsVal(val) || !honestB.Contains(message.To) {
+		return []*types.Message{}, false
+	}
+
+	blockID := getCompetingState(c).blockIDB
+	if blockID == nil {
+		c.Logger().With(log.LogParams{"from": message.From}).Warn("Second block not available to vote for")
+		return []*types.Message{}, false
+	}
+	replica, ok := util.GetReplicaByAddress(c.Replicas, val)
+	if !ok {
+		return []*types.Message{}, false
+	}
+	newVote, err := util.ChangeVote(replica, tMsg, blockID)
+	if err != nil {
+		return []*types.Message{}, false
+	}
+	newMsg, err := newMessage(c, message, newVote)
+	if err != nil {
+		return []*types.Message{}, false
+	}
+	c.Vars.Set("amnesiaVotes", true)
+	return []*types.Message{newMsg}, true
+}
+
+func forked(_ *types.Event, c *testlib.Context) bool {
+	return len(common.GetForks(c)) > 0
+}
+
+// forkExpected is true if there are more than f faulty replicas, the replicas might then commit different blocks
+func forkExpected(faults int) handlers.Condition {
+	return func(_ *types.Event, c *testlib.Context) bool {
+		f, _ := c.Vars.GetInt("maxFaults")
+		return faults > f
+	}
+}
+
+// forkAttributed is true if the replicas blamed for the fork are all faulty and there are more than f of them,
+// two quorums intersect in at least f+1 validators
+func forkAttributed(c *testlib.Context) bool {
+	faulty, _, _ := getParts(c)
+	f, _ := c.Vars.GetInt("maxFaults")
+	for _, fork := range common.GetForks(c) {
+		if len(fork.Faulty) <= f {
+			return false
+		}
+		for _, r := range fork.Faulty {
+			if !faulty.Contains(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Amnesia is the amnesia attack with faults faulty replicas. In height 1, honestA commits
+// the block of round 0 with the precommits of the faulty validators, the other replicas do not see the commit.
+// In round 1 the faulty validators forget their lock and vote for a different block proposed to honestB.
+// With at most f faulty replicas there should be no fork. With more than f faulty replicas the honest replicas
+// should commit different blocks and the faulty validators should be blamed for the fork.
+func Amnesia(faults int) *testlib.TestCase {
+	stateMachine := handlers.NewStateMachine()
+	stateMachine.Builder().
+		On(func(_ *types.Event, c *testlib.Context) bool { return c.Vars.Exists("amnesiaVotes") }, "amnesia").
+		On(forkExpected(faults).And(forked), handlers.SuccessStateLabel)
+
+	h := handlers.NewHandlerCascade()
+	h.AddHandler(common.RecordBlocks)
+	h.AddHandler(recordCommits)
+	h.AddHandler(handlers.NewStateMachineHandler(stateMachine))
+	h.AddHandler(hideFirstBlock)
+	h.AddHandler(hideCommit)
+	h.AddHandler(forgetLock)
+
+	testcase := testlib.NewTestCase(
+		fmt.Sprintf("AmnesiaWith%dFaulty", faults),
+		60*time.Second,
+		h,
+	)
+	util.SetupFunc(testcase, amnesiaSetup(faults))
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		if c.Vars.Exists("safetyViolation") {
+			return false
+		}
+		if forkExpected(faults)(nil, c) {
+			return stateMachine.InSuccessState() && forkAttributed(c)
+		}
+		_, ok := c.Vars.Get("honestCommits")
+		return ok && !forked(nil, c)
+	})
+	return testcase
+}
@@ -0,0 +1,185 @@
+package byzantine
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// amnesiaRun passes the events of a run of the amnesia attack to the testcase with the default monitors,
+// the messages returned by the handler are delivered right away
+type amnesiaRun struct {
+	t        *testing.T
+	k        *testkit.Kit
+	testcase *testlib.TestCase
+	handler  testlib.Handler
+}
+
+func newAmnesiaRun(t *testing.T, faults int) *amnesiaRun {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcase := Amnesia(faults)
+	setup, _ := util.Setup(testcase)
+	if err := setup(k.Context); err != nil {
+		t.Fatal(err)
+	}
+	return &amnesiaRun{
+		t:        t,
+		k:        k,
+		testcase: testcase,
+		handler:  common.WithMonitors([]*testlib.TestCase{testcase})[0].Handler,
+	}
+}
+
+// send sends the message from its replica to replica to and delivers the messages returned by the handler
+func (r *amnesiaRun) send(tMsg *util.TMessage, to int) {
+	_, e := r.k.SendTo(tMsg, to)
+	for _, m := range r.handler.HandleEvent(e, r.k.Context) {
+		r.k.Context.MessagePool.Add(m)
+		r.handler.HandleEvent(r.k.Receive(m), r.k.Context)
+	}
+}
+
+// broadcast sends the message from replica i to every other replica
+func (r *amnesiaRun) broadcast(tMsg *util.TMessage, i int) {
+	for to := range r.k.Replicas {
+		if to != i {
+			r.send(tMsg, to)
+		}
+	}
+}
+
+// propose broadcasts the proposal of the block of replica i in the round and its parts
+func (r *amnesiaRun) propose(i, round, polRound int) {
+	_, parts, blockID := r.k.Block(attackHeight, r.k.Proposer(attackHeight, 0))
+	r.broadcast(r.k.Proposal(i, attackHeight, round, polRound, blockID), i)
+	for _, part := range r.k.BlockParts(i, attackHeight, round, parts) {
+		r.broadcast(part, i)
+	}
+}
+
+// lock runs lockRound, the faulty replicas and honestA prevote and precommit the proposed block and honestA
+// commits it
+func (r *amnesiaRun) lock() {
+	faulty, honestA, _ := getParts(r.k.Context)
+	r.propose(r.k.Proposer(attackHeight, lockRound), lockRound, -1)
+	blockID := *getCompetingState(r.k.Context).blockIDA
+	for i := range r.k.Replicas {
+		if faulty.Contains(r.k.ID(i)) || honestA.Contains(r.k.ID(i)) {
+			r.broadcast(r.k.Prevote(i, attackHeight, lockRound, blockID), i)
+		}
+	}
+	for i := range r.k.Replicas {
+		if faulty.Contains(r.k.ID(i)) || honestA.Contains(r.k.ID(i)) {
+			r.broadcast(r.k.Precommit(i, attackHeight, lockRound, blockID), i)
+		}
+	}
+	r.commit(honestA, false)
+}
+
+// forget runs amnesiaRound, the faulty proposer proposes the locked block again and the faulty validators
+// vote for it
+func (r *amnesiaRun) forget() {
+	faulty, _, _ := getParts(r.k.Context)
+	r.propose(r.k.Proposer(attackHeight, amnesiaRound), amnesiaRound, lockRound)
+	blockID := *getCompetingState(r.k.Context).blockIDA
+	for i := range r.k.Replicas {
+		if faulty.Contains(r.k.ID(i)) {
+			r.broadcast(r.k.Prevote(i, attackHeight, amnesiaRound, blockID), i)
+			r.broadcast(r.k.Precommit(i, attackHeight, amnesiaRound, blockID), i)
+		}
+	}
+}
+
+// commit makes the replicas of the part commit the first block, or the second one
+func (r *amnesiaRun) commit(part *util.Part, second bool) {
+	state := getCompetingState(r.k.Context)
+	blockID := state.blockIDA
+	if second {
+		blockID = state.blockIDB
+	}
+	if blockID == nil {
+		r.t.Fatal("block to commit not proposed")
+	}
+	for i := range r.k.Replicas {
+		if part.Contains(r.k.ID(i)) {
+			r.handler.HandleEvent(r.k.Commit(i, attackHeight, *blockID), r.k.Context)
+		}
+	}
+}
+
+func (r *amnesiaRun) holds() bool {
+	assert, _ := util.Assertion(r.testcase)
+	return assert(r.k.Context)
+}
+
+func TestAmnesiaSetup(t *testing.T) {
+	r := newAmnesiaRun(t, 2)
+	faulty, _, _ := getParts(r.k.Context)
+	faults, _ := r.k.Context.Vars.GetInt("faults")
+	maxFaults, _ := r.k.Context.Vars.GetInt("maxFaults")
+	if faulty.Size() != 2 || faults != 2 || maxFaults != 1 {
+		t.Errorf("expected 2 faulty replicas of at most 1, got %d faulty, faults %d and at most %d", faulty.Size(), faults, maxFaults)
+	}
+	if !faulty.Contains(r.k.ID(r.k.Proposer(attackHeight, amnesiaRound))) {
+		t.Error("expected the proposer of the amnesia round to be faulty")
+	}
+}
+
+func TestAmnesiaWithFFaulty(t *testing.T) {
+	// the locked block is committed by every honest replica, honestB catches up on it
+	r := newAmnesiaRun(t, 1)
+	_, _, honestB := getParts(r.k.Context)
+	r.lock()
+	r.forget()
+	r.commit(honestB, false)
+	if !r.holds() {
+		t.Error("expected the testcase to pass without a fork")
+	}
+
+	// a single faulty validator cannot complete a quorum for the second block
+	r = newAmnesiaRun(t, 1)
+	_, _, honestB = getParts(r.k.Context)
+	r.lock()
+	r.forget()
+	if !r.k.Context.Vars.Exists("amnesiaVotes") {
+		t.Fatal("expected the votes of the faulty validator changed to the second block")
+	}
+	r.commit(honestB, true)
+	if !r.k.Aborted() || r.holds() {
+		t.Error("expected the commit of the second block without a quorum to fail the testcase")
+	}
+}
+
+func TestAmnesiaWithMoreThanFFaulty(t *testing.T) {
+	r := newAmnesiaRun(t, 2)
+	faulty, _, honestB := getParts(r.k.Context)
+	r.lock()
+	r.forget()
+	r.commit(honestB, true)
+	if r.k.Aborted() {
+		t.Fatal("expected the fork to be recorded without failing the testcase")
+	}
+	if !r.holds() {
+		t.Fatalf("expected the fork blamed on the faulty validators, got %+v", common.GetForks(r.k.Context))
+	}
+	for _, replica := range common.GetForks(r.k.Context)[0].Faulty {
+		if !faulty.Contains(replica) {
+			t.Errorf("expected only faulty validators blamed, got %s", replica)
+		}
+	}
+
+	// the honest replicas commit the same block, the expected fork did not happen
+	r = newAmnesiaRun(t, 2)
+	_, _, honestB = getParts(r.k.Context)
+	r.lock()
+	r.commit(honestB, false)
+	if r.holds() {
+		t.Error("expected the testcase to fail without a fork")
+	}
+}
@@ -0,0 +1,47 @@
+package byzantine
+
+import (
+	"fmt"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name: "byzantine.CompetingProposals",
+		Description: "The faulty proposer of height 1 sends two valid blocks to two halves of the honest replicas, " +
+			"they should commit the same block and the duplicate votes should produce evidence.",
+		Tags:  []string{catalog.Safety, catalog.Byzantine},
+		Sizes: catalog.BFTSizes,
+		Params: []catalog.Param{
+			{Name: "support", Type: catalog.StringParam, Default: string(SupportBoth), Description: "block the faulty validators vote for: Both, First, Second or Nil"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			support := Support(p.Get("support"))
+			switch support {
+			case SupportBoth, SupportFirst, SupportSecond, SupportNil:
+			default:
+				return nil, fmt.Errorf("unknown support %s", support)
+			}
+			return One(support), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name: "byzantine.Amnesia",
+		Description: "The faulty validators precommit a block in round 0 and vote for another block in round 1 without a polka, " +
+			"with f faulty there should be no fork, with f+1 the fork should be blamed on the faulty validators.",
+		Tags:  []string{catalog.Safety, catalog.Byzantine},
+		Sizes: catalog.BFTSizes,
+		Params: []catalog.Param{
+			{Name: "faults", Type: catalog.IntParam, Default: "2", Description: "number of faulty replicas"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			faults, err := p.Int("faults")
+			if err != nil {
+				return nil, err
+			}
+			return Amnesia(faults), nil
+		},
+	})
+}
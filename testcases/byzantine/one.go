@@ -1,81 +1,439 @@
 package byzantine
 
 import (
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/ds-test-framework/scheduler/log"
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
 )
 
-func getRoundCond(toRound int) handlers.Condition {
-	return func(e *types.Event, c *testlib.Context) bool {
-		if !e.IsMessageSend() {
-			return false
+// Support is the block that the faulty validators vote for
+type Support string
+
+const (
+	// SupportBoth votes for the first block to honestA and for the second block to honestB
+	SupportBoth Support = "Both"
+	// SupportFirst votes for the first block to all replicas
+	SupportFirst Support = "First"
+	// SupportSecond votes for the second block to all replicas
+	SupportSecond Support = "Second"
+	// SupportNil votes nil to all replicas
+	SupportNil Support = "Nil"
+)
+
+const (
+	attackHeight = 1
+	attackRound  = 0
+)
+
+var competingTx = ttypes.Tx("competing=proposal")
+
+// setupFunc makes the proposer of the attacked height and round faulty along with f-1 other replicas
+// and splits the honest replicas in two halves, honestA and honestB
+func setupFunc(c *testlib.Context) error {
+	n := c.Replicas.Cap()
+	f := (n - 1) / 3
+	if f < 1 {
+		return fmt.Errorf("testcase needs at least 4 replicas, have %d", n)
+	}
+	valSet, err := util.GetValidatorSet(c.Replicas)
+	if err != nil {
+		return err
+	}
+	proposerVal := util.GetProposer(valSet, attackHeight, attackRound)
+	proposer, ok := util.GetReplicaByAddress(c.Replicas, proposerVal.Address)
+	if !ok {
+		return fmt.Errorf("no replica for the proposer %s", proposerVal.Address)
+	}
+
+	others := make([]*types.Replica, 0)
+	for _, r := range c.Replicas.Iter() {
+		if r.ID != proposer.ID {
+			others = append(others, r)
 		}
-		messageID, _ := e.MessageID()
-		message, ok := c.MessagePool.Get(messageID)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].ID < others[j].ID })
+
+	faulty := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "faulty"}
+	honestA := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "honestA"}
+	honestB := &util.Part{ReplicaSet: util.NewReplicaSet(), Label: "honestB"}
+	faulty.ReplicaSet.Add(proposer)
+	for _, r := range others[:f-1] {
+		faulty.ReplicaSet.Add(r)
+	}
+	honest := others[f-1:]
+	for i, r := range honest {
+		if i < (len(honest)+1)/2 {
+			honestA.ReplicaSet.Add(r)
+		} else {
+			honestB.ReplicaSet.Add(r)
+		}
+	}
+	partition := util.NewPartition(faulty, honestA, honestB)
+	c.Logger().With(log.LogParams{"partition": partition.String()}).Info("Created partition")
+
+	c.Vars.Set("n", n)
+	c.Vars.Set("faults", f)
+	c.Vars.Set("partition", partition)
+	c.Vars.Set("proposer", string(proposer.ID))
+	return nil
+}
+
+func getParts(c *testlib.Context) (*util.Part, *util.Part, *util.Part) {
+	pI, _ := c.Vars.Get("partition")
+	partition := pI.(*util.Partition)
+	faulty, _ := partition.GetPart("faulty")
+	honestA, _ := partition.GetPart("honestA")
+	honestB, _ := partition.GetPart("honestB")
+	return faulty, honestA, honestB
+}
+
+func isProposer(c *testlib.Context, replica types.ReplicaID) bool {
+	proposer, _ := c.Vars.GetString("proposer")
+	return string(replica) == proposer
+}
+
+// isCrossRelay is true for messages that would carry one block from one half of the honest replicas to the other
+func isCrossRelay(c *testlib.Context, message *types.Message) bool {
+	_, honestA, honestB := getParts(c)
+	if honestB.Contains(message.To) {
+		return !honestB.Contains(message.From)
+	}
+	return honestA.Contains(message.To) && honestB.Contains(message.From)
+}
+
+func isAttacked(tMsg *util.TMessage) bool {
+	height, round := tMsg.HeightRound()
+	return height == attackHeight && round == attackRound
+}
+
+type competingState struct {
+	blockIDA *ttypes.BlockID
+	blockIDB *ttypes.BlockID
+	partsB   *ttypes.PartSet
+	// proposals of the faulty proposer to honestB waiting for the second block
+	pending map[types.ReplicaID]*types.Message
+	lock    *sync.Mutex
+}
+
+func getCompetingState(c *testlib.Context) *competingState {
+	sI, ok := c.Vars.Get("competing")
+	if !ok {
+		c.Vars.Set("competing", &competingState{
+			pending: make(map[types.ReplicaID]*types.Message),
+			lock:    new(sync.Mutex),
+		})
+		sI, _ = c.Vars.Get("competing")
+	}
+	return sI.(*competingState)
+}
+
+func newMessage(c *testlib.Context, cur *types.Message, tMsg *util.TMessage) (*types.Message, error) {
+	data, err := tMsg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	newMsg := c.NewMessage(cur, data)
+	newMsg.Parse(&util.TMessageParser{})
+	return newMsg, nil
+}
+
+// buildSecondBlock creates a different valid block from the first proposed block once it has been reassembled,
+// the second block has the additional transaction and is created by the faulty proposer
+func (s *competingState) buildSecondBlock(c *testlib.Context, tx ttypes.Tx) {
+	if s.blockIDB != nil || s.blockIDA == nil {
+		return
+	}
+	blockA, ok := common.GetBlock(c, s.blockIDA.Hash.String())
+	if !ok {
+		return
+	}
+	proposerID, _ := c.Vars.GetString("proposer")
+	proposer, ok := c.Replicas.Get(types.ReplicaID(proposerID))
+	if !ok {
+		return
+	}
+	address, err := util.GetReplicaAddress(proposer)
+	if err != nil {
+		return
+	}
+	blockB, err := util.AddTx(blockA, tx)
+	if err != nil {
+		c.Logger().With(log.LogParams{"error": err}).Error("Could not create the second block")
+		return
+	}
+	blockB.ProposerAddress = address
+	s.partsB = blockB.MakePartSet(ttypes.BlockPartSizeBytes)
+	s.blockIDB = &ttypes.BlockID{Hash: blockB.Hash(), PartSetHeader: s.partsB.Header()}
+	c.Logger().With(log.LogParams{
+		"first_block":  s.blockIDA.Hash.String(),
+		"second_block": s.blockIDB.Hash.String(),
+	}).Info("Created competing block")
+}
+
+// flush sends the second proposal and its block parts to the honestB replicas
+func (s *competingState) flush(c *testlib.Context) []*types.Message {
+	messages := make([]*types.Message, 0)
+	if s.blockIDB == nil {
+		return messages
+	}
+	for to, pMsg := range s.pending {
+		delete(s.pending, to)
+		tMsg, _ := util.GetParsedMessage(pMsg)
+		proposer, ok := c.Replicas.Get(pMsg.From)
 		if !ok {
-			return false
+			continue
+		}
+		proposal, err := util.ChangeProposalBlock(proposer, tMsg, *s.blockIDB)
+		if err != nil {
+			c.Logger().With(log.LogParams{"error": err}).Error("Could not change proposal")
+			continue
+		}
+		newMsg, err := newMessage(c, pMsg, proposal)
+		if err != nil {
+			continue
 		}
+		messages = append(messages, newMsg)
+		for i := 0; i < int(s.partsB.Total()); i++ {
+			part, err := util.NewBlockPartMessage(proposal, attackHeight, attackRound, s.partsB.GetPart(i))
+			if err != nil {
+				continue
+			}
+			newMsg, err := newMessage(c, pMsg, part)
+			if err != nil {
+				continue
+			}
+			messages = append(messages, newMsg)
+		}
+		c.Vars.Set("competingSent", true)
+		c.Logger().With(log.LogParams{"to": to}).Info("Sent competing proposal")
+	}
+	return messages
+}
 
+func changeProposal(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	message, _ := c.GetMessage(e)
+	tMsg, ok := util.GetParsedMessage(message)
+	if !ok || tMsg.Type != util.Proposal || !isAttacked(tMsg) {
+		return []*types.Message{}, false
+	}
+	if !isProposer(c, message.From) {
+		if isCrossRelay(c, message) {
+			return []*types.Message{}, true
+		}
+		return []*types.Message{}, false
+	}
+
+	state := getCompetingState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if state.blockIDA == nil {
+		state.blockIDA, _ = util.GetProposalBlockID(tMsg)
+	}
+	_, _, honestB := getParts(c)
+	if !honestB.Contains(message.To) {
+		return []*types.Message{message}, true
+	}
+	state.pending[message.To] = message
+	state.buildSecondBlock(c, competingTx)
+	return state.flush(c), true
+}
+
+func changeBlockParts(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
+	message, _ := c.GetMessage(e)
+	tMsg, ok := util.GetParsedMessage(message)
+	if !ok || tMsg.Type != util.BlockPart || !isAttacked(tMsg) {
+		return []*types.Message{}, false
+	}
+
+	state := getCompetingState(c)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	state.buildSecondBlock(c, competingTx)
+	messages := state.flush(c)
+	if isCrossRelay(c, message) {
+		// The parts of the first block to honestB are replaced by the second block
+		return messages, true
+	}
+	return append(messages, message), true
+}
+
+func changeVote(support Support) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if !e.IsMessageSend() || support == SupportFirst {
+			return []*types.Message{}, false
+		}
+		message, _ := c.GetMessage(e)
 		tMsg, ok := util.GetParsedMessage(message)
-		if !ok {
-			return false
+		if !ok || (tMsg.Type != util.Prevote && tMsg.Type != util.Precommit) || !isAttacked(tMsg) {
+			return []*types.Message{}, false
 		}
-		round := tMsg.Round()
-		rI, ok := c.Vars.Get("roundCount")
-		if !ok {
-			c.Vars.Set("roundCount", map[string]int{})
-			rI, _ = c.Vars.Get("roundCount")
+		faulty, _, honestB := getParts(c)
+		val, _ := util.GetVoteValidator(tMsg)
+		if !faulty.Contains(message.From) || !faulty.ContainsVal(val) {
+			return []*types.Message{}, false
 		}
-		roundCount := rI.(map[string]int)
-		cRound, ok := roundCount[string(message.From)]
+		if blockID, _ := util.GetVoteBlockIDS(tMsg); blockID == "" {
+			return []*types.Message{}, false
+		}
+
+		var blockID *ttypes.BlockID
+		switch support {
+		case SupportBoth:
+			if !honestB.Contains(message.To) {
+				return []*types.Message{}, false
+			}
+			blockID = getCompetingState(c).blockIDB
+		case SupportSecond:
+			blockID = getCompetingState(c).blockIDB
+		case SupportNil:
+			blockID = &ttypes.BlockID{}
+		}
+		if blockID == nil {
+			c.Logger().With(log.LogParams{"from": message.From}).Warn("Second block not available to vote for")
+			return []*types.Message{}, false
+		}
+
+		replica, ok := util.GetReplicaByAddress(c.Replicas, val)
 		if !ok {
-			roundCount[string(message.From)] = round
+			return []*types.Message{}, false
 		}
-		if cRound < round {
-			roundCount[string(message.From)] = round
+		newVote, err := util.ChangeVote(replica, tMsg, blockID)
+		if err != nil {
+			return []*types.Message{}, false
 		}
-		c.Vars.Set("roundCount", roundCount)
+		newMsg, err := newMessage(c, message, newVote)
+		if err != nil {
+			return []*types.Message{}, false
+		}
+		return []*types.Message{newMsg}, true
+	}
+}
 
-		skipped := 0
-		for _, r := range roundCount {
-			if r >= toRound {
-				skipped++
-			}
+func isFaultyEvidence(c *testlib.Context, ev ttypes.Evidence) bool {
+	faulty, _, _ := getParts(c)
+	for _, r := range faulty.ReplicaSet.Iter() {
+		replica, ok := c.Replicas.Get(r)
+		if !ok {
+			continue
+		}
+		addr, err := util.GetReplicaAddress(replica)
+		if err == nil && util.IsDuplicateVoteOf(ev, addr) {
+			return true
 		}
-		return skipped == c.Replicas.Cap()
 	}
+	return false
 }
 
-func changeProposal(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+// recordEvidence looks for duplicate vote evidence against the faulty validators,
+// gossiped by the evidence reactor or included in a committed block
+func recordEvidence(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	evidence := make([]ttypes.Evidence, 0)
+	if commit, ok := common.GetCommit(e, c); ok {
+		if block, ok := common.GetBlock(c, commit.BlockID); ok {
+			evidence = append(evidence, block.Evidence.Evidence...)
+		}
+	} else if tMsg, ok := util.GetMessageFromEvent(e, c); ok {
+		if ev, ok := util.GetEvidence(tMsg); ok {
+			evidence = append(evidence, ev...)
+		}
+	}
+	for _, ev := range evidence {
+		if isFaultyEvidence(c, ev) && !c.Vars.Exists("evidence") {
+			c.Logger().With(log.LogParams{"evidence": ev.String()}).Info("Found evidence")
+			c.Vars.Set("evidence", ev.String())
+		}
+	}
 	return []*types.Message{}, false
 }
 
-func changeVote(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+func recordCommits(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	commit, ok := common.GetCommit(e, c)
+	if !ok || commit.Height != attackHeight {
+		return []*types.Message{}, false
+	}
+	faulty, _, _ := getParts(c)
+	if faulty.Contains(commit.Replica) {
+		return []*types.Message{}, false
+	}
+	if !c.Vars.Exists("honestCommits") {
+		c.Vars.Set("honestCommits", make(map[types.ReplicaID]string))
+	}
+	cI, _ := c.Vars.Get("honestCommits")
+	cI.(map[types.ReplicaID]string)[commit.Replica] = commit.BlockID
 	return []*types.Message{}, false
 }
 
-func changeBlockParts(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
-	return []*types.Message{}, false
+// honestCommitted is true once every honest replica commits the attacked height
+func honestCommitted(_ *types.Event, c *testlib.Context) bool {
+	cI, ok := c.Vars.Get("honestCommits")
+	if !ok {
+		return false
+	}
+	faulty, _, _ := getParts(c)
+	return len(cI.(map[types.ReplicaID]string)) == c.Replicas.Cap()-faulty.Size()
 }
 
-func One() *testlib.TestCase {
+func sameBlockCommitted(c *testlib.Context) bool {
+	cI, ok := c.Vars.Get("honestCommits")
+	if !ok {
+		return false
+	}
+	blocks := make(map[string]bool)
+	for _, blockID := range cI.(map[types.ReplicaID]string) {
+		blocks[blockID] = true
+	}
+	return len(blocks) == 1
+}
+
+// One is the competing proposals scenario with the replicas of the cluster. The faulty proposer of height 1
+// sends two valid blocks to the two halves of the honest replicas and the faulty validators
+// vote according to support. The honest replicas should commit the same block and, when the
+// faulty validators vote for both blocks, evidence of the duplicate votes should be produced.
+func One(support Support) *testlib.TestCase {
 	stateMachine := handlers.NewStateMachine()
+	stateMachine.Builder().
+		On(func(_ *types.Event, c *testlib.Context) bool { return c.Vars.Exists("competingSent") }, "competing").
+		On(honestCommitted, handlers.SuccessStateLabel)
 
-	h := handlers.NewHandlerCascade(
-		handlers.WithStateMachine(stateMachine),
-	)
+	h := handlers.NewHandlerCascade()
+	h.AddHandler(common.RecordBlocks)
+	h.AddHandler(recordCommits)
+	// The state machine runs once the commits are recorded
+	h.AddHandler(handlers.NewStateMachineHandler(stateMachine))
+	h.AddHandler(recordEvidence)
 	h.AddHandler(changeProposal)
-	h.AddHandler(changeVote)
+	h.AddHandler(changeVote(support))
 	h.AddHandler(changeBlockParts)
 
 	testcase := testlib.NewTestCase(
-		"CompetingProposals",
-		30*time.Second,
+		fmt.Sprintf("CompetingProposals%s", support),
+		60*time.Second,
 		h,
 	)
+	util.SetupFunc(testcase, setupFunc)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		if c.Vars.Exists("safetyViolation") || !stateMachine.InSuccessState() || !sameBlockCommitted(c) {
+			return false
+		}
+		if support == SupportBoth {
+			return c.Vars.Exists("evidence")
+		}
+		return true
+	})
 	return testcase
 }
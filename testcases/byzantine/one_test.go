@@ -0,0 +1,234 @@
+package byzantine
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// newCompetingKit returns the kit of 4 replicas set up for the competing proposals, with the proposal and the
+// block parts of the faulty proposer of the attacked height
+func newCompetingKit(t *testing.T) (*testkit.Kit, *util.TMessage, []*util.TMessage) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setupFunc(k.Context); err != nil {
+		t.Fatal(err)
+	}
+	proposer := k.Proposer(attackHeight, attackRound)
+	_, parts, blockID := k.Block(attackHeight, proposer)
+	return k, k.Proposal(proposer, attackHeight, attackRound, -1, blockID), k.BlockParts(proposer, attackHeight, attackRound, parts)
+}
+
+// indices returns the indices of the replicas of the part
+func indices(k *testkit.Kit, part *util.Part) []int {
+	result := make([]int, 0)
+	for i := range k.Replicas {
+		if part.Contains(k.ID(i)) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func TestSetup(t *testing.T) {
+	k, _, _ := newCompetingKit(t)
+	faulty, honestA, honestB := getParts(k.Context)
+	if faulty.Size() != 1 || honestA.Size() != 2 || honestB.Size() != 1 {
+		t.Errorf("expected parts of size 1, 2 and 1, got %d, %d and %d", faulty.Size(), honestA.Size(), honestB.Size())
+	}
+	if !faulty.Contains(k.ID(k.Proposer(attackHeight, attackRound))) {
+		t.Error("expected the proposer of the attacked round to be faulty")
+	}
+	if n, _ := k.Context.Vars.GetInt("n"); n != 4 {
+		t.Errorf("expected n of the cluster, got %d", n)
+	}
+}
+
+func TestChangeProposal(t *testing.T) {
+	k, proposal, parts := newCompetingKit(t)
+	_, honestA, honestB := getParts(k.Context)
+	a, b := indices(k, honestA)[0], indices(k, honestB)[0]
+
+	m, e := k.SendTo(proposal, a)
+	if messages, ok := changeProposal(e, k.Context); !ok || len(messages) != 1 || messages[0].ID != m.ID {
+		t.Errorf("expected the proposal to honestA delivered, got %v", testkit.IDs(messages))
+	}
+	// the first block is not reassembled yet, the proposal to honestB waits for the second block
+	_, e = k.SendTo(proposal, b)
+	if messages, ok := changeProposal(e, k.Context); !ok || len(messages) != 0 {
+		t.Errorf("expected the proposal to honestB to wait, got %v", testkit.IDs(messages))
+	}
+	if _, ok := getCompetingState(k.Context).pending[k.ID(b)]; !ok {
+		t.Error("expected the proposal to honestB pending")
+	}
+
+	relayed := k.Proposal(a, attackHeight, attackRound, -1, testkit.BlockID("a"))
+	_, e = k.SendTo(relayed, b)
+	if messages, ok := changeProposal(e, k.Context); !ok || len(messages) != 0 {
+		t.Errorf("expected the proposal relayed to honestB dropped, got %v", testkit.IDs(messages))
+	}
+	_, e = k.SendTo(relayed, indices(k, honestA)[1])
+	if _, ok := changeProposal(e, k.Context); ok {
+		t.Error("expected the proposal relayed within honestA not handled")
+	}
+	_, e = k.SendTo(k.Proposal(k.Proposer(2, 0), 2, 0, -1, testkit.BlockID("a")), b)
+	if _, ok := changeProposal(e, k.Context); ok {
+		t.Error("expected the proposal of another height not handled")
+	}
+	_, e = k.SendTo(parts[0], a)
+	if _, ok := changeProposal(e, k.Context); ok {
+		t.Error("expected a block part not handled")
+	}
+}
+
+func TestChangeBlockParts(t *testing.T) {
+	k, proposal, parts := newCompetingKit(t)
+	_, honestA, honestB := getParts(k.Context)
+	a, b := indices(k, honestA)[0], indices(k, honestB)[0]
+
+	// the handler of the testcase records the blocks first
+	_, e := k.SendTo(proposal, b)
+	common.RecordBlocks(e, k.Context)
+	changeProposal(e, k.Context)
+	recordAndHandle := func(e *types.Event) ([]*types.Message, bool) {
+		common.RecordBlocks(e, k.Context)
+		return changeBlockParts(e, k.Context)
+	}
+	for _, part := range parts[:len(parts)-1] {
+		_, e := k.SendTo(part, a)
+		recordAndHandle(e)
+	}
+	m, e := k.SendTo(parts[len(parts)-1], a)
+	messages, ok := recordAndHandle(e)
+	state := getCompetingState(k.Context)
+	if !ok || state.blockIDB == nil || state.blockIDB.Equals(*state.blockIDA) {
+		t.Fatalf("expected a second block built once the first block is reassembled, got %v", testkit.IDs(messages))
+	}
+	// the second proposal and its parts are flushed to honestB along with the part to honestA
+	if len(messages) != 2+int(state.partsB.Total()) || messages[len(messages)-1].ID != m.ID {
+		t.Fatalf("expected the second proposal, its parts and the part to honestA, got %v", testkit.IDs(messages))
+	}
+	second, _ := util.GetParsedMessage(messages[0])
+	if blockID, _ := util.GetProposalBlockID(second); second.Type != util.Proposal || !blockID.Equals(*state.blockIDB) {
+		t.Errorf("expected the proposal of the second block, got %s", second.Type)
+	}
+	if messages[0].To != k.ID(b) || !k.Context.Vars.Exists("competingSent") {
+		t.Errorf("expected the second proposal sent to honestB, got %s", messages[0].To)
+	}
+
+	_, e = k.SendTo(parts[0], b)
+	if messages, ok := changeBlockParts(e, k.Context); !ok || len(messages) != 0 {
+		t.Errorf("expected the part of the first block to honestB dropped, got %v", testkit.IDs(messages))
+	}
+	_, e = k.SendTo(proposal, a)
+	if _, ok := changeBlockParts(e, k.Context); ok {
+		t.Error("expected a proposal not handled")
+	}
+}
+
+func TestChangeVote(t *testing.T) {
+	blockA := testkit.BlockID("a")
+	blockB := testkit.BlockID("b")
+	cases := []struct {
+		name    string
+		support Support
+		// vote returns the vote of the attacked round and the part of the recipient
+		vote func(k *testkit.Kit, faulty int) *util.TMessage
+		toB  bool
+		// expected is the block of the delivered vote, nil if the handler does not handle the vote
+		expected *ttypes.BlockID
+	}{
+		{"both to honestB", SupportBoth, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote(f, 1, 0, blockA) }, true, &blockB},
+		{"both to honestA", SupportBoth, func(k *testkit.Kit, f int) *util.TMessage { return k.Precommit(f, 1, 0, blockA) }, false, nil},
+		{"second", SupportSecond, func(k *testkit.Kit, f int) *util.TMessage { return k.Precommit(f, 1, 0, blockA) }, false, &blockB},
+		{"nil", SupportNil, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote(f, 1, 0, blockA) }, false, &ttypes.BlockID{}},
+		{"first", SupportFirst, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote(f, 1, 0, blockA) }, true, nil},
+		{"nil vote", SupportBoth, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote(f, 1, 0, ttypes.BlockID{}) }, true, nil},
+		{"vote of another round", SupportBoth, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote(f, 1, 1, blockA) }, true, nil},
+		{"vote of an honest replica", SupportBoth, func(k *testkit.Kit, f int) *util.TMessage { return k.Prevote((f+1)%4, 1, 0, blockA) }, true, nil},
+	}
+	for _, tc := range cases {
+		k, _, _ := newCompetingKit(t)
+		faulty, honestA, honestB := getParts(k.Context)
+		getCompetingState(k.Context).blockIDB = &blockB
+		to := indices(k, honestA)[0]
+		if tc.toB {
+			to = indices(k, honestB)[0]
+		}
+		tMsg := tc.vote(k, indices(k, faulty)[0])
+		if tMsg.From == k.ID(to) {
+			to = (to + 1) % 4
+		}
+		_, e := k.SendTo(tMsg, to)
+		messages, ok := changeVote(tc.support)(e, k.Context)
+		if ok != (tc.expected != nil) {
+			t.Errorf("%s: expected handled %v, got %v", tc.name, tc.expected != nil, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(messages) != 1 {
+			t.Fatalf("%s: expected the changed vote, got %v", tc.name, testkit.IDs(messages))
+		}
+		changed, _ := util.GetParsedMessage(messages[0])
+		if blockID, _ := util.GetVoteBlockID(changed); !blockID.Equals(*tc.expected) {
+			t.Errorf("%s: expected the vote for %s, got %s", tc.name, tc.expected.Hash, blockID.Hash)
+		}
+	}
+}
+
+func TestOne(t *testing.T) {
+	cases := []struct {
+		name    string
+		support Support
+		// second is true if honestB commits the second block
+		second   bool
+		expected bool
+	}{
+		{"same block committed", SupportFirst, false, true},
+		{"different blocks committed", SupportFirst, true, false},
+		{"no evidence of the votes for both blocks", SupportBoth, false, false},
+	}
+	for _, tc := range cases {
+		k, proposal, parts := newCompetingKit(t)
+		testcase := One(tc.support)
+		assert, _ := util.Assertion(testcase)
+		faulty, _, honestB := getParts(k.Context)
+		for i := range k.Replicas {
+			if faulty.Contains(k.ID(i)) {
+				continue
+			}
+			for _, tMsg := range append([]*util.TMessage{proposal}, parts...) {
+				_, e := k.SendTo(tMsg, i)
+				testcase.Handler.HandleEvent(e, k.Context)
+			}
+		}
+		if !k.Context.Vars.Exists("competingSent") {
+			t.Fatalf("%s: expected the competing proposal sent", tc.name)
+		}
+		blockIDA, blockIDB := getCompetingState(k.Context).blockIDA, getCompetingState(k.Context).blockIDB
+		for i := range k.Replicas {
+			if faulty.Contains(k.ID(i)) {
+				continue
+			}
+			if state, _ := k.Context.Vars.GetString("curState"); state != "competing" {
+				t.Errorf("%s: expected the competing state before the commits, got %q", tc.name, state)
+			}
+			blockID := *blockIDA
+			if tc.second && honestB.Contains(k.ID(i)) {
+				blockID = *blockIDB
+			}
+			testcase.Handler.HandleEvent(k.Commit(i, attackHeight, blockID), k.Context)
+		}
+		if got := assert(k.Context); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package testcases
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name:        "dummy.Dummy",
+		Description: "Delivers every message and passes, used to check the setup of the replicas and the server.",
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return DummyTestCase(), nil
+		},
+	})
+}
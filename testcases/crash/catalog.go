@@ -0,0 +1,26 @@
+package crash
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name: "crash.One",
+		Description: "Drops the messages to and from one replica from height 2 for a few heights, " +
+			"the replica should catch up once it recovers.",
+		Tags:  []string{catalog.Liveness},
+		Sizes: catalog.BFTSizes,
+		Params: []catalog.Param{
+			{Name: "heights", Type: catalog.IntParam, Default: "3", Description: "heights committed by the others while the replica is crashed"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			heights, err := p.Int("heights")
+			if err != nil {
+				return nil, err
+			}
+			return OneTestcase(heights), nil
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package crash
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+const (
+	crashedPart = "h"
+	crashHeight = 2
+)
+
+// OneTestcase crashes the replica of the part "h" once height 2 is committed and recovers it
+// after the others commit `heights` more heights. The replica should catch up with the network.
+func OneTestcase(heights int) *testlib.TestCase {
+	handler := handlers.NewHandlerCascade()
+	handler.AddHandler(common.Crash(crashedPart, common.OnCommit(crashHeight), common.OnCommit(crashHeight+heights)))
+
+	testcase := testlib.NewTestCase(fmt.Sprintf("CrashRecovery%d", heights), 60*time.Second, handler)
+	util.SetupFunc(testcase, common.Setup())
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		return !c.Vars.Exists("safetyViolation") && common.CaughtUp(c, crashedPart)
+	})
+	return testcase
+}
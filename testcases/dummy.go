@@ -35,7 +35,7 @@ func cond(e *types.Event, c *testlib.Context) bool {
 
 func DummyTestCase() *testlib.TestCase {
 	testcase := testlib.NewTestCase("Dummy", 20*time.Second, handlers.NewGenericHandler(handler))
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		return true
 	})
 	return testcase
@@ -0,0 +1,59 @@
+package lockedvalue
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+var params = []catalog.Param{
+	{Name: "height", Type: catalog.IntParam, Default: "1", Description: "height at which the value is locked"},
+	{Name: "seed", Type: catalog.IntParam, Default: "-1", Description: "seed of the partition of the replicas, -1 for an arbitrary partition"},
+}
+
+// newTestcase creates the testcase with the height and the seed of the parameters
+func newTestcase(p catalog.Params, testcase func(int, int64) *testlib.TestCase) (*testlib.TestCase, error) {
+	height, err := p.Int("height")
+	if err != nil {
+		return nil, err
+	}
+	seed, err := p.Int("seed")
+	if err != nil {
+		return nil, err
+	}
+	return testcase(height, int64(seed)), nil
+}
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name: "lockedvalue.One",
+		Description: "Only one replica locks on the proposal of round 0, it should prevote the locked block in round 1 " +
+			"and unlock and prevote the new proposal in round 2.",
+		Tags:   []string{catalog.Safety},
+		Sizes:  catalog.BFTSizes,
+		Params: params,
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			return newTestcase(p, One)
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name:        "lockedvalue.Two",
+		Description: "Only one replica locks on the proposal of round 0, it should not prevote a new proposal without a polka for it.",
+		Tags:        []string{catalog.Safety},
+		Sizes:       catalog.BFTSizes,
+		Params:      params,
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			return newTestcase(p, Two)
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name: "lockedvalue.Three",
+		Description: "Only one replica locks on the proposal of round 0, the faulty replicas show it a polka for a different block " +
+			"in a later round and it should relock on the new block.",
+		Tags:   []string{catalog.Safety, catalog.Byzantine},
+		Sizes:  catalog.BFTSizes,
+		Params: params,
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			return newTestcase(p, Three)
+		},
+	})
+}
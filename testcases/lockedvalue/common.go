@@ -5,6 +5,7 @@ import (
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
 )
 
@@ -28,10 +29,27 @@ func (commonCond) updateRoundCount(c *testlib.Context, round int, from types.Rep
 	return roundCount
 }
 
-func (t commonCond) roundReached(toRound int) handlers.Condition {
+// withSeed returns the setup with the partition of the replicas depending only on the seed,
+// or with an arbitrary partition if the seed is negative
+func withSeed(seed int64, setup func(*testlib.Context) error) func(*testlib.Context) error {
+	if seed < 0 {
+		return setup
+	}
+	return common.WithPartitionSeed(seed, setup)
+}
+
+// atHeight is true for the messages of the height, the testcases change the messages of the height of the lock only
+func atHeight(height int) handlers.Condition {
 	return func(e *types.Event, c *testlib.Context) bool {
 		tMsg, ok := util.GetMessageFromEvent(e, c)
-		if !ok {
+		return ok && tMsg.Height() == height
+	}
+}
+
+func (t commonCond) roundReached(height, toRound int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if !ok || tMsg.Height() != height {
 			return false
 		}
 		round := tMsg.Round()
@@ -111,8 +129,9 @@ func (commonUtil) changeFultyVote(e *types.Event, c *testlib.Context, message *t
 		if err != nil {
 			return message
 		}
-
-		return c.NewMessage(message, newMsgB)
+		newMsg := c.NewMessage(message, newMsgB)
+		newMsg.Parse(&util.TMessageParser{})
+		return newMsg
 	}
 	return message
 }
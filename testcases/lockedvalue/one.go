@@ -1,18 +1,26 @@
 package lockedvalue
 
 import (
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/ds-test-framework/scheduler/log"
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
 )
 
 type testCaseOneFilters struct{}
 
+// faultyReplicaFilter changes the votes of the faulty replicas to nil when they are sent, the receive event of the
+// changed vote is not changed again
 func (t testCaseOneFilters) faultyReplicaFilter(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
 	message, _ := c.GetMessage(e)
 	tMsg, ok := util.GetParsedMessage(message)
 	if !ok {
@@ -94,7 +102,7 @@ func (t testCaseOneFilters) Round1(e *types.Event, c *testlib.Context) ([]*types
 					"old_proposal": oldProposal,
 					"vote_blockid": voteBlockID,
 				}).Info("Failing because locked value was not voted")
-				c.Abort()
+				util.Abort(c)
 			}
 		}
 	}
@@ -140,7 +148,7 @@ func (testCaseOneFilters) Round2(e *types.Event, c *testlib.Context) ([]*types.M
 						"round0_proposal": oldProp,
 						"round2_proposal": blockID,
 					}).Info("Failing because proposals are the same! Expecting different proposals")
-					c.Abort()
+					util.Abort(c)
 				}
 			}
 		}
@@ -159,7 +167,7 @@ func (testCaseOneFilters) Round2(e *types.Event, c *testlib.Context) ([]*types.M
 					"round0_proposal": oldProp,
 					"vote":            voteBlockID,
 				}).Info("Failing because replica did not unlock")
-				c.Abort()
+				util.Abort(c)
 			}
 		}
 	}
@@ -168,8 +176,8 @@ func (testCaseOneFilters) Round2(e *types.Event, c *testlib.Context) ([]*types.M
 
 func testCaseOneSetup(c *testlib.Context) error {
 	faults := int((c.Replicas.Cap() - 1) / 3)
-	partition, _ := util.
-		NewGenericPartitioner(c.Replicas).
+	partition, _ := common.
+		NewPartitioner(c).
 		CreatePartition([]int{faults, 1, 2 * faults}, []string{"faulty", "honestDelayed", "rest"})
 	c.Vars.Set("partition", partition)
 	c.Vars.Set("faults", faults)
@@ -179,6 +187,82 @@ func testCaseOneSetup(c *testlib.Context) error {
 	return nil
 }
 
+// unlockSetup creates the partition of testCaseOneSetup with the proposer of round 1 of the height faulty and
+// the proposer of round 2 in rest. The round 1 proposer prevotes its own block, the other replicas prevote nil
+// and the delayed replica unlocks on the polka for nil only if the proposer is faulty, its prevote is changed
+// to nil. The faulty and the delayed replicas have seen the polka of round 0 and would propose the locked block
+// again in round 2, the replicas of rest propose a new block.
+func unlockSetup(height int) func(*testlib.Context) error {
+	return func(c *testlib.Context) error {
+		faults := int((c.Replicas.Cap() - 1) / 3)
+		partition, err := common.
+			NewPartitioner(c).
+			CreatePartition([]int{faults, 1, 2 * faults}, []string{"faulty", "honestDelayed", "rest"})
+		if err != nil {
+			return err
+		}
+		valSet, err := util.GetValidatorSet(c.Replicas)
+		if err != nil {
+			return err
+		}
+		round1, ok := util.GetProposerReplica(c.Replicas, valSet, height, 1)
+		if !ok {
+			return fmt.Errorf("no replica for the proposer of round 1")
+		}
+		round2, ok := util.GetProposerReplica(c.Replicas, valSet, height, 2)
+		if !ok {
+			return fmt.Errorf("no replica for the proposer of round 2")
+		}
+		partition = swapInto(c.Replicas, partition, round1.ID, "faulty", round2.ID)
+		partition = swapInto(c.Replicas, partition, round2.ID, "rest", round1.ID)
+
+		c.Vars.Set("partition", partition)
+		c.Vars.Set("faults", faults)
+		c.Logger().With(log.LogParams{
+			"partition": partition.String(),
+		}).Info("Partitiion created")
+		return nil
+	}
+}
+
+// swapInto moves the replica to the part with the label, in exchange for the replica of the part with the lowest
+// ID other than keep, or keep if it is the only replica of the part
+func swapInto(replicas *types.ReplicaStore, partition *util.Partition, id types.ReplicaID, label string, keep types.ReplicaID) *util.Partition {
+	labels := make(map[types.ReplicaID]string)
+	for l, part := range partition.Parts {
+		for _, r := range part.ReplicaSet.Iter() {
+			labels[r] = l
+		}
+	}
+	if labels[id] == label {
+		return partition
+	}
+	part, _ := partition.GetPart(label)
+	members := part.ReplicaSet.Iter()
+	sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+	other := keep
+	for _, r := range members {
+		if r != keep {
+			other = r
+			break
+		}
+	}
+	labels[id], labels[other] = label, labels[id]
+
+	parts := make(map[string]*util.Part)
+	for l := range partition.Parts {
+		parts[l] = &util.Part{ReplicaSet: util.NewReplicaSet(), Label: l}
+	}
+	for _, r := range replicas.Iter() {
+		parts[labels[r.ID]].ReplicaSet.Add(r)
+	}
+	result := make([]*util.Part, 0, len(parts))
+	for _, p := range parts {
+		result = append(result, p)
+	}
+	return util.NewPartition(result...)
+}
+
 func getReplicaPartition(c *testlib.Context) *util.Partition {
 	v, _ := c.Vars.Get("partition")
 	return v.(*util.Partition)
@@ -187,58 +271,41 @@ func getReplicaPartition(c *testlib.Context) *util.Partition {
 type testCaseOneCond struct{}
 
 func (t testCaseOneCond) commitNewCond(e *types.Event, c *testlib.Context) bool {
-	cEventType := e.Type
-	switch cEventType := cEventType.(type) {
-	case *types.GenericEventType:
-		if cEventType.T != "Committing block" {
-			return false
-		}
-		blockID, ok := cEventType.Params["block_id"]
-		if !ok {
-			return false
-		}
-		newProposalI, ok := c.Vars.Get("newProposal")
-		if !ok {
-			return false
-		}
-		newProposal := newProposalI.(string)
-		c.Logger().With(log.LogParams{
-			"new_proposal": newProposal,
-			"commit_block": blockID,
-		}).Info("Checking commit")
-		if blockID == newProposal {
-			c.EndTestCase()
-			return true
-		}
+	commit, ok := util.GetCommitEvent(e)
+	if !ok {
+		return false
+	}
+	newProposalI, ok := c.Vars.Get("newProposal")
+	if !ok {
+		return false
+	}
+	newProposal := newProposalI.(string)
+	c.Logger().With(log.LogParams{
+		"new_proposal": newProposal,
+		"commit_block": commit.BlockID,
+	}).Info("Checking commit")
+	if commit.BlockID == newProposal {
+		util.EndTestCase(c)
+		return true
 	}
 	return false
 }
 
 func (t testCaseOneCond) commitOldCond(e *types.Event, c *testlib.Context) bool {
-	cEventType := e.Type
-	switch cEventType := cEventType.(type) {
-	case *types.GenericEventType:
-		if cEventType.T != "Committing block" {
-			return false
-		}
-		blockID, ok := cEventType.Params["block_id"]
-		if !ok {
-			return false
-		}
-		oldProposalI, ok := c.Vars.Get("oldProposal")
-		if !ok {
-			return false
-		}
-		oldProposal := oldProposalI.(string)
-		c.Logger().With(log.LogParams{
-			"old_proposal": oldProposal,
-			"commit_block": blockID,
-		}).Info("Checking commit")
-		if blockID == oldProposal {
-			return true
-		}
+	commit, ok := util.GetCommitEvent(e)
+	if !ok {
+		return false
 	}
-	return false
+	oldProposalI, ok := c.Vars.Get("oldProposal")
+	if !ok {
+		return false
+	}
+	oldProposal := oldProposalI.(string)
+	c.Logger().With(log.LogParams{
+		"old_proposal": oldProposal,
+		"commit_block": commit.BlockID,
+	}).Info("Checking commit")
+	return commit.BlockID == oldProposal
 }
 
 func (testCaseOneFilters) round0Message(e *types.Event, c *testlib.Context) bool {
@@ -282,7 +349,10 @@ func (testCaseOneFilters) round0(e *types.Event, c *testlib.Context) ([]*types.M
 	return []*types.Message{message}, true
 }
 
-func One() *testlib.TestCase {
+// One locks the value of round 0 of the height in one replica only, which should unlock in round 2. The proposers
+// of rounds 1 and 2 are chosen in the partition as in unlockSetup, the other replicas depend only on the seed
+// if it is not negative.
+func One(height int, seed int64) *testlib.TestCase {
 	filters := testCaseOneFilters{}
 	cond := testCaseOneCond{}
 	commonCond := commonCond{}
@@ -292,8 +362,8 @@ func One() *testlib.TestCase {
 	builder := stateMachine.Builder()
 	round2 := builder.
 		On(commonCond.valueLockedCond, "LockedValue").
-		On(commonCond.roundReached(1), "Round1").
-		On(commonCond.roundReached(2), "Round2")
+		On(commonCond.roundReached(height, 1), "Round1").
+		On(commonCond.roundReached(height, 2), "Round2")
 
 	round2.On(cond.commitNewCond, handlers.SuccessStateLabel)
 	round2.On(cond.commitOldCond, handlers.FailStateLabel)
@@ -301,15 +371,15 @@ func One() *testlib.TestCase {
 	handler := handlers.NewHandlerCascade(
 		handlers.WithStateMachine(stateMachine),
 	)
-	handler.AddHandler(filters.faultyReplicaFilter)
-	handler.AddHandler(filters.Round0)
-	handler.AddHandler(filters.Round1)
-	handler.AddHandler(filters.Round2)
+	handler.AddHandler(common.When(atHeight(height), filters.faultyReplicaFilter))
+	handler.AddHandler(common.When(atHeight(height), filters.Round0))
+	handler.AddHandler(common.When(atHeight(height), filters.Round1))
+	handler.AddHandler(common.When(atHeight(height), filters.Round2))
 
 	testcase := testlib.NewTestCase("LockedValueOne", 50*time.Second, handler)
-	testcase.SetupFunc(testCaseOneSetup)
+	util.SetupFunc(testcase, withSeed(seed, unlockSetup(height)))
 
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		newProposal, ok := c.Vars.GetString("newProposal")
 		if !ok {
 			return false
@@ -0,0 +1,55 @@
+package lockedvalue
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/sim"
+	"github.com/ds-test-framework/tendermint-test/trace"
+)
+
+// TestOne runs the testcase with several partitions, the proposer of round 1 is faulty and the proposer of round 2
+// is in rest whatever the seed. The delayed replica then sees a polka for nil in round 1 and prevotes the new
+// proposal of round 2.
+func TestOne(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the testcase against simulated replicas")
+	}
+	for seed := int64(0); seed < 3; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed%d", seed), func(t *testing.T) {
+			testcase := One(1, seed)
+			// the testcase ends on the commit of round 2, the timeout of the server is on the wall clock and
+			// only bounds a run that does not commit, however slow the replicas are with the race detector
+			testcase.Timeout = 3 * time.Minute
+			var buf bytes.Buffer
+			tracer := trace.NewTracer(&buf)
+			tracer.Trace(testcase)
+			ok, err := sim.RunTestCase(4, common.WithMonitors([]*testlib.TestCase{testcase})[0], 5*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("expected the delayed replica to unlock and prevote the proposal of round 2")
+			}
+
+			// the assertion holds again on the recorded events
+			records, err := trace.Read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			evaluated := One(1, seed)
+			evaluation, err := trace.Evaluate(records, common.WithMonitors([]*testlib.TestCase{evaluated})[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if evaluation.Assertion != ok {
+				t.Errorf("expected the evaluation of the trace to pass as the run, got %v", evaluation.Assertion)
+			}
+		})
+	}
+}
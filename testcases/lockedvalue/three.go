@@ -1,26 +1,32 @@
 package lockedvalue
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/ds-test-framework/scheduler/log"
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
 )
 
-// Does not work need to fix the handlers
 var (
 	stateLockedValue = "lockedValue"
 	stateRound1      = "round1"
 	stateForceRelock = "forceRelock"
-	// stateRelocked    = "relocked"
 )
 
 type testCaseThreeFilters struct{}
 
+// faultyVoteFilter changes the votes of the faulty replicas when they are sent, the receive event of the changed
+// vote is not changed again
 func (testCaseThreeFilters) faultyVoteFilter(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !e.IsMessageSend() {
+		return []*types.Message{}, false
+	}
 	message, _ := c.GetMessage(e)
 	tMsg, ok := util.GetParsedMessage(message)
 	if !ok {
@@ -32,30 +38,23 @@ func (testCaseThreeFilters) faultyVoteFilter(e *types.Event, c *testlib.Context)
 
 	partition := getReplicaPartition(c)
 	faulty, _ := partition.GetPart("faulty")
-	// honestDelayed, _ := partition.GetPart("honestDelayed")
+	honestDelayed, _ := partition.GetPart("honestDelayed")
 	faultyReplica, _ := c.Replicas.Get(tMsg.From)
-	if !faulty.Contains(tMsg.From) {
+	if !faulty.Contains(tMsg.From) || !util.IsVoteFrom(tMsg, faultyReplica) {
 		return []*types.Message{}, false
 	}
 
-	// Need to fix this
-	// if c.StateMachine.CurState().Is(stateForceRelock) {
-	// if honestDelayed.Contains(tMsg.To) {
-	// 	newPropBlockIDI, _ := c.Vars.Get("newPropBlockID")
-	// 	newPropBlockID := newPropBlockIDI.(*ttypes.BlockID)
-	// 	newVote, err := util.ChangeVote(faultyReplica, tMsg, newPropBlockID)
-	// 	if err != nil {
-	// 		return []*types.Message{}, false
-	// 	}
-	// 	newMsgB, err := newVote.Marshal()
-	// 	if err != nil {
-	// 		return []*types.Message{}, false
-	// 	}
-	// 	return []*types.Message{c.NewMessage(tMsg.Message, newMsgB)}, true
-	// }
-	// }
-
-	newVote, err := util.ChangeVoteToNil(faultyReplica, tMsg)
+	var newVote *util.TMessage
+	var err error
+	newPropRound, _ := c.Vars.GetInt("newProposalRound")
+	if handlers.InState(stateForceRelock)(e, c) && honestDelayed.Contains(tMsg.To) && tMsg.Round() == newPropRound {
+		// Faulty replicas vote for the new proposal to complete the polka for honestDelayed
+		newPropBlockIDI, _ := c.Vars.Get("newPropBlockID")
+		newPropBlockID := newPropBlockIDI.(*ttypes.BlockID)
+		newVote, err = util.ChangeVote(faultyReplica, tMsg, newPropBlockID)
+	} else {
+		newVote, err = util.ChangeVoteToNil(faultyReplica, tMsg)
+	}
 	if err != nil {
 		return []*types.Message{}, false
 	}
@@ -63,7 +62,9 @@ func (testCaseThreeFilters) faultyVoteFilter(e *types.Event, c *testlib.Context)
 	if err != nil {
 		return []*types.Message{}, false
 	}
-	return []*types.Message{c.NewMessage(message, newMsgB)}, true
+	newMsg := c.NewMessage(message, newMsgB)
+	newMsg.Parse(&util.TMessageParser{})
+	return []*types.Message{newMsg}, true
 }
 
 func (testCaseThreeFilters) round0(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
@@ -115,12 +116,6 @@ func (testCaseThreeFilters) higherRound(e *types.Event, c *testlib.Context) ([]*
 		return []*types.Message{message}, true
 	}
 
-	// Need to figure this out
-	// curState := c.StateMachine.CurState()
-	// if curState.Is(stateForceRelock) {
-	// 	return []*types.Message{}, false
-	// }
-
 	blockID, ok := util.GetProposalBlockID(tMsg)
 	if !ok {
 		return []*types.Message{}, false
@@ -131,6 +126,7 @@ func (testCaseThreeFilters) higherRound(e *types.Event, c *testlib.Context) ([]*
 	if blockIDS != oldProposal {
 		c.Vars.Set("newPropBlockID", blockID)
 		c.Vars.Set("newProposal", blockIDS)
+		c.Vars.Set("newProposalRound", round)
 		return []*types.Message{message}, true
 	}
 
@@ -159,6 +155,7 @@ func (testCaseThreeCond) diffProposal(e *types.Event, c *testlib.Context) bool {
 			c.Vars.Set("newPropBlockID", blockID)
 		}
 		c.Vars.Set("newProposal", blockIDS)
+		c.Vars.Set("newProposalRound", tMsg.Round())
 		return true
 	}
 	return false
@@ -202,7 +199,8 @@ func (testCaseThreeCond) oldVote(e *types.Event, c *testlib.Context) bool {
 	if !ok {
 		return false
 	}
-	if tMsg.Type != util.Precommit {
+	// Precommits of round 0 are for the locked value
+	if tMsg.Type != util.Precommit || tMsg.Round() == 0 {
 		return false
 	}
 	partition := getReplicaPartition(c)
@@ -254,26 +252,48 @@ func (testCaseThreeCond) newVote(e *types.Event, c *testlib.Context) bool {
 		"new_proposal": newProposal,
 	}).Info("Checking vote == new proposal")
 	if blockID == newProposal {
-		c.EndTestCase()
+		util.EndTestCase(c)
 		return true
 	}
 	return false
 }
 
-func testCaseThreeSetup(c *testlib.Context) error {
-	faults := int((c.Replicas.Cap() - 1) / 3)
-	partition, _ := util.
-		NewGenericPartitioner(c.Replicas).
-		CreatePartition([]int{faults, 1, 2 * faults}, []string{"faulty", "honestDelayed", "rest"})
-	c.Vars.Set("partition", partition)
-	c.Vars.Set("faults", faults)
-	c.Logger().With(log.LogParams{
-		"partition": partition.String(),
-	}).Info("Partitiion created")
-	return nil
+// relockSetup creates the partition with the proposer of round 2 of the height in rest. The faulty and the delayed
+// replicas have seen the polka of round 0 and propose the locked block again, which is dropped, the replicas of
+// rest propose a new block. The new proposal of round 1 is sent before every replica has reached round 1, the
+// proposal of round 2 is then the first one the faulty replicas complete a polka for.
+func relockSetup(height int) func(*testlib.Context) error {
+	return func(c *testlib.Context) error {
+		faults := int((c.Replicas.Cap() - 1) / 3)
+		partition, err := common.
+			NewPartitioner(c).
+			CreatePartition([]int{faults, 1, 2 * faults}, []string{"faulty", "honestDelayed", "rest"})
+		if err != nil {
+			return err
+		}
+		valSet, err := util.GetValidatorSet(c.Replicas)
+		if err != nil {
+			return err
+		}
+		round2, ok := util.GetProposerReplica(c.Replicas, valSet, height, 2)
+		if !ok {
+			return fmt.Errorf("no replica for the proposer of round 2")
+		}
+		partition = swapInto(c.Replicas, partition, round2.ID, "rest", round2.ID)
+
+		c.Vars.Set("partition", partition)
+		c.Vars.Set("faults", faults)
+		c.Logger().With(log.LogParams{
+			"partition": partition.String(),
+		}).Info("Partitiion created")
+		return nil
+	}
 }
 
-func Three() *testlib.TestCase {
+// Three locks the value of round 0 of the height in one replica only, which should relock on the block of
+// a later round once the faulty replicas complete a polka for it. The proposer of round 2 is in rest as in
+// relockSetup, the other replicas depend only on the seed if it is not negative.
+func Three(height int, seed int64) *testlib.TestCase {
 
 	filters := testCaseThreeFilters{}
 	cond := testCaseThreeCond{}
@@ -282,8 +302,8 @@ func Three() *testlib.TestCase {
 	sm := handlers.NewStateMachine()
 	relocked := sm.Builder().
 		On(commonConds.valueLockedCond, stateLockedValue).
-		On(commonConds.roundReached(1), stateRound1).
-		On(cond.diffProposal, stateForceRelock)
+		On(commonConds.roundReached(height, 1), stateRound1).
+		On(handlers.Condition(cond.diffProposal).And(atHeight(height)), stateForceRelock)
 
 	relocked.On(cond.oldVote, handlers.FailStateLabel)
 	relocked.On(cond.newVote, handlers.SuccessStateLabel)
@@ -291,13 +311,13 @@ func Three() *testlib.TestCase {
 	handler := handlers.NewHandlerCascade(
 		handlers.WithStateMachine(sm),
 	)
-	handler.AddHandler(filters.faultyVoteFilter)
-	handler.AddHandler(filters.round0)
-	handler.AddHandler(filters.higherRound)
+	handler.AddHandler(common.When(atHeight(height), filters.faultyVoteFilter))
+	handler.AddHandler(common.When(atHeight(height), filters.round0))
+	handler.AddHandler(common.When(atHeight(height), filters.higherRound))
 
 	testcase := testlib.NewTestCase("ChangeLockedValue", 70*time.Second, handler)
-	testcase.SetupFunc(testCaseThreeSetup)
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, withSeed(seed, relockSetup(height)))
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		return sm.InSuccessState()
 	})
 	return testcase
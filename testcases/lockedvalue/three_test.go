@@ -0,0 +1,34 @@
+package lockedvalue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/sim"
+)
+
+// TestThree runs the testcase with several partitions. The faulty replicas complete the polka for the block of a
+// later round only towards the replica locked in round 0, which should relock and precommit the new block.
+func TestThree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the testcase against simulated replicas")
+	}
+	for seed := int64(0); seed < 3; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed%d", seed), func(t *testing.T) {
+			testcase := Three(1, seed)
+			// the testcase ends on the precommit of the new block, see TestOne for the timeout
+			testcase.Timeout = 3 * time.Minute
+			ok, err := sim.RunTestCase(4, common.WithMonitors([]*testlib.TestCase{testcase})[0], 5*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("expected the locked replica to relock on the block of the later round")
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
 )
 
@@ -48,14 +49,16 @@ func (testCaseTwoFilters) Round2(e *types.Event, c *testlib.Context) ([]*types.M
 					"round1_proposal": newProp,
 					"vote":            voteBlockID,
 				}).Info("Failing because replica did unlocked")
-				c.Abort()
+				util.Abort(c)
 			}
 		}
 	}
 	return []*types.Message{message}, true
 }
 
-func Two() *testlib.TestCase {
+// Two locks the value of round 0 of the height in one replica only, which should not prevote a new proposal
+// without a polka for it. The partition of the replicas depends only on the seed if it is not negative.
+func Two(height int, seed int64) *testlib.TestCase {
 
 	commonCond := commonCond{}
 	tOneCond := testCaseOneCond{}
@@ -66,8 +69,8 @@ func Two() *testlib.TestCase {
 	builder := stateMachine.Builder()
 	round2 := builder.
 		On(commonCond.valueLockedCond, "LockedValue").
-		On(commonCond.roundReached(1), "Round1").
-		On(commonCond.roundReached(2), "Round2")
+		On(commonCond.roundReached(height, 1), "Round1").
+		On(commonCond.roundReached(height, 2), "Round2")
 
 	round2.On(tOneCond.commitNewCond, handlers.SuccessStateLabel)
 	round2.On(tOneCond.commitOldCond, handlers.FailStateLabel)
@@ -75,15 +78,15 @@ func Two() *testlib.TestCase {
 	handler := handlers.NewHandlerCascade(
 		handlers.WithStateMachine(stateMachine),
 	)
-	handler.AddHandler(tOneFilters.faultyReplicaFilter)
-	handler.AddHandler(tOneFilters.Round0)
-	handler.AddHandler(tOneFilters.Round1)
-	handler.AddHandler(filters.Round2)
+	handler.AddHandler(common.When(atHeight(height), tOneFilters.faultyReplicaFilter))
+	handler.AddHandler(common.When(atHeight(height), tOneFilters.Round0))
+	handler.AddHandler(common.When(atHeight(height), tOneFilters.Round1))
+	handler.AddHandler(common.When(atHeight(height), filters.Round2))
 
 	testcase := testlib.NewTestCase("LockedValueOne", 50*time.Second, handler)
-	testcase.SetupFunc(testCaseOneSetup)
+	util.SetupFunc(testcase, withSeed(seed, testCaseOneSetup))
 
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		oldProposal, ok := c.Vars.GetString("oldProposal")
 		if !ok {
 			return false
@@ -0,0 +1,27 @@
+package replay
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name: "replay.One",
+		Description: "Captures the messages of a type at height 1 and replays them once every replica is at height 2, " +
+			"the replicas should ignore them and commit.",
+		Tags: []string{catalog.Safety},
+		Params: []catalog.Param{
+			{Name: "type", Type: catalog.StringParam, Default: string(util.Precommit), Description: "type of the replayed messages"},
+			{Name: "times", Type: catalog.IntParam, Default: "3", Description: "number of times the messages are replayed"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			times, err := p.Int("times")
+			if err != nil {
+				return nil, err
+			}
+			return OneTestcase(util.MessageType(p.Get("type")), times), nil
+		},
+	})
+}
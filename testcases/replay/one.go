@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/property"
+	"github.com/ds-test-framework/tendermint-test/util"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const capturedLabel = "stale"
+
+func isStaleMessage(mType util.MessageType, height int) handlers.Condition {
+	return func(e *types.Event, c *testlib.Context) bool {
+		tMsg, ok := util.GetMessageFromEvent(e, c)
+		if !ok {
+			return false
+		}
+		return tMsg.Type == mType && tMsg.Height() == height
+	}
+}
+
+func commitAfterReplay(e *types.Event, c *testlib.Context) bool {
+	if !common.IsCommit(e, c) {
+		return false
+	}
+	if !c.Vars.Exists("commitsAfterReplay") {
+		c.Vars.Set("commitsAfterReplay", make(map[types.ReplicaID]bool))
+	}
+	commitsI, _ := c.Vars.Get("commitsAfterReplay")
+	commits := commitsI.(map[types.ReplicaID]bool)
+	commits[e.Replica] = true
+	c.Vars.Set("commitsAfterReplay", commits)
+	return len(commits) == c.Replicas.Cap()
+}
+
+func replayEffect(c *testlib.Context, reason string, params log.LogParams) {
+	c.Logger().With(params).Error(reason)
+	util.AddReportLog(c, reason, params)
+	c.Vars.Set("replayEffect", reason)
+	util.Abort(c)
+}
+
+// replayIgnored fails the testcase when a replica acts on the replayed messages of the height: it commits
+// the height again, or evidence of duplicate votes is gossiped or committed while the replayed votes are
+// identical to the votes of the validators. The default monitors count the votes of a quorum once per validator,
+// a replica that commits with the replayed votes counted twice fails the agreement monitor.
+func replayIgnored(height int) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if !common.IsReplayed(capturedLabel)(e, c) {
+			return []*types.Message{}, false
+		}
+		evidence := make([]ttypes.Evidence, 0)
+		if commit, ok := common.GetCommit(e, c); ok {
+			if commit.Height <= height {
+				replayEffect(c, "Replayed height committed again", log.LogParams{
+					"replica": commit.Replica,
+					"height":  commit.Height,
+				})
+				return []*types.Message{}, false
+			}
+			if block, ok := common.GetBlock(c, commit.BlockID); ok {
+				evidence = append(evidence, block.Evidence.Evidence...)
+			}
+		} else if tMsg, ok := util.GetMessageFromEvent(e, c); ok {
+			if ev, ok := util.GetEvidence(tMsg); ok {
+				evidence = append(evidence, ev...)
+			}
+		}
+		for _, ev := range evidence {
+			if _, ok := ev.(*ttypes.DuplicateVoteEvidence); ok {
+				replayEffect(c, "Evidence of duplicate votes after the replay", log.LogParams{
+					"replica":  e.Replica,
+					"evidence": ev.String(),
+				})
+				break
+			}
+		}
+		return []*types.Message{}, false
+	}
+}
+
+// States:
+// 	1. Capture all messages of type mType at height 1
+// 	2. Once all replicas move to height 2, replay the captured messages `times` times
+// 	3. All replicas should ignore the stale messages and commit the next block, without committing
+// 	   height 1 again or reporting the replayed votes as duplicate votes
+func OneTestcase(mType util.MessageType, times int) *testlib.TestCase {
+	sm := handlers.NewStateMachine()
+	sm.Builder().
+		On(common.IsReplayed(capturedLabel), "replayed").
+		On(commitAfterReplay, handlers.SuccessStateLabel)
+
+	progress := property.CommitProgress(2)
+
+	handler := handlers.NewHandlerCascade(
+		handlers.WithStateMachine(sm),
+	)
+	handler.AddHandler(progress.Handler())
+	handler.AddHandler(replayIgnored(1))
+	handler.AddHandler(common.CaptureMessages(capturedLabel, isStaleMessage(mType, 1)))
+	handler.AddHandler(common.ReplayMessages(capturedLabel, common.HeightReached(2), times))
+
+	testcase := testlib.NewTestCase(fmt.Sprintf("Replay%s", mType), 50*time.Second, handler)
+	util.SetupFunc(testcase, common.Setup())
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		return sm.InSuccessState() && progress.Holds(c) && !c.Vars.Exists("replayEffect")
+	})
+	return testcase
+}
@@ -0,0 +1,33 @@
+package replay
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/tendermint-test/testkit"
+)
+
+func TestReplayIgnored(t *testing.T) {
+	cases := []struct {
+		name     string
+		replayed bool
+		height   int
+		expected bool
+	}{
+		{"next height after the replay", true, 2, false},
+		{"replayed height after the replay", true, 1, true},
+		{"replayed height before the replay", false, 1, false},
+	}
+	for _, tc := range cases {
+		k, err := testkit.New(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.replayed {
+			k.Context.Vars.Set("replayed_"+capturedLabel, true)
+		}
+		replayIgnored(1)(k.Commit(0, tc.height, testkit.BlockID("a")), k.Context)
+		if k.Aborted() != tc.expected {
+			t.Errorf("%s: expected aborted %v, got %v", tc.name, tc.expected, k.Aborted())
+		}
+	}
+}
@@ -23,12 +23,7 @@ func commit_or_round1(e *types.Event, c *testlib.Context) bool {
 		}
 	}
 
-	eType, ok := e.Type.(*types.GenericEventType)
-	if ok && eType.T == "Committing block" {
-		return true
-	}
-
-	return false
+	return util.IsEventType(e, util.CommitEventType)
 }
 
 type counter struct {
@@ -109,8 +104,8 @@ func BlockingTestcase() *testlib.TestCase {
 	handler.AddHandler(deliverDelayedFilter)
 
 	testcase := testlib.NewTestCase("BlockingTestCase", 30*time.Second, handler)
-	testcase.SetupFunc(setupFunc)
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, setupFunc)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		cmr1, ok := c.Vars.GetBool("cmr1")
 		return !ok || !cmr1
 	})
@@ -0,0 +1,48 @@
+package rskip
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name: "rskip.One",
+		Description: "Delays the prevotes of one replica and changes the prevotes of the faulty replicas to nil until the round, " +
+			"the replicas should skip rounds and commit the next height within 2 rounds once the delayed messages are delivered.",
+		Tags:  []string{catalog.Liveness},
+		Sizes: catalog.BFTSizes,
+		Params: []catalog.Param{
+			{Name: "height", Type: catalog.IntParam, Default: "1", Description: "height of the round skips"},
+			{Name: "round", Type: catalog.IntParam, Default: "2", Description: "round until which the prevotes are delayed"},
+			{Name: "seed", Type: catalog.IntParam, Default: "-1", Description: "seed of the partition of the replicas, -1 for an arbitrary partition"},
+		},
+		New: func(p catalog.Params) (*testlib.TestCase, error) {
+			height, err := p.Int("height")
+			if err != nil {
+				return nil, err
+			}
+			round, err := p.Int("round")
+			if err != nil {
+				return nil, err
+			}
+			seed, err := p.Int("seed")
+			if err != nil {
+				return nil, err
+			}
+			if seed < 0 {
+				return OneTestcase(height, round), nil
+			}
+			return SeededTestcase(height, round, int64(seed)), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name:        "rskip.Blocking",
+		Description: "Delays the first f+1 prevotes to every replica, the replicas should neither commit nor move to round 1 with the remaining prevotes.",
+		Tags:        []string{catalog.Liveness},
+		Sizes:       catalog.BFTSizes,
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return BlockingTestcase(), nil
+		},
+	})
+}
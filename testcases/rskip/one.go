@@ -7,6 +7,7 @@ import (
 	"github.com/ds-test-framework/scheduler/testlib"
 	"github.com/ds-test-framework/scheduler/testlib/handlers"
 	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
 	"github.com/ds-test-framework/tendermint-test/util"
 )
 
@@ -68,7 +69,7 @@ func roundReached(toRound int) handlers.Condition {
 
 func setupFunc(c *testlib.Context) error {
 	faults := int((c.Replicas.Cap() - 1) / 3)
-	partitioner := util.NewGenericPartitioner(c.Replicas)
+	partitioner := common.NewPartitioner(c)
 	partition, _ := partitioner.CreatePartition([]int{1, faults, 2 * faults}, []string{"honestDelayed", "faulty", "rest"})
 	c.Vars.Set("partition", partition)
 	delayedMessages := types.NewMessageStore()
@@ -127,7 +128,9 @@ func changeVoteFilter(height, round int) handlers.HandlerFunc {
 			if err != nil {
 				return []*types.Message{}, false
 			}
-			return []*types.Message{c.NewMessage(message, data)}, true
+			newMsg := c.NewMessage(message, data)
+			newMsg.Parse(&util.TMessageParser{})
+			return []*types.Message{newMsg}, true
 		} else {
 			delayedM := getDelayedMStore(c)
 			delayedM.Add(message)
@@ -169,15 +172,23 @@ func OneTestcase(height, round int) *testlib.TestCase {
 	handler := handlers.NewHandlerCascade(
 		handlers.WithStateMachine(sm),
 	)
+	handler.AddHandler(common.LivenessMonitor(handlers.InState("deliverDelayed"), 2, 10*time.Second))
 	handler.AddHandler(changeVoteFilter(height, round))
-	handler.AddHandler(handlers.If(handlers.InState("deliverDelayed")).Then(deliverDelayedFilter))
+	handler.AddHandler(common.When(handlers.InState("deliverDelayed"), deliverDelayedFilter))
 
 	testcase := testlib.NewTestCase("RoundSkipPrevote", 30*time.Second, handler)
-	testcase.SetupFunc(setupFunc)
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, setupFunc)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		curRound, ok := c.Vars.GetInt("CurRound")
-		return ok && curRound == round
+		return ok && curRound == round && common.LivenessHolds(c)
 	})
 
 	return testcase
 }
+
+// SeededTestcase is OneTestcase with the partition of the replicas depending only on the seed
+func SeededTestcase(height, round int, seed int64) *testlib.TestCase {
+	testcase := OneTestcase(height, round)
+	util.SetupFunc(testcase, common.WithPartitionSeed(seed, setupFunc))
+	return testcase
+}
@@ -0,0 +1,78 @@
+package rskip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/sim"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func TestChangeVoteFilter(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Partition(map[string][]int{"honestDelayed": {0}, "faulty": {1}, "rest": {2, 3}})
+	k.Context.Vars.Set("delayedMessages", types.NewMessageStore())
+	filter := changeVoteFilter(1, 2)
+	blockID := testkit.BlockID("a")
+
+	cases := []struct {
+		name  string
+		tMsg  *util.TMessage
+		to    int
+		count int
+		ok    bool
+		// nilVote is true if the delivered message is a prevote changed to nil
+		nilVote bool
+		delayed int
+	}{
+		{"prevote of rest", k.Prevote(2, 1, 0, blockID), 0, 1, true, false, 0},
+		{"prevote of faulty", k.Prevote(1, 1, 1, blockID), 0, 1, true, true, 0},
+		{"prevote of honest delayed", k.Prevote(0, 1, 0, blockID), 2, 0, true, false, 1},
+		{"prevote of round 2", k.Prevote(0, 1, 2, blockID), 2, 1, true, false, 1},
+		{"prevote of height 2", k.Prevote(1, 2, 0, blockID), 2, 1, true, false, 1},
+		{"precommit", k.Precommit(0, 1, 0, blockID), 2, 1, false, false, 1},
+	}
+	for _, tc := range cases {
+		m, e := k.SendTo(tc.tMsg, tc.to)
+		messages, ok := filter(e, k.Context)
+		if ok != tc.ok || len(messages) != tc.count {
+			t.Errorf("%s: expected %d messages and %v, got %v and %v", tc.name, tc.count, tc.ok, testkit.IDs(messages), ok)
+			continue
+		}
+		if delayed := getDelayedMStore(k.Context).Size(); delayed != tc.delayed {
+			t.Errorf("%s: expected %d delayed messages, got %d", tc.name, tc.delayed, delayed)
+		}
+		if tc.count == 0 {
+			continue
+		}
+		delivered, ok := util.GetParsedMessage(messages[0])
+		if !ok {
+			t.Fatalf("%s: could not parse the delivered message", tc.name)
+		}
+		blockIDS, _ := util.GetVoteBlockIDS(delivered)
+		if tc.nilVote != (blockIDS == "") || tc.nilVote == (messages[0].ID == m.ID) {
+			t.Errorf("%s: expected nil vote %v, got block %q", tc.name, tc.nilVote, blockIDS)
+		}
+	}
+}
+
+func TestOneTestcase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the testcase against simulated replicas")
+	}
+	testcase := SeededTestcase(1, 2, 1)
+	ok, err := sim.RunTestCase(4, common.WithMonitors([]*testlib.TestCase{testcase})[0], time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected the replicas to skip to round 2 and commit height 1")
+	}
+}
@@ -0,0 +1,46 @@
+package sanity
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/tendermint-test/catalog"
+)
+
+func init() {
+	catalog.Register(&catalog.Entry{
+		Name:        "sanity.One",
+		Description: "Forces a round skip and checks that the quorums of the two rounds intersect in at least f+1 replicas.",
+		Tags:        []string{catalog.Safety},
+		Sizes:       catalog.BFTSizes,
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return OneTestCase(), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name: "sanity.Two",
+		Description: "Forces a round skip after the replicas lock and proposes a nil block in round 1, " +
+			"the replicas should commit the block of round 0.",
+		Tags:  []string{catalog.Safety},
+		Sizes: catalog.BFTSizes,
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return TwoTestCase(), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name:        "sanity.Three",
+		Description: "Only f+1 replicas lock on the proposal of round 0, checks the prevotes of the locked replicas in the next round.",
+		Tags:        []string{catalog.Safety},
+		Sizes:       catalog.BFTSizes,
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return ThreeTestCase(), nil
+		},
+	})
+	catalog.Register(&catalog.Entry{
+		Name:        "sanity.HigherProp",
+		Description: "A replica locked in a round should accept the proposal of a higher round with a polka from a round after its lock.",
+		Tags:        []string{catalog.Safety},
+		Sizes:       catalog.BFTSizes,
+		New: func(_ catalog.Params) (*testlib.TestCase, error) {
+			return HigherProp(), nil
+		},
+	})
+}
@@ -50,7 +50,9 @@ func (h higherPropFilters) faultyFilter(e *types.Event, c *testlib.Context) ([]*
 		if err != nil {
 			return []*types.Message{}, false
 		}
-		return []*types.Message{c.NewMessage(message, newMsgB)}, true
+		newMsg := c.NewMessage(message, newMsgB)
+		newMsg.Parse(&util.TMessageParser{})
+		return []*types.Message{newMsg}, true
 	}
 
 	newVote, err := util.ChangeVoteToNil(replica, tMsg)
@@ -61,7 +63,9 @@ func (h higherPropFilters) faultyFilter(e *types.Event, c *testlib.Context) ([]*
 	if err != nil {
 		return []*types.Message{}, false
 	}
-	return []*types.Message{c.NewMessage(message, newMsgB)}, true
+	newMsg := c.NewMessage(message, newMsgB)
+	newMsg.Parse(&util.TMessageParser{})
+	return []*types.Message{newMsg}, true
 }
 
 func (higherPropFilters) round0(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
@@ -322,8 +326,8 @@ func HigherProp() *testlib.TestCase {
 	handler.AddHandler(filter.propFilter)
 
 	testcase := testlib.NewTestCase("HigherLockedRoundProp", 3*time.Minute, handler)
-	testcase.SetupFunc(higherPropSetup)
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, higherPropSetup)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		return sm.InSuccessState()
 	})
 	return testcase
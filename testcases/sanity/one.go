@@ -133,7 +133,7 @@ func quorumCond() handlers.Condition {
 
 			ok, err := findIntersection(votes.recorded, roundZeroVotes.recorded, faults)
 			if err == errDifferentQuorum {
-				c.Abort()
+				util.Abort(c)
 			}
 			if ok {
 				c.Vars.Set("QuorumIntersection", true)
@@ -200,8 +200,8 @@ func OneTestCase() *testlib.TestCase {
 	handler.AddHandler(filters.round0)
 
 	testcase := testlib.NewTestCase("QuorumIntersection", 50*time.Second, handler)
-	testcase.SetupFunc(common.Setup(setupVoteCount))
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, common.Setup(setupVoteCount))
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		i, ok := c.Vars.GetBool("QuorumIntersection")
 		return ok && i
 	})
@@ -98,8 +98,8 @@ func ThreeTestCase() *testlib.TestCase {
 	)
 
 	testcase := testlib.NewTestCase("LockedValueCheck", 30*time.Second, handler)
-	testcase.SetupFunc(common.Setup(threeSetup))
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, common.Setup(threeSetup))
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		commitBlock, ok := c.Vars.GetString("CommitBlockID")
 		if !ok {
 			return false
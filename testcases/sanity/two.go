@@ -50,7 +50,9 @@ func (twoFilters) changeProposal(e *types.Event, c *testlib.Context) ([]*types.M
 		c.Logger().With(log.LogParams{"error": err}).Error("Failed to marshal changed proposal")
 		return []*types.Message{message}, true
 	}
-	return []*types.Message{c.NewMessage(message, newMsgB)}, true
+	newMsg := c.NewMessage(message, newMsgB)
+	newMsg.Parse(&util.TMessageParser{})
+	return []*types.Message{newMsg}, true
 }
 
 // States:
@@ -92,8 +94,8 @@ func TwoTestCase() *testlib.TestCase {
 	)
 
 	testcase := testlib.NewTestCase("WrongProposal", 30*time.Second, handler)
-	testcase.SetupFunc(common.Setup())
-	testcase.AssertFn(func(c *testlib.Context) bool {
+	util.SetupFunc(testcase, common.Setup())
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
 		committed, ok := c.Vars.GetBool("Committed")
 		if !ok {
 			return false
@@ -0,0 +1,205 @@
+package testkit
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/config"
+	"github.com/ds-test-framework/scheduler/context"
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/cluster"
+	"github.com/ds-test-framework/tendermint-test/util"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// ChainID of the replicas of a kit
+const ChainID = "tendermint-test"
+
+// Kit builds the events and messages of a network of replicas for unit tests of handlers and conditions.
+// The messages are signed with the validator keys of the nodes of a cluster of the same size and are added
+// to the message pool of the context, the events are passed to the handlers as the testing server would.
+type Kit struct {
+	// Replicas are named node0, node1, ... with the keys of the nodes of a cluster
+	Replicas []*types.Replica
+	Keys     []ed25519.PrivKey
+	ValSet   *ttypes.ValidatorSet
+	// Context has the replicas, the variables "n" and "faults" and the partition set with Partition
+	Context  *testlib.Context
+	TestCase *testlib.TestCase
+
+	messages int
+	events   uint64
+}
+
+// New returns the kit of a network of n replicas
+func New(n int) (*Kit, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of replicas %d", n)
+	}
+	logger := log.NewLogger(config.LogConfig{Path: os.DevNull, Format: "json"})
+	root := context.NewRootContext(&config.Config{NumReplicas: n, Byzantine: true}, logger)
+
+	k := &Kit{
+		Replicas: make([]*types.Replica, n),
+		Keys:     make([]ed25519.PrivKey, n),
+	}
+	for i := 0; i < n; i++ {
+		k.Keys[i] = cluster.ValidatorKey(ChainID, i)
+		info, err := util.NewReplicaInfo(k.Keys[i], ChainID)
+		if err != nil {
+			return nil, err
+		}
+		k.Replicas[i] = &types.Replica{
+			ID:    types.ReplicaID(cluster.NodeName(i)),
+			Ready: true,
+			Info:  info,
+		}
+		root.Replicas.Add(k.Replicas[i])
+	}
+	valSet, err := util.GetValidatorSet(root.Replicas)
+	if err != nil {
+		return nil, err
+	}
+	k.ValSet = valSet
+
+	k.TestCase = testlib.NewTestCase("testkit", time.Minute, &testlib.DoNothingHandler{})
+	k.TestCase.Logger = logger
+	k.Context = testlib.NewContext(root, k.TestCase, testlib.NewTestCaseReport(k.TestCase.Name))
+	k.Context.Vars.Set("n", n)
+	k.Context.Vars.Set("faults", (n-1)/3)
+	return k, nil
+}
+
+// ID of replica i
+func (k *Kit) ID(i int) types.ReplicaID {
+	return k.Replicas[i].ID
+}
+
+// Partition sets the partition of the context to the parts with the replicas of the indices,
+// for example map[string][]int{"h": {0}, "faulty": {1}, "rest": {2, 3}}
+func (k *Kit) Partition(parts map[string][]int) *util.Partition {
+	labels := make([]string, 0, len(parts))
+	for label := range parts {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	result := make([]*util.Part, len(labels))
+	for i, label := range labels {
+		result[i] = &util.Part{ReplicaSet: util.NewReplicaSet(), Label: label}
+		for _, index := range parts[label] {
+			result[i].ReplicaSet.Add(k.Replicas[index])
+		}
+	}
+	partition := util.NewPartition(result...)
+	k.Context.Vars.Set("partition", partition)
+	return partition
+}
+
+// Proposer returns the index of the expected proposer of the height and round
+func (k *Kit) Proposer(height, round int) int {
+	proposer := util.GetProposer(k.ValSet, height, round)
+	replica, _ := util.GetReplicaByAddress(k.Context.Replicas, proposer.Address)
+	for i, r := range k.Replicas {
+		if r.ID == replica.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Message returns the message of tMsg from its sender to replica to and adds it to the message pool
+func (k *Kit) Message(tMsg *util.TMessage, to int) *types.Message {
+	parsed := tMsg.Clone().(*util.TMessage)
+	parsed.To = k.ID(to)
+	data, err := parsed.Marshal()
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal %s message: %s", tMsg.Type, err))
+	}
+	k.messages++
+	m := &types.Message{
+		From:          parsed.From,
+		To:            parsed.To,
+		Data:          data,
+		Type:          string(parsed.Type),
+		ID:            fmt.Sprintf("%s_%s_%d", parsed.From, parsed.To, k.messages),
+		Intercept:     true,
+		ParsedMessage: parsed,
+	}
+	k.Context.MessagePool.Add(m)
+	return m
+}
+
+func (k *Kit) event(replica types.ReplicaID, t types.EventType) *types.Event {
+	k.events++
+	return types.NewEvent(replica, t, t.String(), k.events, time.Now().Unix())
+}
+
+// Send returns the event of the sender of the message sending it
+func (k *Kit) Send(m *types.Message) *types.Event {
+	return k.event(m.From, types.NewMessageSendEventType(m.ID))
+}
+
+// Receive returns the event of the recipient of the message receiving it
+func (k *Kit) Receive(m *types.Message) *types.Event {
+	return k.event(m.To, types.NewMessageReceiveEventType(m.ID))
+}
+
+// SendTo returns the message of tMsg to replica to and the event of sending it
+func (k *Kit) SendTo(tMsg *util.TMessage, to int) (*types.Message, *types.Event) {
+	m := k.Message(tMsg, to)
+	return m, k.Send(m)
+}
+
+// Commit returns the event of replica i committing the block at the height
+func (k *Kit) Commit(i, height int, blockID ttypes.BlockID) *types.Event {
+	return k.event(k.ID(i), types.NewGenericEventType(map[string]string{
+		"height":     strconv.Itoa(height),
+		"block_id":   blockID.Hash.String(),
+		"block_time": strconv.FormatInt(blockTime(height).Unix(), 10),
+	}, string(util.CommitEventType)))
+}
+
+// NewProposal returns the event of replica i receiving the complete proposal of the height and round
+func (k *Kit) NewProposal(i, height, round int, blockID ttypes.BlockID) *types.Event {
+	return k.event(k.ID(i), types.NewGenericEventType(map[string]string{
+		"height":          strconv.Itoa(height),
+		"round":           strconv.Itoa(round),
+		"blockID":         blockID.Hash.String(),
+		"block_timestamp": strconv.FormatInt(blockTime(height).Unix(), 10),
+	}, string(util.NewProposalEventType)))
+}
+
+// Timeout returns the event of the timeout of type t of replica i at the height and round, the start of the
+// timeout or, if ended, the timeout firing
+func (k *Kit) Timeout(i int, t util.TimeoutType, height, round int, ended bool) *types.Event {
+	timeout := &types.ReplicaTimeout{
+		Replica:  k.ID(i),
+		Type:     fmt.Sprintf("%d/%d %s", height, round, t),
+		Duration: time.Second,
+	}
+	if ended {
+		return k.event(k.ID(i), types.NewTimeoutEndEventType(timeout))
+	}
+	return k.event(k.ID(i), types.NewTimeoutStartEventType(timeout))
+}
+
+// Aborted is true if a handler aborted the testcase of the context with util.Abort or the state machine
+// of the handler reached its fail state
+func (k *Kit) Aborted() bool {
+	return util.Aborted(k.Context.Vars)
+}
+
+// IDs returns the IDs of the messages, to compare the messages returned by a handler
+func IDs(messages []*types.Message) []string {
+	result := make([]string, len(messages))
+	for i, m := range messages {
+		result[i] = m.ID
+	}
+	return result
+}
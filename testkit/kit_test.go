@@ -0,0 +1,85 @@
+package testkit
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/tendermint-test/util"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestSignedMessages(t *testing.T) {
+	k, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parts, blockID := k.Block(1, k.Proposer(1, 0))
+
+	votes := []*util.TMessage{
+		k.Prevote(1, 1, 0, blockID),
+		k.Precommit(2, 1, 0, ttypes.BlockID{}),
+	}
+	for _, tMsg := range votes {
+		m := k.Message(tMsg, 0)
+		parsed, ok := util.GetParsedMessage(m)
+		if !ok {
+			t.Fatalf("could not parse %s", m.ID)
+		}
+		vote, err := ttypes.VoteFromProto(parsed.Data.GetVote().Vote)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, val := k.ValSet.GetByAddress(vote.ValidatorAddress)
+		if err := vote.Verify(ChainID, val.PubKey); err != nil {
+			t.Errorf("%s: %s", parsed.Type, err)
+		}
+	}
+
+	proposal := k.Proposal(k.Proposer(1, 0), 1, 0, -1, blockID)
+	p := proposal.Data.GetProposal().Proposal
+	if !k.Keys[k.Proposer(1, 0)].PubKey().VerifySignature(ttypes.ProposalSignBytes(ChainID, &p), p.Signature) {
+		t.Error("invalid proposal signature")
+	}
+	for _, part := range k.BlockParts(0, 1, 0, parts) {
+		if part.Type != util.BlockPart {
+			t.Errorf("expected a block part, got %s", part.Type)
+		}
+	}
+	if parts.Header().Total == 0 || blockID.IsZero() {
+		t.Error("expected a complete block ID")
+	}
+	if vType := votes[1].Data.GetVote().Vote.Type; vType != prototypes.PrecommitType {
+		t.Errorf("expected a precommit, got %s", vType)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	k, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, send := k.SendTo(k.Prevote(1, 1, 0, BlockID("a")), 2)
+	if !send.IsMessageSend() || send.Replica != k.ID(1) {
+		t.Errorf("expected a send event of node1, got %s", send.TypeS)
+	}
+	if got, ok := k.Context.GetMessage(send); !ok || got.ID != m.ID {
+		t.Error("expected the message of the send event in the message pool")
+	}
+	receive := k.Receive(m)
+	if !receive.IsMessageReceive() || receive.Replica != k.ID(2) {
+		t.Errorf("expected a receive event of node2, got %s", receive.TypeS)
+	}
+	commit, ok := util.GetCommitEvent(k.Commit(0, 1, BlockID("a")))
+	if !ok || commit.Height != 1 || commit.BlockID != BlockID("a").Hash.String() {
+		t.Errorf("unexpected commit event %v", commit)
+	}
+
+	partition := k.Partition(map[string][]int{"faulty": {1}, "rest": {0, 2, 3}})
+	faulty, _ := partition.GetPart("faulty")
+	if !faulty.Contains(k.ID(1)) || faulty.Size() != 1 {
+		t.Error("expected node1 in the faulty part")
+	}
+	if k.Aborted() {
+		t.Error("expected the testcase not aborted")
+	}
+}
@@ -0,0 +1,130 @@
+package testkit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ds-test-framework/tendermint-test/util"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmsg "github.com/tendermint/tendermint/proto/tendermint/consensus"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	ttypes "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+var genesisTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// blockTime is the time of the block of the height, one second after the block of the previous height
+func blockTime(height int) time.Time {
+	return genesisTime.Add(time.Duration(height-1) * time.Second)
+}
+
+// messageTime is the timestamp of the votes and proposals of the height and round
+func messageTime(height, round int) time.Time {
+	return blockTime(height).Add(500*time.Millisecond + time.Duration(round)*time.Millisecond)
+}
+
+// BlockID returns a block ID with a hash derived from the name, for votes and proposals of blocks that are not
+// sent in parts. Equal names give equal block IDs.
+func BlockID(name string) ttypes.BlockID {
+	return ttypes.BlockID{
+		Hash: tmhash.Sum([]byte(name)),
+		PartSetHeader: ttypes.PartSetHeader{
+			Total: 1,
+			Hash:  tmhash.Sum([]byte(name + "/parts")),
+		},
+	}
+}
+
+// Block returns a block of the height proposed by replica i, with its parts and ID.
+// The blocks of a height proposed by different replicas are different.
+func (k *Kit) Block(height, i int) (*ttypes.Block, *ttypes.PartSet, ttypes.BlockID) {
+	txs := []ttypes.Tx{ttypes.Tx(fmt.Sprintf("height=%d", height))}
+	block := ttypes.MakeBlock(int64(height), txs, ttypes.NewCommit(0, 0, ttypes.BlockID{}, nil), nil)
+	block.Header.Populate(
+		tmversion.Consensus{Block: version.BlockProtocol, App: 0},
+		ChainID,
+		blockTime(height),
+		ttypes.BlockID{},
+		k.ValSet.Hash(),
+		k.ValSet.Hash(),
+		nil, nil, nil,
+		k.Keys[i].PubKey().Address(),
+	)
+	parts := block.MakePartSet(ttypes.BlockPartSizeBytes)
+	return block, parts, ttypes.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+}
+
+func (k *Kit) vote(vType prototypes.SignedMsgType, i, height, round int, blockID ttypes.BlockID) *util.TMessage {
+	index, _ := k.ValSet.GetByAddress(k.Keys[i].PubKey().Address())
+	vote := &prototypes.Vote{
+		Type:             vType,
+		Height:           int64(height),
+		Round:            int32(round),
+		BlockID:          blockID.ToProto(),
+		Timestamp:        messageTime(height, round),
+		ValidatorAddress: k.Keys[i].PubKey().Address(),
+		ValidatorIndex:   index,
+	}
+	sig, err := k.Keys[i].Sign(ttypes.VoteSignBytes(ChainID, vote))
+	if err != nil {
+		panic(fmt.Sprintf("could not sign vote: %s", err))
+	}
+	vote.Signature = sig
+	tMsg := &util.TMessage{
+		ChannelID: 0x22,
+		From:      k.ID(i),
+		Type:      util.Prevote,
+		Data: &tmsg.Message{
+			Sum: &tmsg.Message_Vote{Vote: &tmsg.Vote{Vote: vote}},
+		},
+	}
+	if vType == prototypes.PrecommitType {
+		tMsg.Type = util.Precommit
+	}
+	return tMsg
+}
+
+// Prevote returns the prevote of replica i for the block, an empty block ID is a nil vote
+func (k *Kit) Prevote(i, height, round int, blockID ttypes.BlockID) *util.TMessage {
+	return k.vote(prototypes.PrevoteType, i, height, round, blockID)
+}
+
+// Precommit returns the precommit of replica i for the block, an empty block ID is a nil vote
+func (k *Kit) Precommit(i, height, round int, blockID ttypes.BlockID) *util.TMessage {
+	return k.vote(prototypes.PrecommitType, i, height, round, blockID)
+}
+
+// Proposal returns the proposal of the block signed by replica i, whether or not it is the proposer
+func (k *Kit) Proposal(i, height, round, polRound int, blockID ttypes.BlockID) *util.TMessage {
+	proposal := ttypes.NewProposal(int64(height), int32(round), int32(polRound), blockID)
+	proposal.Timestamp = messageTime(height, round)
+	proposalP := proposal.ToProto()
+	sig, err := k.Keys[i].Sign(ttypes.ProposalSignBytes(ChainID, proposalP))
+	if err != nil {
+		panic(fmt.Sprintf("could not sign proposal: %s", err))
+	}
+	proposalP.Signature = sig
+	return &util.TMessage{
+		ChannelID: 0x21,
+		From:      k.ID(i),
+		Type:      util.Proposal,
+		Data: &tmsg.Message{
+			Sum: &tmsg.Message_Proposal{Proposal: &tmsg.Proposal{Proposal: *proposalP}},
+		},
+	}
+}
+
+// BlockParts returns the block part messages of the parts sent by replica i in the height and round
+func (k *Kit) BlockParts(i, height, round int, parts *ttypes.PartSet) []*util.TMessage {
+	result := make([]*util.TMessage, 0, parts.Total())
+	for p := 0; p < int(parts.Total()); p++ {
+		part, err := util.NewBlockPartMessage(&util.TMessage{ChannelID: 0x21, From: k.ID(i)}, height, round, parts.GetPart(p))
+		if err != nil {
+			panic(fmt.Sprintf("could not create block part: %s", err))
+		}
+		result = append(result, part)
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package trace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ds-test-framework/scheduler/config"
+	"github.com/ds-test-framework/scheduler/context"
+	"github.com/ds-test-framework/scheduler/log"
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// evaluationContext returns the context of the testcase with the recorded replicas
+func evaluationContext(replicas []*Replica, testcase *testlib.TestCase, report *testlib.TestCaseReport) *testlib.Context {
+	logger := log.NewLogger(config.LogConfig{Path: os.DevNull, Format: "json"})
+	root := context.NewRootContext(&config.Config{NumReplicas: len(replicas), Byzantine: true}, logger)
+	for _, r := range replicas {
+		root.Replicas.Add(&types.Replica{ID: r.ID, Ready: true, Info: r.Info})
+	}
+	if testcase.Logger == nil {
+		testcase.Logger = logger
+	}
+	return testlib.NewContext(root, testcase, report)
+}
+
+// Evaluate runs the setup, handler and assert function of the testcase against the recorded events of the
+// testcase with the same name, without replicas. The context has the recorded replicas and the message pool
+// has the messages of the replicas and the mutations of the recording when their events are handled.
+// The messages returned by the handler are not delivered, the recorded events are handled until the testcase
+// ends with util.EndTestCase or util.Abort, as the testing server would. The event DAG of the context is not built.
+//
+// The setup and assert functions of the testcase should be set with util.SetupFunc and util.AssertFn, the
+// testcase does not return them. The testcase should be created anew, it cannot be evaluated or run again once
+// it has ended.
+func Evaluate(records []*Record, testcase *testlib.TestCase) (*testlib.TestCaseReport, error) {
+	var start *Record
+	for _, r := range records {
+		if r.Kind == TestcaseRecord && r.Testcase == testcase.Name {
+			start = r
+			break
+		}
+	}
+	if start == nil {
+		return nil, fmt.Errorf("no testcase %s in the trace", testcase.Name)
+	}
+	setup, ok := util.Setup(testcase)
+	if !ok {
+		setup = func(*testlib.Context) error { return nil }
+	}
+	assert, ok := util.Assertion(testcase)
+	if !ok {
+		return nil, fmt.Errorf("testcase %s has no assert function set with util.AssertFn", testcase.Name)
+	}
+
+	report := testlib.NewTestCaseReport(testcase.Name)
+	c := evaluationContext(start.Replicas, testcase, report)
+	if err := setup(c); err != nil {
+		return nil, fmt.Errorf("could not set up testcase %s: %s", testcase.Name, err)
+	}
+	for _, r := range records {
+		if r.Kind != EventRecord || r.Testcase != testcase.Name || r.Event == nil {
+			continue
+		}
+		e, err := r.Event.Event()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %s", r.Seq, err)
+		}
+		if r.Message != nil && !c.MessagePool.Exists(r.Message.ID) {
+			c.MessagePool.Add(r.Message.Message())
+		}
+		testcase.Handler.HandleEvent(e, c)
+		if util.Ended(c.Vars) {
+			break
+		}
+		for _, d := range r.Decisions {
+			if d.Action == Mutate && d.Message != nil && !c.MessagePool.Exists(d.MessageID) {
+				c.MessagePool.Add(d.Message.Message())
+			}
+		}
+	}
+
+	if !util.Aborted(c.Vars) {
+		report.Assertion = assert(c)
+	}
+	return report, nil
+}
@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// commitTestCase passes once a replica commits, it counts the send events of the messages in the message pool
+func commitTestCase(name string) (*testlib.TestCase, *int) {
+	sends := 0
+	sm := handlers.NewStateMachine()
+	sm.Builder().On(common.IsCommit, handlers.SuccessStateLabel)
+	h := handlers.NewHandlerCascade(handlers.WithStateMachine(sm))
+	h.AddHandler(func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if _, ok := util.GetMessageFromEvent(e, c); ok && e.IsMessageSend() {
+			sends++
+		}
+		return []*types.Message{}, false
+	})
+	testcase := testlib.NewTestCase(name, time.Minute, h)
+	util.SetupFunc(testcase, func(c *testlib.Context) error {
+		c.Vars.Set("n", c.Replicas.Cap())
+		return nil
+	})
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		n, _ := c.Vars.GetInt("n")
+		return n == 4 && sm.InSuccessState()
+	})
+	return testcase, &sends
+}
+
+func TestEvaluate(t *testing.T) {
+	records := record(t)
+	testcase, sends := commitTestCase("testkit")
+	report, err := Evaluate(records, testcase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Assertion {
+		t.Error("expected the assertion to hold on the recording")
+	}
+	if *sends != 2 {
+		t.Errorf("expected the 2 recorded messages in the message pool, got %d", *sends)
+	}
+
+	if _, err := Evaluate(records, testlib.NewTestCase("other", time.Minute, handlers.NewHandlerCascade())); err == nil {
+		t.Error("expected an error for a testcase that was not recorded")
+	}
+}
+
+func TestEvaluateMonitors(t *testing.T) {
+	testcase, _ := commitTestCase("testkit")
+	var ctx *testlib.Context
+	common.WithMonitors([]*testlib.TestCase{testcase}, func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		ctx = c
+		return []*types.Message{}, false
+	})
+	// the recording delivers a single precommit before the commit
+	report, err := Evaluate(record(t), testcase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Assertion {
+		t.Error("expected the agreement monitor to fail the testcase")
+	}
+	if !util.Aborted(ctx.Vars) {
+		t.Error("expected the testcase to be aborted")
+	}
+}
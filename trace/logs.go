@@ -0,0 +1,585 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+	"github.com/tendermint/tendermint/crypto"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/privval"
+	tmsg "github.com/tendermint/tendermint/proto/tendermint/consensus"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// channels of the consensus reactor of tendermint
+const (
+	dataChannel = 0x21
+	voteChannel = 0x22
+)
+
+// the log lines of the nodes that are imported
+const (
+	signedVoteLine     = "signed and pushed vote"
+	addedPrevoteLine   = "added vote to prevote"
+	addedPrecommitLine = "added vote to precommit"
+	proposalLine       = "received proposal"
+	nodeIDLine         = "P2P Node ID"
+)
+
+var (
+	// a line of the log of a node, I[2021-10-05|19:22:43.008] signed and pushed vote      module=consensus height=1 ...
+	nodeLineRe = regexp.MustCompile(`^[A-Z]\[(\d{4}-\d\d-\d\d\|\d\d:\d\d:\d\d\.\d{3})\] (.*?) +(\w+=.*)$`)
+	// Vote{3:B3025C0D65BD 1/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) 237E98712535 6AFC92083EB5 @ 2021-10-05T19:22:42.9420246Z}
+	voteRe = regexp.MustCompile(`^Vote\{(\d+):([0-9A-F]+) (\d+)/(\d+)/SIGNED_MSG_TYPE_\w+\((\w+)\) ([0-9A-F]+) [0-9A-F]+ @ ([^}]+)\}$`)
+	// Proposal{1/0 (237E9871...042E:1:A054D78B5771, -1) 027A8B30826A @ 2021-10-05T19:22:42.4222365Z}
+	proposalRe = regexp.MustCompile(`^Proposal\{(\d+)/(\d+) \(([0-9A-F]+):(\d+):[0-9A-F]+, (-?\d+)\) [0-9A-F]+ @ ([^}]+)\}$`)
+	// a field of a log line, the value is quoted if it has spaces
+	fieldRe = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+const nodeTimeLayout = "2006-01-02|15:04:05.000"
+
+// nilHash is the fingerprint of the hash of a nil vote in the logs
+const nilHash = "000000000000"
+
+// fields returns the fields of a log line
+func fields(s string) map[string]string {
+	result := make(map[string]string)
+	for _, m := range fieldRe.FindAllStringSubmatch(s, -1) {
+		value := m[2]
+		if strings.HasPrefix(value, `"`) {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		}
+		result[m[1]] = value
+	}
+	return result
+}
+
+// logRun is the testcase of a run of the cluster read from the log of the testing server
+type logRun struct {
+	testcase string
+	start    time.Time
+	end      time.Time
+	replicas []*Replica
+}
+
+// readChecker reads the testcase, its start and end and the replicas from the log of the testing server.
+// The replicas are in the order they first registered, with the key and chain id they registered last.
+func readChecker(path string) (*logRun, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	run := &logRun{replicas: make([]*Replica, 0)}
+	index := make(map[types.ReplicaID]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := fields(scanner.Text())
+		t, err := time.Parse(time.RFC3339, line["time"])
+		if err != nil {
+			continue
+		}
+		switch line["msg"] {
+		case "Received replica information":
+			info, err := replicaInfo(line["info"])
+			if err != nil {
+				return nil, fmt.Errorf("replica %s: %s", line["replica_id"], err)
+			}
+			id := types.ReplicaID(line["replica_id"])
+			if i, ok := index[id]; ok {
+				run.replicas[i].Info = info
+				continue
+			}
+			index[id] = len(run.replicas)
+			run.replicas = append(run.replicas, &Replica{ID: id, Info: info})
+		case "Starting testcase":
+			if run.testcase == "" {
+				run.testcase, run.start = line["testcase"], t
+			}
+		case "Finalizing":
+			if line["testcase"] == run.testcase && run.end.IsZero() {
+				run.end = t
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if run.testcase == "" {
+		return nil, fmt.Errorf("no testcase in %s", path)
+	}
+	if run.end.IsZero() {
+		run.end = time.Now()
+	}
+	// the times of the testing server are in seconds
+	run.end = run.end.Add(time.Second)
+	return run, nil
+}
+
+// replicaInfo returns the information of the replica as expected by util.GetPrivKey and util.GetChainID from the
+// information printed by the testing server, map[chain_id:... info:map[...] privkey:{...}]
+func replicaInfo(s string) (map[string]interface{}, error) {
+	chainID := regexp.MustCompile(`chain_id:(\S+)`).FindStringSubmatch(s)
+	i := strings.Index(s, "privkey:")
+	if chainID == nil || i < 0 {
+		return nil, fmt.Errorf("no chain id or private key in %q", s)
+	}
+	var key privval.FilePVKey
+	if err := tmjson.Unmarshal([]byte(strings.TrimSuffix(s[i+len("privkey:"):], "]")), &key); err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err)
+	}
+	return util.NewReplicaInfo(key.PrivKey, chainID[1])
+}
+
+// nodeLine is an imported line of the log of a node
+type nodeLine struct {
+	time   time.Time
+	node   int
+	line   int
+	msg    string
+	fields map[string]string
+}
+
+// readNode returns the ID of the replica of the node and the imported lines of its log within the run
+func readNode(path string, node int, run *logRun) (types.ReplicaID, []*nodeLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	var id types.ReplicaID
+	lines := make([]*nodeLine, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 0; scanner.Scan(); n++ {
+		m := nodeLineRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		msg := strings.TrimSpace(m[2])
+		switch msg {
+		case nodeIDLine:
+			id = types.ReplicaID(fields(m[3])["ID"])
+			continue
+		case signedVoteLine, addedPrevoteLine, addedPrecommitLine, proposalLine:
+		default:
+			continue
+		}
+		t, err := time.Parse(nodeTimeLayout, m[1])
+		if err != nil || t.Before(run.start) || !t.Before(run.end) {
+			continue
+		}
+		lines = append(lines, &nodeLine{time: t, node: node, line: n, msg: msg, fields: fields(m[3])})
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if id == "" {
+		return "", nil, fmt.Errorf("no node ID in %s", path)
+	}
+	return id, lines, nil
+}
+
+// logVote is a vote printed in the logs, the hashes are fingerprints of 6 bytes
+type logVote struct {
+	index     int32
+	validator string
+	height    int
+	round     int
+	vType     util.MessageType
+	hash      string
+	timestamp time.Time
+}
+
+func parseVote(s string) (*logVote, bool) {
+	m := voteRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	index, _ := strconv.Atoi(m[1])
+	height, _ := strconv.Atoi(m[3])
+	round, _ := strconv.Atoi(m[4])
+	timestamp, err := time.Parse(time.RFC3339Nano, m[7])
+	if err != nil {
+		return nil, false
+	}
+	vType := util.MessageType(m[5])
+	if vType != util.Prevote && vType != util.Precommit {
+		return nil, false
+	}
+	return &logVote{
+		index:     int32(index),
+		validator: m[2],
+		height:    height,
+		round:     round,
+		vType:     vType,
+		hash:      m[6],
+		timestamp: timestamp,
+	}, true
+}
+
+type logProposal struct {
+	height    int
+	round     int
+	polRound  int
+	blockID   ttypes.BlockID
+	timestamp time.Time
+}
+
+func parseProposal(s string) (*logProposal, bool) {
+	m := proposalRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	height, _ := strconv.Atoi(m[1])
+	round, _ := strconv.Atoi(m[2])
+	total, _ := strconv.Atoi(m[4])
+	polRound, _ := strconv.Atoi(m[5])
+	hash, err := hex.DecodeString(m[3])
+	if err != nil {
+		return nil, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, m[6])
+	if err != nil {
+		return nil, false
+	}
+	return &logProposal{
+		height:   height,
+		round:    round,
+		polRound: polRound,
+		// the hash of the part set header is a fingerprint in the logs
+		blockID:   ttypes.BlockID{Hash: hash, PartSetHeader: ttypes.PartSetHeader{Total: uint32(total)}},
+		timestamp: timestamp,
+	}, true
+}
+
+// importer builds the records of a run from the lines of the logs of the nodes
+type importer struct {
+	run      *logRun
+	replicas *types.ReplicaStore
+	valSet   *ttypes.ValidatorSet
+	chainID  string
+	// blocks are the block IDs of the proposals by the fingerprint of their hash
+	blocks map[string]ttypes.BlockID
+
+	records  []*Record
+	events   uint64
+	messages int
+	// sent are the send records of the messages by sender, recipient, type, height and round
+	sent map[string]*Record
+	// received are the messages received by the recipients
+	received map[string]bool
+}
+
+func messageKey(from, to types.ReplicaID, mType util.MessageType, height, round int) string {
+	return fmt.Sprintf("%s_%s_%s_%d_%d", from, to, mType, height, round)
+}
+
+func (im *importer) record(t time.Time, replica types.ReplicaID, eventType types.EventType) *Record {
+	im.events++
+	r := &Record{
+		Kind:     EventRecord,
+		Testcase: im.run.testcase,
+		Time:     t,
+		Event:    NewEvent(types.NewEvent(replica, eventType, eventType.String(), im.events, t.Unix())),
+	}
+	im.records = append(im.records, r)
+	return r
+}
+
+func (im *importer) message(from, to types.ReplicaID, id string, tMsg *util.TMessage) (*types.Message, error) {
+	tMsg.From, tMsg.To = from, to
+	data, err := tMsg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	m := &types.Message{From: from, To: to, Data: data, Type: string(tMsg.Type), ID: id, Intercept: true}
+	m.Parse(&util.TMessageParser{})
+	return m, nil
+}
+
+// send records the send event of the message to the recipient, unless it was already recorded on its receive
+func (im *importer) send(t time.Time, from, to types.ReplicaID, height, round int, tMsg *util.TMessage) (*Record, error) {
+	key := messageKey(from, to, tMsg.Type, height, round)
+	if r, ok := im.sent[key]; ok {
+		return r, nil
+	}
+	im.messages++
+	m, err := im.message(from, to, fmt.Sprintf("%s_%s_%d", from, to, im.messages), tMsg)
+	if err != nil {
+		return nil, err
+	}
+	r := im.record(t, from, types.NewMessageSendEventType(m.ID))
+	r.Message = NewMessage(m)
+	im.sent[key] = r
+	return r, nil
+}
+
+// receive records the receive event of the message, the send is recorded first if it was not imported yet and
+// the message is mutated if the sender sent a different message
+func (im *importer) receive(t time.Time, from, to types.ReplicaID, height, round int, tMsg *util.TMessage) error {
+	sent, err := im.send(t, from, to, height, round, tMsg)
+	if err != nil {
+		return err
+	}
+	id := sent.Message.ID
+	if im.received[id] {
+		return nil
+	}
+	im.received[id] = true
+	tMsg.From, tMsg.To = from, to
+	data, err := tMsg.Clone().(*util.TMessage).Marshal()
+	if err != nil {
+		return err
+	}
+	if sentData, err := sentContent(sent.Message); err == nil && string(sentData) != string(data) {
+		im.messages++
+		m, err := im.message(from, to, fmt.Sprintf("%s_%s_change%d", from, to, im.messages), tMsg)
+		if err != nil {
+			return err
+		}
+		sent.Decisions = append(sent.Decisions, &Decision{Action: Mutate, MessageID: m.ID, Message: NewMessage(m), Original: id})
+		id = m.ID
+	}
+	im.record(t, to, types.NewMessageReceiveEventType(id))
+	return nil
+}
+
+// sentContent is the content of the message without its sender and recipient, to compare it with a received message
+func sentContent(m *Message) ([]byte, error) {
+	tMsg, ok := util.GetParsedMessage(m.Message())
+	if !ok {
+		return nil, fmt.Errorf("message %s is not a tendermint message", m.ID)
+	}
+	return tMsg.Clone().(*util.TMessage).Marshal()
+}
+
+// replicaOf returns the replica and the key of the validator with the fingerprint of its address
+func (im *importer) replicaOf(validator string) (*types.Replica, crypto.PrivKey, bool) {
+	for _, r := range im.replicas.Iter() {
+		key, err := util.GetPrivKey(r)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(fmt.Sprintf("%X", key.PubKey().Address()), validator) {
+			return r, key, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (im *importer) vote(v *logVote) (types.ReplicaID, *util.TMessage, bool) {
+	replica, key, ok := im.replicaOf(v.validator)
+	if !ok {
+		return "", nil, false
+	}
+	blockID := ttypes.BlockID{}
+	if v.hash != nilHash {
+		if blockID, ok = im.blocks[v.hash]; !ok {
+			return "", nil, false
+		}
+	}
+	vType := prototypes.PrevoteType
+	if v.vType == util.Precommit {
+		vType = prototypes.PrecommitType
+	}
+	vote := &prototypes.Vote{
+		Type:             vType,
+		Height:           int64(v.height),
+		Round:            int32(v.round),
+		BlockID:          blockID.ToProto(),
+		Timestamp:        v.timestamp,
+		ValidatorAddress: key.PubKey().Address(),
+		ValidatorIndex:   v.index,
+	}
+	sig, err := key.Sign(ttypes.VoteSignBytes(im.chainID, vote))
+	if err != nil {
+		return "", nil, false
+	}
+	vote.Signature = sig
+	tMsg := &util.TMessage{
+		ChannelID: voteChannel,
+		Type:      v.vType,
+		Data:      &tmsg.Message{Sum: &tmsg.Message_Vote{Vote: &tmsg.Vote{Vote: vote}}},
+	}
+	return replica.ID, tMsg, true
+}
+
+func (im *importer) proposal(p *logProposal) (types.ReplicaID, *util.TMessage, bool) {
+	replica, ok := util.GetProposerReplica(im.replicas, im.valSet, p.height, p.round)
+	if !ok {
+		return "", nil, false
+	}
+	key, err := util.GetPrivKey(replica)
+	if err != nil {
+		return "", nil, false
+	}
+	proposal := ttypes.NewProposal(int64(p.height), int32(p.round), int32(p.polRound), p.blockID)
+	proposal.Timestamp = p.timestamp
+	proposalP := proposal.ToProto()
+	sig, err := key.Sign(ttypes.ProposalSignBytes(im.chainID, proposalP))
+	if err != nil {
+		return "", nil, false
+	}
+	proposalP.Signature = sig
+	tMsg := &util.TMessage{
+		ChannelID: dataChannel,
+		Type:      util.Proposal,
+		Data:      &tmsg.Message{Sum: &tmsg.Message_Proposal{Proposal: &tmsg.Proposal{Proposal: *proposalP}}},
+	}
+	return replica.ID, tMsg, true
+}
+
+// line imports the line of the log of the node
+func (im *importer) line(node types.ReplicaID, l *nodeLine) error {
+	var from types.ReplicaID
+	var tMsg *util.TMessage
+	var height, round int
+	switch l.msg {
+	case proposalLine:
+		p, ok := parseProposal(l.fields["proposal"])
+		if !ok {
+			return nil
+		}
+		if from, tMsg, ok = im.proposal(p); !ok {
+			return nil
+		}
+		height, round = p.height, p.round
+	default:
+		v, ok := parseVote(l.fields["vote"])
+		if !ok {
+			return nil
+		}
+		if from, tMsg, ok = im.vote(v); !ok {
+			return nil
+		}
+		height, round = v.height, v.round
+	}
+
+	if l.msg == signedVoteLine || (l.msg == proposalLine && from == node) {
+		for _, r := range im.run.replicas {
+			if r.ID == from {
+				continue
+			}
+			if _, err := im.send(l.time, from, r.ID, height, round, tMsg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if from == node {
+		// the votes of the node are added when they are signed
+		return nil
+	}
+	return im.receive(l.time, from, node, height, round, tMsg)
+}
+
+// ImportLogs returns the records of the testcase of a run of the cluster from the logs of the testing server
+// and the nodes, such as the runs in logs that predate the traces. The directory has the log of the testing
+// server checker.log and the logs of the nodes node*/tendermint.log.
+//
+// The import is lossy, the logs do not have the messages and only some of the events of the nodes:
+//   - the testcase record has the replicas with the keys and chain id they registered with, its timeout is unknown
+//   - a vote signed by a node is sent to every other replica and received when a node adds it to its votes, only
+//     the votes that the nodes log are imported. The vote is signed again with the key of the replica and has the
+//     timestamp of the log, the hash of the part set header of its block is unknown.
+//   - a proposal is received when a node logs it and is sent by the proposer of its round when the proposer logs it
+//   - a vote received from a node is the vote signed by the node, a different vote is recorded as a mutation of
+//     the signed vote by the testing server. A message received before its sender logged it is sent on its receive.
+//   - the votes for a block whose proposal was not logged, the block parts, the timeouts and the generic events
+//     such as the commits are not imported, the times of the events are the times of the lines of the logs
+//
+// The records can be evaluated with Evaluate, the conditions on the missing events do not hold.
+func ImportLogs(dir string) ([]*Record, error) {
+	run, err := readChecker(filepath.Join(dir, "checker.log"))
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "node*", "tendermint.log"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no logs of the nodes in %s", dir)
+	}
+	sort.Strings(paths)
+
+	nodes := make([]types.ReplicaID, len(paths))
+	lines := make([]*nodeLine, 0)
+	for i, path := range paths {
+		id, nodeLines, err := readNode(path, i, run)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = id
+		lines = append(lines, nodeLines...)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if !lines[i].time.Equal(lines[j].time) {
+			return lines[i].time.Before(lines[j].time)
+		}
+		if lines[i].node != lines[j].node {
+			return lines[i].node < lines[j].node
+		}
+		return lines[i].line < lines[j].line
+	})
+
+	im := &importer{
+		run:      run,
+		replicas: types.NewReplicaStore(len(run.replicas)),
+		blocks:   make(map[string]ttypes.BlockID),
+		records:  make([]*Record, 0),
+		sent:     make(map[string]*Record),
+		received: make(map[string]bool),
+	}
+	for _, r := range run.replicas {
+		im.replicas.Add(&types.Replica{ID: r.ID, Ready: true, Info: r.Info})
+	}
+	if im.valSet, err = util.GetValidatorSet(im.replicas); err != nil {
+		return nil, err
+	}
+	if im.chainID, err = util.GetChainID(im.replicas.Iter()[0]); err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if l.msg != proposalLine {
+			continue
+		}
+		if p, ok := parseProposal(l.fields["proposal"]); ok {
+			im.blocks[fmt.Sprintf("%X", p.blockID.Hash)[:len(nilHash)]] = p.blockID
+		}
+	}
+
+	im.records = append(im.records, &Record{
+		Kind:     TestcaseRecord,
+		Testcase: run.testcase,
+		Time:     run.start,
+		Replicas: run.replicas,
+	})
+	for _, l := range lines {
+		if err := im.line(nodes[l.node], l); err != nil {
+			return nil, err
+		}
+	}
+	for i, r := range im.records {
+		r.Seq = i + 1
+	}
+	return im.records, nil
+}
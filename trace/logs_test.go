@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func TestImportLogs(t *testing.T) {
+	// node0 is faulty and node1 delayed in the run, the testing server changes the votes of node0 to nil
+	// and the delayed replica prevotes the locked block in round 2
+	const (
+		faulty   = types.ReplicaID("31f4e9402f9e8a660a784a1f7a1fc6e449d12fe7")
+		delayed  = types.ReplicaID("e3ba881689c3f1a1df3a2772348d4c213a832c21")
+		oldBlock = "237E987125353E3EA9740650A5DC82F4EC62347A62BC95E1646BED8964BF042E"
+	)
+	records, err := ImportLogs("../logs/run_lockedvalue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := records[0]
+	if start.Kind != TestcaseRecord || start.Testcase != "LockedValueOne" || len(start.Replicas) != 4 {
+		t.Fatalf("expected the testcase record of LockedValueOne with 4 replicas, got %v", start)
+	}
+	replica := &types.Replica{Info: start.Replicas[0].Info}
+	if chainID, err := util.GetChainID(replica); err != nil || chainID != "chain-vDDAwr" {
+		t.Errorf("expected the chain id of the run, got %q", chainID)
+	}
+
+	messages := make(map[string]*Message)
+	mutated, unlocked := false, true
+	for _, r := range records[1:] {
+		switch r.Event.Kind {
+		case MessageSendEvent:
+			messages[r.Message.ID] = r.Message
+			if r.Message.From == delayed && r.Message.MessageType == string(util.Prevote) && r.Message.Round == 2 {
+				unlocked = unlocked && r.Message.BlockID != oldBlock
+			}
+		case MessageReceiveEvent:
+			if _, ok := messages[r.Event.MessageID]; !ok {
+				t.Errorf("record %d: receive of %s before its send", r.Seq, r.Event.MessageID)
+			}
+		}
+		for _, d := range r.Decisions {
+			messages[d.MessageID] = d.Message
+			original := messages[d.Original]
+			if original != nil && original.From == faulty && original.BlockID != "" && d.Message.BlockID == "" {
+				mutated = true
+			}
+			if !strings.Contains(d.MessageID, "_change") {
+				t.Errorf("record %d: unexpected decision %s on %s", r.Seq, d.Action, d.MessageID)
+			}
+		}
+	}
+	if !mutated {
+		t.Error("expected a vote of the faulty replica changed to nil")
+	}
+	if unlocked {
+		t.Error("expected the delayed replica to prevote the locked block in round 2")
+	}
+}
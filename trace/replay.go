@@ -0,0 +1,398 @@
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// Kinds of divergences of a replay from the recording
+const (
+	// ReplicasDivergence is a replica that is not in the recording or has another key
+	ReplicasDivergence = "replicas"
+	// UnexpectedDivergence is a message sent by a replica that was not sent in the recording
+	UnexpectedDivergence = "unexpected"
+	// MissingDivergence is a recorded delivery of a message that was not sent by the end of the replay
+	MissingDivergence = "missing"
+	// EventDivergence is an event of a replica that differs from the event of the replica in the recording
+	EventDivergence = "event"
+	// ContentDivergence is a message with different contents than the recorded message, such as a vote with
+	// another timestamp. It does not fail the replay.
+	ContentDivergence = "content"
+)
+
+// Divergence of a replay from the recording
+type Divergence struct {
+	Kind    string          `json:"kind"`
+	Replica types.ReplicaID `json:"replica,omitempty"`
+	// Seq of the record the replay diverges from, 0 if there is none
+	Seq int `json:"seq,omitempty"`
+	// Event is the ID of the event of the replay, 0 if there is none
+	Event    uint64 `json:"event,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("%s at record %d of %s: expected %s, got %s", d.Kind, d.Seq, d.Replica, d.Expected, d.Actual)
+}
+
+func (m *Message) String() string {
+	if m.MessageType == "" {
+		return fmt.Sprintf("%s %s->%s", m.Type, m.From, m.To)
+	}
+	return fmt.Sprintf("%s(%d/%d %s) %s->%s", m.MessageType, m.Height, m.Round, m.BlockID, m.From, m.To)
+}
+
+func (e *Event) String() string {
+	if e.Kind == GenericEvent {
+		return fmt.Sprintf("%s%v", e.Type, e.Params)
+	}
+	return e.Kind
+}
+
+// scheduleKey identifies a message of a replica without its timestamps and signatures,
+// the messages of a replay are matched to the recorded messages with the same key in the order they were sent
+func scheduleKey(m *types.Message) string {
+	tMsg, ok := util.GetParsedMessage(m)
+	if !ok || tMsg.Data == nil {
+		return fmt.Sprintf("%s|%s|%s|%X", m.From, m.To, m.Type, m.Data)
+	}
+	height, round := tMsg.HeightRound()
+	key := fmt.Sprintf("%s|%s|%s|%d|%d", m.From, m.To, tMsg.Type, height, round)
+	switch tMsg.Type {
+	case util.Prevote, util.Precommit:
+		blockID, _ := util.GetVoteBlockIDS(tMsg)
+		key += "|" + blockID
+	case util.Proposal:
+		blockID, _ := util.GetProposalBlockIDS(tMsg)
+		key += "|" + blockID
+	case util.BlockPart:
+		key += fmt.Sprintf("|%d", tMsg.Data.GetBlockPart().Part.Index)
+	}
+	return key
+}
+
+// delivery is a message delivered in the recording
+type delivery struct {
+	seq       int
+	action    string
+	messageID string
+	// message created by a mutation
+	message  *Message
+	original string
+}
+
+type recordedEvent struct {
+	seq   int
+	event *Event
+}
+
+// Replayer replays the recorded schedule of a testcase. The messages sent by the replicas are matched to the
+// recorded messages and the recorded deliveries and mutations are made in the recorded order, a delivery waits
+// for its message to be sent. The divergences of the replay from the recording are collected as it runs.
+type Replayer struct {
+	testcase string
+	replicas []*Replica
+	timeout  time.Duration
+	// recorded messages sent by the replicas by ID and the recorded IDs of the messages with the same key
+	sent       map[string]*Message
+	keys       map[string][]string
+	deliveries []*delivery
+	events     map[types.ReplicaID][]*recordedEvent
+
+	// live messages by recorded ID
+	live        map[string]*types.Message
+	next        int
+	eventsSeen  map[types.ReplicaID]int
+	divergences []*Divergence
+	lock        *sync.Mutex
+}
+
+// Testcases returns the names of the testcases of the records in the recorded order
+func Testcases(records []*Record) []string {
+	result := make([]string, 0)
+	for _, r := range records {
+		if r.Kind == TestcaseRecord {
+			result = append(result, r.Testcase)
+		}
+	}
+	return result
+}
+
+// NewReplayer returns the replayer of the recorded testcase
+func NewReplayer(records []*Record, testcase string) (*Replayer, error) {
+	r := &Replayer{
+		testcase:    testcase,
+		sent:        make(map[string]*Message),
+		keys:        make(map[string][]string),
+		deliveries:  make([]*delivery, 0),
+		events:      make(map[types.ReplicaID][]*recordedEvent),
+		live:        make(map[string]*types.Message),
+		eventsSeen:  make(map[types.ReplicaID]int),
+		divergences: make([]*Divergence, 0),
+		lock:        new(sync.Mutex),
+	}
+	found := false
+	mutated := make(map[string]bool)
+	for _, record := range records {
+		if record.Testcase != testcase {
+			continue
+		}
+		if record.Kind == TestcaseRecord {
+			found = true
+			r.replicas = record.Replicas
+			r.timeout = record.Timeout
+			continue
+		}
+		if record.Event == nil {
+			continue
+		}
+		switch record.Event.Kind {
+		case MessageSendEvent:
+			if record.Message == nil {
+				return nil, fmt.Errorf("record %d: no message of the send event", record.Seq)
+			}
+			key := scheduleKey(record.Message.Message())
+			r.sent[record.Message.ID] = record.Message
+			r.keys[key] = append(r.keys[key], record.Message.ID)
+		case GenericEvent:
+			replica := record.Event.Replica
+			r.events[replica] = append(r.events[replica], &recordedEvent{seq: record.Seq, event: record.Event})
+		}
+		for _, d := range record.Decisions {
+			switch d.Action {
+			case Deliver:
+				if _, ok := r.sent[d.MessageID]; !ok && !mutated[d.MessageID] {
+					return nil, fmt.Errorf("record %d: delivery of unknown message %s", record.Seq, d.MessageID)
+				}
+			case Mutate:
+				if d.Message == nil {
+					return nil, fmt.Errorf("record %d: no message of the mutation %s", record.Seq, d.MessageID)
+				}
+				mutated[d.MessageID] = true
+			default:
+				continue
+			}
+			r.deliveries = append(r.deliveries, &delivery{
+				seq:       record.Seq,
+				action:    d.Action,
+				messageID: d.MessageID,
+				message:   d.Message,
+				original:  d.Original,
+			})
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no testcase %s in the trace", testcase)
+	}
+	return r, nil
+}
+
+// Timeout of the recorded testcase
+func (r *Replayer) Timeout() time.Duration {
+	return r.timeout
+}
+
+// Divergences returns the divergences of the replay from the recording, in the order they were found
+func (r *Replayer) Divergences() []*Divergence {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*Divergence{}, r.divergences...)
+}
+
+// Diverged is true if the replay diverged from the recording, differences in the contents of messages are ignored
+func (r *Replayer) Diverged() bool {
+	for _, d := range r.Divergences() {
+		if d.Kind != ContentDivergence {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Replayer) diverge(d *Divergence) {
+	r.divergences = append(r.divergences, d)
+}
+
+// done is true once every recorded message is delivered and every recorded event has occurred
+func (r *Replayer) done() bool {
+	if r.next < len(r.deliveries) {
+		return false
+	}
+	for replica, events := range r.events {
+		if r.eventsSeen[replica] < len(events) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestCase returns the testcase replaying the recording, with the name of the recorded testcase.
+// It ends once the recorded schedule is replayed and passes if the replay did not diverge.
+func (r *Replayer) TestCase(timeout time.Duration) *testlib.TestCase {
+	testcase := testlib.NewTestCase(r.testcase, timeout, &replayHandler{replayer: r})
+	util.SetupFunc(testcase, r.setup)
+	util.AssertFn(testcase, func(c *testlib.Context) bool {
+		r.lock.Lock()
+		r.missing()
+		r.lock.Unlock()
+		return !r.Diverged()
+	})
+	return testcase
+}
+
+func (r *Replayer) setup(c *testlib.Context) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, recorded := range r.replicas {
+		replica, ok := c.Replicas.Get(recorded.ID)
+		if !ok {
+			r.diverge(&Divergence{Kind: ReplicasDivergence, Replica: recorded.ID, Expected: "replica", Actual: "none"})
+			continue
+		}
+		if !reflect.DeepEqual(replica.Info["privkey"], recorded.Info["privkey"]) {
+			r.diverge(&Divergence{Kind: ReplicasDivergence, Replica: recorded.ID, Expected: "recorded key", Actual: "another key"})
+		}
+	}
+	if len(r.divergences) != 0 {
+		return fmt.Errorf("the replicas are not the replicas of the recording")
+	}
+	return nil
+}
+
+// missing records the first delivery that was not made and the first recorded event of every replica
+// that did not occur
+func (r *Replayer) missing() {
+	replicas := make([]string, 0, len(r.events))
+	for replica := range r.events {
+		replicas = append(replicas, string(replica))
+	}
+	sort.Strings(replicas)
+	for _, id := range replicas {
+		replica := types.ReplicaID(id)
+		events := r.events[replica]
+		if seen := r.eventsSeen[replica]; seen < len(events) {
+			r.diverge(&Divergence{Kind: EventDivergence, Replica: replica, Seq: events[seen].seq, Expected: events[seen].event.String(), Actual: "no event"})
+		}
+	}
+	if r.next >= len(r.deliveries) {
+		return
+	}
+	d := r.deliveries[r.next]
+	expected := d.messageID
+	if m, ok := r.sent[d.messageID]; ok {
+		expected = m.String()
+	} else if d.message != nil {
+		expected = d.message.String()
+	}
+	r.diverge(&Divergence{
+		Kind:     MissingDivergence,
+		Seq:      d.seq,
+		Expected: expected,
+		Actual:   fmt.Sprintf("not sent, %d of %d deliveries made", r.next, len(r.deliveries)),
+	})
+}
+
+func (r *Replayer) onSend(e *types.Event, m *types.Message) {
+	key := scheduleKey(m)
+	ids := r.keys[key]
+	if len(ids) == 0 {
+		if r.next >= len(r.deliveries) {
+			// the replicas keep running once the schedule is replayed
+			return
+		}
+		r.diverge(&Divergence{Kind: UnexpectedDivergence, Replica: e.Replica, Event: e.ID, Expected: "no message", Actual: NewMessage(m).String()})
+		return
+	}
+	r.keys[key] = ids[1:]
+	r.live[ids[0]] = m
+	if recorded := r.sent[ids[0]]; !bytes.Equal(recorded.Data, m.Data) {
+		r.diverge(&Divergence{Kind: ContentDivergence, Replica: e.Replica, Event: e.ID, Expected: recorded.String(), Actual: "different contents"})
+	}
+}
+
+func (r *Replayer) onEvent(e *types.Event) {
+	event := NewEvent(e)
+	seen := r.eventsSeen[e.Replica]
+	recorded := r.events[e.Replica]
+	if seen >= len(recorded) && r.next >= len(r.deliveries) {
+		// the replicas keep running once the schedule is replayed
+		return
+	}
+	r.eventsSeen[e.Replica]++
+	if seen >= len(recorded) {
+		r.diverge(&Divergence{Kind: EventDivergence, Replica: e.Replica, Event: e.ID, Expected: "no event", Actual: event.String()})
+		return
+	}
+	expected := recorded[seen]
+	if expected.event.Type != event.Type || !reflect.DeepEqual(expected.event.Params, event.Params) {
+		r.diverge(&Divergence{Kind: EventDivergence, Replica: e.Replica, Seq: expected.seq, Event: e.ID, Expected: expected.event.String(), Actual: event.String()})
+	}
+}
+
+// deliver returns the recorded deliveries that can be made in order, a delivery waits for its message
+// or the original message of a mutation to be sent
+func (r *Replayer) deliver(c *testlib.Context) []*types.Message {
+	result := make([]*types.Message, 0)
+	for ; r.next < len(r.deliveries); r.next++ {
+		d := r.deliveries[r.next]
+		if d.action == Deliver {
+			m, ok := r.live[d.messageID]
+			if !ok {
+				break
+			}
+			result = append(result, m)
+			continue
+		}
+		cur := d.message.Message()
+		if d.original != "" {
+			original, ok := r.live[d.original]
+			if !ok {
+				break
+			}
+			cur = original
+		}
+		m := c.NewMessage(cur, d.message.Data)
+		m.Parse(&util.TMessageParser{})
+		r.live[d.messageID] = m
+		result = append(result, m)
+	}
+	return result
+}
+
+type replayHandler struct {
+	replayer *Replayer
+}
+
+var _ testlib.Handler = &replayHandler{}
+
+func (h *replayHandler) HandleEvent(e *types.Event, c *testlib.Context) []*types.Message {
+	r := h.replayer
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if e.IsMessageSend() {
+		if m, ok := c.GetMessage(e); ok {
+			r.onSend(e, m)
+		}
+	} else if _, ok := e.Type.(*types.GenericEventType); ok {
+		r.onEvent(e)
+	}
+	messages := r.deliver(c)
+	if r.done() {
+		util.EndTestCase(c)
+	}
+	return messages
+}
+
+func (h *replayHandler) Name() string {
+	return "Replay"
+}
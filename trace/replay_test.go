@@ -0,0 +1,186 @@
+package trace
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/sim"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func changeFaultyVotes(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	if !common.IsVoteFromFaulty()(e, c) {
+		return []*types.Message{}, false
+	}
+	return common.ChangeVoteToNil(e, c)
+}
+
+// record traces a testcase changing the prevote of node1 to nil and delivering the precommit of node2
+func record(t *testing.T) []*Record {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Partition(map[string][]int{"faulty": {1}, "rest": {0, 2, 3}})
+	h := handlers.NewHandlerCascade()
+	h.AddHandler(changeFaultyVotes)
+	k.TestCase.Handler = h
+
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf)
+	tracer.Trace(k.TestCase)
+	blockID := testkit.BlockID("a")
+	_, prevote := k.SendTo(k.Prevote(1, 1, 0, blockID), 0)
+	_, precommit := k.SendTo(k.Precommit(2, 1, 0, blockID), 0)
+	for _, e := range []*types.Event{prevote, precommit, k.Commit(0, 1, blockID)} {
+		k.TestCase.Handler.HandleEvent(e, k.Context)
+	}
+	records, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestReplay(t *testing.T) {
+	records := record(t)
+	replayer, err := NewReplayer(records, "testkit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcase := replayer.TestCase(time.Minute)
+	if err := replayer.setup(k.Context); err != nil {
+		t.Fatal(err)
+	}
+	blockID := testkit.BlockID("a")
+
+	// the precommit is sent first, it is delivered after the change of the prevote
+	_, precommit := k.SendTo(k.Precommit(2, 1, 0, blockID), 0)
+	if messages := testcase.Handler.HandleEvent(precommit, k.Context); len(messages) != 0 {
+		t.Errorf("expected the precommit to wait for the prevote, got %v", testkit.IDs(messages))
+	}
+	_, prevote := k.SendTo(k.Prevote(1, 1, 0, blockID), 0)
+	messages := testcase.Handler.HandleEvent(prevote, k.Context)
+	if len(messages) != 2 {
+		t.Fatalf("expected the changed prevote and the precommit, got %v", testkit.IDs(messages))
+	}
+	changed, _ := util.GetParsedMessage(messages[0])
+	if id, _ := util.GetVoteBlockIDS(changed); changed.Type != util.Prevote || id != "" {
+		t.Errorf("expected the nil prevote, got %s for %q", changed.Type, id)
+	}
+	if tMsg, _ := util.GetParsedMessage(messages[1]); tMsg.Type != util.Precommit {
+		t.Errorf("expected the precommit, got %s", tMsg.Type)
+	}
+	testcase.Handler.HandleEvent(k.Commit(0, 1, blockID), k.Context)
+	if !replayer.done() {
+		t.Error("expected the replay to be done")
+	}
+	if divergences := replayer.Divergences(); len(divergences) != 0 {
+		t.Errorf("expected no divergences, got %v", divergences)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	replayer, err := NewReplayer(record(t), "testkit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testcase := replayer.TestCase(time.Minute)
+
+	_, prevote := k.SendTo(k.Prevote(1, 1, 0, testkit.BlockID("b")), 0)
+	testcase.Handler.HandleEvent(prevote, k.Context)
+	testcase.Handler.HandleEvent(k.Commit(0, 1, testkit.BlockID("b")), k.Context)
+	replayer.missing()
+
+	expected := []string{UnexpectedDivergence, EventDivergence, MissingDivergence}
+	divergences := replayer.Divergences()
+	if len(divergences) != len(expected) {
+		t.Fatalf("expected %d divergences, got %v", len(expected), divergences)
+	}
+	for i, kind := range expected {
+		if divergences[i].Kind != kind {
+			t.Errorf("expected a %s divergence, got %s", kind, divergences[i])
+		}
+	}
+	if divergences[2].Seq != 2 {
+		t.Errorf("expected the missing delivery of record 2, got %d", divergences[2].Seq)
+	}
+	if !replayer.Diverged() {
+		t.Error("expected the replay to diverge")
+	}
+}
+
+// changeNode1Prevotes changes the prevotes of node1 at height 1 to nil
+func changeNode1Prevotes(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	m, ok := util.GetMessageFromEvent(e, c)
+	if !ok || !e.IsMessageSend() || m.From != "node1" || m.Type != util.Prevote || m.Height() != 1 {
+		return []*types.Message{}, false
+	}
+	return common.ChangeVoteToNil(e, c)
+}
+
+// endOnCommit ends the testcase once every replica has committed the height
+func endOnCommit(height, replicas int) handlers.HandlerFunc {
+	committed := make(map[types.ReplicaID]bool)
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		if commit, ok := util.GetCommitEvent(e); ok && commit.Height == height {
+			committed[e.Replica] = true
+			if len(committed) == replicas {
+				util.EndTestCase(c)
+			}
+		}
+		return []*types.Message{}, false
+	}
+}
+
+func TestReplaySim(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the testcase against simulated replicas")
+	}
+	h := handlers.NewHandlerCascade()
+	h.AddHandler(endOnCommit(2, 4))
+	h.AddHandler(changeNode1Prevotes)
+	testcase := testlib.NewTestCase("Record", 30*time.Second, h)
+
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf)
+	tracer.Trace(testcase)
+	if _, err := sim.Run(4, []*testlib.TestCase{testcase}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	records, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := NewReplayer(records, "Record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the timeouts of the simulated replicas expire on the logical clock of the network, the replay
+	// makes the same timeouts expire as the recording however long the replay waits for the messages
+	ok, err := sim.RunTestCase(4, replayer.TestCase(time.Minute), 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Diverged() {
+		t.Errorf("expected the replay not to diverge, got %v", replayer.Divergences())
+	}
+	if !ok {
+		t.Error("expected the replay to pass")
+	}
+}
@@ -0,0 +1,257 @@
+// Package trace records the events of the testcases and the decisions of their handlers as JSON lines
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// Kinds of records
+const (
+	// TestcaseRecord starts the records of a testcase, with the replicas of the network
+	TestcaseRecord = "testcase"
+	// EventRecord is an event of a replica and the decisions of the handler of the testcase
+	EventRecord = "event"
+)
+
+// Kinds of events, the type of the event as published by the replica is the Type of generic events
+const (
+	MessageSendEvent    = "MessageSend"
+	MessageReceiveEvent = "MessageReceive"
+	TimeoutStartEvent   = "TimeoutStart"
+	TimeoutEndEvent     = "TimeoutEnd"
+	GenericEvent        = "Generic"
+)
+
+// Actions of the decisions of a handler
+const (
+	// Deliver is a message of the replicas delivered unchanged
+	Deliver = "deliver"
+	// Drop is a message sent by a replica and not delivered when it was sent.
+	// The message can still be delivered on a later event.
+	Drop = "drop"
+	// Mutate is a message created by the handler, it replaces the original message when it has one
+	Mutate = "mutate"
+)
+
+// Replica of the network of a testcase
+type Replica struct {
+	ID   types.ReplicaID        `json:"id"`
+	Info map[string]interface{} `json:"info"`
+}
+
+// Event of a replica
+type Event struct {
+	ID      uint64          `json:"id"`
+	Replica types.ReplicaID `json:"replica"`
+	Kind    string          `json:"kind"`
+	// Type is the type of a generic event
+	Type      string            `json:"type,omitempty"`
+	Params    map[string]string `json:"params,omitempty"`
+	MessageID string            `json:"message_id,omitempty"`
+	Timeout   *Timeout          `json:"timeout,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// Timeout of a timeout event
+type Timeout struct {
+	Type     string `json:"type"`
+	Duration string `json:"duration"`
+}
+
+func newTimeout(t *types.ReplicaTimeout) *Timeout {
+	return &Timeout{Type: t.Type, Duration: t.Duration.String()}
+}
+
+func (e *Event) replicaTimeout() (*types.ReplicaTimeout, error) {
+	if e.Timeout == nil {
+		return nil, fmt.Errorf("no timeout in %s event %d", e.Kind, e.ID)
+	}
+	t, ok := types.TimeoutFromParams(e.Replica, map[string]string{"type": e.Timeout.Type, "duration": e.Timeout.Duration})
+	if !ok {
+		return nil, fmt.Errorf("invalid timeout in %s event %d", e.Kind, e.ID)
+	}
+	return t, nil
+}
+
+// NewEvent returns the trace event of e
+func NewEvent(e *types.Event) *Event {
+	event := &Event{
+		ID:        e.ID,
+		Replica:   e.Replica,
+		Timestamp: e.Timestamp,
+	}
+	switch t := e.Type.(type) {
+	case *types.MessageSendEventType:
+		event.Kind = MessageSendEvent
+		event.MessageID = t.MessageID
+	case *types.MessageReceiveEventType:
+		event.Kind = MessageReceiveEvent
+		event.MessageID = t.MessageID
+	case *types.TimeoutStartEventType:
+		event.Kind = TimeoutStartEvent
+		event.Timeout = newTimeout(t.Timeout)
+	case *types.TimeoutEndEventType:
+		event.Kind = TimeoutEndEvent
+		event.Timeout = newTimeout(t.Timeout)
+	case *types.GenericEventType:
+		event.Kind = GenericEvent
+		event.Type = t.T
+		event.Params = t.Params
+	}
+	return event
+}
+
+// Event returns the event of the replica as the testing server creates it
+func (e *Event) Event() (*types.Event, error) {
+	var t types.EventType
+	switch e.Kind {
+	case MessageSendEvent:
+		t = types.NewMessageSendEventType(e.MessageID)
+	case MessageReceiveEvent:
+		t = types.NewMessageReceiveEventType(e.MessageID)
+	case TimeoutStartEvent, TimeoutEndEvent:
+		timeout, err := e.replicaTimeout()
+		if err != nil {
+			return nil, err
+		}
+		if e.Kind == TimeoutStartEvent {
+			t = types.NewTimeoutStartEventType(timeout)
+		} else {
+			t = types.NewTimeoutEndEventType(timeout)
+		}
+	case GenericEvent:
+		t = types.NewGenericEventType(e.Params, e.Type)
+	default:
+		return nil, fmt.Errorf("unknown event kind %q", e.Kind)
+	}
+	return types.NewEvent(e.Replica, t, t.String(), e.ID, e.Timestamp), nil
+}
+
+// Message is a message with its decoded contents
+type Message struct {
+	ID        string          `json:"id"`
+	From      types.ReplicaID `json:"from"`
+	To        types.ReplicaID `json:"to"`
+	Type      string          `json:"type"`
+	Intercept bool            `json:"intercept"`
+	// Data is the message as sent by the replica
+	Data []byte `json:"data"`
+	// MessageType is the type of the decoded tendermint message
+	MessageType string `json:"message_type,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Round       int    `json:"round,omitempty"`
+	// BlockID is the hash of the block of a vote or a proposal, empty for a nil vote
+	BlockID string `json:"block_id,omitempty"`
+	// Content is the decoded tendermint message
+	Content interface{} `json:"content,omitempty"`
+}
+
+// NewMessage returns the trace message of m, the contents are decoded if m is a tendermint message
+func NewMessage(m *types.Message) *Message {
+	message := &Message{
+		ID:        m.ID,
+		From:      m.From,
+		To:        m.To,
+		Type:      m.Type,
+		Intercept: m.Intercept,
+		Data:      m.Data,
+	}
+	tMsg, ok := util.GetParsedMessage(m)
+	if !ok || tMsg.Data == nil {
+		return message
+	}
+	message.MessageType = string(tMsg.Type)
+	message.Height, message.Round = tMsg.HeightRound()
+	switch tMsg.Type {
+	case util.Prevote, util.Precommit:
+		message.BlockID, _ = util.GetVoteBlockIDS(tMsg)
+	case util.Proposal:
+		message.BlockID = fmt.Sprintf("%X", tMsg.Data.GetProposal().Proposal.BlockID.Hash)
+	}
+	message.Content = tMsg.Data.Sum
+	return message
+}
+
+// Message returns the message as the testing server receives it
+func (m *Message) Message() *types.Message {
+	message := &types.Message{
+		ID:        m.ID,
+		From:      m.From,
+		To:        m.To,
+		Type:      m.Type,
+		Intercept: m.Intercept,
+		Data:      m.Data,
+	}
+	message.Parse(&util.TMessageParser{})
+	return message
+}
+
+// Decision of the handler of a testcase on an event
+type Decision struct {
+	Action    string `json:"action"`
+	MessageID string `json:"message_id"`
+	// Message is the message created by a mutation, the messages of the replicas are in the records of their send events
+	Message *Message `json:"message,omitempty"`
+	// Original is the ID of the message replaced by a mutation
+	Original string `json:"original,omitempty"`
+}
+
+// Transition of the state machine of a testcase
+type Transition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Record is a line of a trace
+type Record struct {
+	// Seq numbers the records of a trace from 1
+	Seq      int       `json:"seq"`
+	Kind     string    `json:"kind"`
+	Testcase string    `json:"testcase"`
+	Time     time.Time `json:"time"`
+	// Replicas and Timeout of a testcase record
+	Replicas []*Replica    `json:"replicas,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+
+	Event *Event `json:"event,omitempty"`
+	// Message of a message send event, receive events refer to the message by its ID
+	Message *Message `json:"message,omitempty"`
+	// Handler is the handler of the cascade that decided on the event
+	Handler    string      `json:"handler,omitempty"`
+	Decisions  []*Decision `json:"decisions,omitempty"`
+	Transition *Transition `json:"transition,omitempty"`
+	// Ended is true if the testcase ended on the event, the messages of the handler were not delivered
+	// and the later events are not recorded
+	Ended bool `json:"ended,omitempty"`
+}
+
+// Read reads the records of a trace
+func Read(r io.Reader) ([]*Record, error) {
+	records := make([]*Record, 0)
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			return records, fmt.Errorf("record %d: %s", len(records)+1, err)
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// ReadFile reads the records of the trace file
+func ReadFile(path string) ([]*Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,113 @@
+package trace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/common"
+	"github.com/ds-test-framework/tendermint-test/testkit"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+func dropPrecommits(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+	m, ok := util.GetMessageFromEvent(e, c)
+	if !ok || !e.IsMessageSend() || m.Type != util.Precommit {
+		return []*types.Message{}, false
+	}
+	return []*types.Message{}, true
+}
+
+func TestTrace(t *testing.T) {
+	k, err := testkit.New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockID := testkit.BlockID("a")
+	// the locking monitor does not check the precommit without a polka of node2
+	k.Partition(map[string][]int{"faulty": {2}, "rest": {0, 1, 3}})
+
+	sm := handlers.NewStateMachine()
+	sm.Builder().On(common.IsCommit, handlers.SuccessStateLabel)
+	h := handlers.NewHandlerCascade(handlers.WithStateMachine(sm))
+	h.AddHandler(dropPrecommits)
+	h.AddHandler(common.ChangeVoteToNil)
+	k.TestCase.Handler = h
+	common.WithMonitors([]*testlib.TestCase{k.TestCase})
+
+	var buf bytes.Buffer
+	tracer := NewTracer(&buf)
+	tracer.Trace(k.TestCase)
+
+	prevote, sendPrevote := k.SendTo(k.Prevote(1, 1, 0, blockID), 0)
+	_, sendPrecommit := k.SendTo(k.Precommit(2, 1, 0, blockID), 0)
+	events := []*types.Event{sendPrevote, sendPrecommit, k.Receive(prevote), k.Commit(0, 1, blockID)}
+	for _, e := range events {
+		k.TestCase.Handler.HandleEvent(e, k.Context)
+	}
+	// the agreement monitor aborts the testcase on the commit without a precommit quorum,
+	// the events after the end of the testcase are not recorded
+	_, after := k.SendTo(k.Prevote(3, 1, 0, blockID), 0)
+	k.TestCase.Handler.HandleEvent(after, k.Context)
+	if err := tracer.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(events)+1 {
+		t.Fatalf("expected %d records, got %d", len(events)+1, len(records))
+	}
+	start := records[0]
+	if start.Kind != TestcaseRecord || start.Testcase != k.TestCase.Name || len(start.Replicas) != 4 {
+		t.Errorf("expected the testcase record with 4 replicas, got %s with %d", start.Kind, len(start.Replicas))
+	}
+
+	mutated := records[1]
+	if mutated.Message == nil || mutated.Message.MessageType != string(util.Prevote) || mutated.Message.BlockID != blockID.Hash.String() {
+		t.Errorf("expected the prevote for the block in the send record, got %v", mutated.Message)
+	}
+	if mutated.Handler != "common.ChangeVoteToNil" {
+		t.Errorf("expected the decision of common.ChangeVoteToNil, got %s", mutated.Handler)
+	}
+	if len(mutated.Decisions) != 1 || mutated.Decisions[0].Action != Mutate || mutated.Decisions[0].Original != prevote.ID {
+		t.Fatalf("expected a mutation of the prevote, got %v", mutated.Decisions)
+	}
+	if m := mutated.Decisions[0].Message; m == nil || m.BlockID != "" || m.Content == nil {
+		t.Errorf("expected the decoded nil prevote, got %v", m)
+	}
+
+	dropped := records[2]
+	if dropped.Handler != "trace.dropPrecommits" || len(dropped.Decisions) != 1 || dropped.Decisions[0].Action != Drop {
+		t.Errorf("expected trace.dropPrecommits to drop the precommit, got %s %v", dropped.Handler, dropped.Decisions)
+	}
+
+	received := records[3]
+	if received.Event.Kind != MessageReceiveEvent || received.Event.MessageID != prevote.ID || received.Message != nil {
+		t.Errorf("expected the receive event of the prevote, got %v", received.Event)
+	}
+
+	commit := records[4]
+	if commit.Transition == nil || commit.Transition.To != handlers.SuccessStateLabel {
+		t.Errorf("expected a transition to the success state, got %v", commit.Transition)
+	}
+	if !commit.Ended || len(commit.Decisions) != 0 {
+		t.Errorf("expected the commit to end the testcase without decisions, got %v", commit.Decisions)
+	}
+	e, err := commit.Event.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := util.GetCommitEvent(e); !ok || c.Height != 1 || c.BlockID != blockID.Hash.String() {
+		t.Errorf("expected the commit event of height 1, got %v", c)
+	}
+
+	m := records[1].Message.Message()
+	if tMsg, ok := util.GetParsedMessage(m); !ok || tMsg.Type != util.Prevote || m.ID != prevote.ID {
+		t.Errorf("expected the recorded prevote, got %s", m.ID)
+	}
+}
@@ -0,0 +1,208 @@
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/ds-test-framework/tendermint-test/util"
+)
+
+// wrapper is a handler that wraps the handler of the testcase, such as the handler of the monitors
+type wrapper interface {
+	Unwrap() testlib.Handler
+}
+
+// cascadeOf returns the handler cascade wrapped by h
+func cascadeOf(h testlib.Handler) (*handlers.HandlerCascade, bool) {
+	for {
+		switch t := h.(type) {
+		case *handlers.HandlerCascade:
+			return t, true
+		case wrapper:
+			h = t.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// funcName is the name of the function without the path of its package, for example rskip.changeVoteFilter.func1
+func funcName(f interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// Tracer writes the records of the traced testcases as JSON lines
+type Tracer struct {
+	encoder *json.Encoder
+	seq     int
+	err     error
+	lock    *sync.Mutex
+}
+
+// NewTracer creates a tracer writing to w
+func NewTracer(w io.Writer) *Tracer {
+	return &Tracer{
+		encoder: json.NewEncoder(w),
+		lock:    new(sync.Mutex),
+	}
+}
+
+// Err returns the first error writing the trace, no records are written after it
+func (t *Tracer) Err() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.err
+}
+
+func (t *Tracer) write(r *Record) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.err != nil {
+		return
+	}
+	t.seq++
+	r.Seq = t.seq
+	t.err = t.encoder.Encode(r)
+}
+
+// Trace wraps the handler of the testcase to record every event with the decisions of the handler
+// until the testcase ends.
+// The testcase should already be wrapped with the monitors. When the handler is a handler cascade,
+// the handlers of the cascade are wrapped to record the handler that decided on the event.
+// The end of the testcase is recorded only if it ends with util.EndTestCase, util.Abort or the fail state
+// of a state machine, the testcase does not tell when it ends with c.EndTestCase or c.Abort.
+func (t *Tracer) Trace(testcase *testlib.TestCase) {
+	h := &tracedHandler{
+		tracer:   t,
+		testcase: testcase,
+		handler:  testcase.Handler,
+	}
+	if cascade, ok := cascadeOf(testcase.Handler); ok {
+		for i, f := range cascade.Handlers {
+			cascade.Handlers[i] = h.decider(funcName(f), f)
+		}
+		cascade.DefaultHandler = h.decider("default", cascade.DefaultHandler)
+	}
+	testcase.Handler = h
+}
+
+type tracedHandler struct {
+	tracer   *Tracer
+	testcase *testlib.TestCase
+	handler  testlib.Handler
+	started  bool
+	ended    bool
+	// decided is the name of the handler of the cascade that decided on the current event
+	decided string
+}
+
+var _ testlib.Handler = &tracedHandler{}
+
+func (h *tracedHandler) decider(name string, f handlers.HandlerFunc) handlers.HandlerFunc {
+	return func(e *types.Event, c *testlib.Context) ([]*types.Message, bool) {
+		messages, ok := f(e, c)
+		if ok {
+			h.decided = name
+		}
+		return messages, ok
+	}
+}
+
+func (h *tracedHandler) start(c *testlib.Context) {
+	h.started = true
+	record := &Record{
+		Kind:     TestcaseRecord,
+		Testcase: h.testcase.Name,
+		Time:     time.Now(),
+		Replicas: make([]*Replica, 0),
+		Timeout:  h.testcase.Timeout,
+	}
+	for _, r := range c.Replicas.Iter() {
+		record.Replicas = append(record.Replicas, &Replica{ID: r.ID, Info: r.Info})
+	}
+	h.tracer.write(record)
+}
+
+func (h *tracedHandler) HandleEvent(e *types.Event, c *testlib.Context) []*types.Message {
+	if h.ended {
+		return h.handler.HandleEvent(e, c)
+	}
+	if !h.started {
+		h.start(c)
+	}
+	fromState, _ := c.Vars.GetString("curState")
+	sent, hasMessage := c.GetMessage(e)
+
+	h.decided = h.handler.Name()
+	messages := h.handler.HandleEvent(e, c)
+
+	record := &Record{
+		Kind:     EventRecord,
+		Testcase: h.testcase.Name,
+		Time:     time.Now(),
+		Event:    NewEvent(e),
+		Handler:  h.decided,
+	}
+	if h.ended = util.Ended(c.Vars); h.ended {
+		record.Ended = true
+	} else {
+		record.Decisions = decisions(e, c, sent, messages)
+	}
+	if hasMessage && e.IsMessageSend() {
+		record.Message = NewMessage(sent)
+	}
+	if toState, _ := c.Vars.GetString("curState"); toState != fromState {
+		record.Transition = &Transition{From: fromState, To: toState}
+	}
+	h.tracer.write(record)
+	return messages
+}
+
+func (h *tracedHandler) Name() string {
+	return h.handler.Name()
+}
+
+// Unwrap returns the handler of the testcase
+func (h *tracedHandler) Unwrap() testlib.Handler {
+	return h.handler
+}
+
+// decisions classifies the messages returned by the handler for the event. The messages that are not in the
+// message pool are created by the handler, a new message between the sender and the recipient of a sent message
+// is a mutation of the sent message. A sent message that is neither delivered nor mutated is dropped.
+func decisions(e *types.Event, c *testlib.Context, sent *types.Message, messages []*types.Message) []*Decision {
+	result := make([]*Decision, 0, len(messages))
+	handled := false
+	for _, m := range messages {
+		if c.MessagePool.Exists(m.ID) {
+			result = append(result, &Decision{Action: Deliver, MessageID: m.ID})
+			if sent != nil && m.ID == sent.ID {
+				handled = true
+			}
+			continue
+		}
+		d := &Decision{Action: Mutate, MessageID: m.ID, Message: NewMessage(m)}
+		if e.IsMessageSend() && sent != nil && m.From == sent.From && m.To == sent.To {
+			d.Original = sent.ID
+			handled = true
+		}
+		result = append(result, d)
+	}
+	if e.IsMessageSend() && sent != nil && !handled {
+		result = append(result, &Decision{Action: Drop, MessageID: sent.ID})
+	}
+	return result
+}
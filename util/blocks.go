@@ -0,0 +1,145 @@
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type heightRound struct {
+	height int
+	round  int
+}
+
+// BlockStore reassembles the blocks from the `Proposal` and `BlockPart` messages seen
+type BlockStore struct {
+	parts    map[heightRound][]*ttypes.Part
+	partSets map[heightRound]map[string]*ttypes.PartSet
+	blocks   map[string]*ttypes.Block
+	lock     *sync.Mutex
+}
+
+func NewBlockStore() *BlockStore {
+	return &BlockStore{
+		parts:    make(map[heightRound][]*ttypes.Part),
+		partSets: make(map[heightRound]map[string]*ttypes.PartSet),
+		blocks:   make(map[string]*ttypes.Block),
+		lock:     new(sync.Mutex),
+	}
+}
+
+// Add records the proposal or the block part and returns the block if the message completes one
+func (s *BlockStore) Add(msg *TMessage) (*ttypes.Block, bool) {
+	switch msg.Type {
+	case Proposal:
+		return s.addProposal(msg)
+	case BlockPart:
+		return s.addBlockPart(msg)
+	}
+	return nil, false
+}
+
+func (s *BlockStore) addProposal(msg *TMessage) (*ttypes.Block, bool) {
+	blockID, ok := GetProposalBlockID(msg)
+	if !ok || blockID.PartSetHeader.IsZero() {
+		return nil, false
+	}
+	height, round := msg.HeightRound()
+	hr := heightRound{height, round}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.partSets[hr]; !ok {
+		s.partSets[hr] = make(map[string]*ttypes.PartSet)
+	}
+	headerKey := blockID.PartSetHeader.String()
+	if _, ok := s.partSets[hr][headerKey]; ok {
+		return nil, false
+	}
+	partSet := ttypes.NewPartSetFromHeader(blockID.PartSetHeader)
+	s.partSets[hr][headerKey] = partSet
+	for _, part := range s.parts[hr] {
+		partSet.AddPart(part)
+	}
+	return s.complete(partSet)
+}
+
+func (s *BlockStore) addBlockPart(msg *TMessage) (*ttypes.Block, bool) {
+	blockPart := msg.Data.GetBlockPart()
+	part, err := ttypes.PartFromProto(&blockPart.Part)
+	if err != nil {
+		return nil, false
+	}
+	hr := heightRound{int(blockPart.Height), int(blockPart.Round)}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.parts[hr] = append(s.parts[hr], part)
+	for _, partSet := range s.partSets[hr] {
+		added, _ := partSet.AddPart(part)
+		if added {
+			return s.complete(partSet)
+		}
+	}
+	return nil, false
+}
+
+func (s *BlockStore) complete(partSet *ttypes.PartSet) (*ttypes.Block, bool) {
+	if !partSet.IsComplete() {
+		return nil, false
+	}
+	block, err := DecodeBlock(partSet)
+	if err != nil {
+		return nil, false
+	}
+	hash := block.Hash().String()
+	if _, ok := s.blocks[hash]; ok {
+		return nil, false
+	}
+	s.blocks[hash] = block
+	return block, true
+}
+
+// Block returns the reassembled block with the hash
+func (s *BlockStore) Block(hash string) (*ttypes.Block, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	block, ok := s.blocks[hash]
+	return block, ok
+}
+
+// DecodeBlock decodes the block from a complete part set
+func DecodeBlock(partSet *ttypes.PartSet) (*ttypes.Block, error) {
+	bz, err := ioutil.ReadAll(partSet.GetReader())
+	if err != nil {
+		return nil, err
+	}
+	pbb := new(prototypes.Block)
+	if err := proto.Unmarshal(bz, pbb); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal block: %s", err)
+	}
+	return ttypes.BlockFromProto(pbb)
+}
+
+// AddTx returns a copy of the block with the transaction appended and the header updated.
+// The copy is a different valid block of the same height.
+func AddTx(block *ttypes.Block, tx ttypes.Tx) (*ttypes.Block, error) {
+	pbb, err := block.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	newBlock, err := ttypes.BlockFromProto(pbb)
+	if err != nil {
+		return nil, err
+	}
+	// new data without the hash cached by the validation of the decoded block
+	newBlock.Data = ttypes.Data{Txs: append(newBlock.Data.Txs, tx)}
+	newBlock.DataHash = nil
+	// Computing the hash fills the data hash from the new transactions
+	newBlock.Hash()
+	return newBlock, nil
+}
@@ -0,0 +1,212 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+type EventType string
+
+const (
+	CommitEventType      EventType = "Committing block"
+	NewProposalEventType EventType = "NewProposal"
+)
+
+var (
+	ErrNotReplicaEvent = errors.New("not a replica event")
+	ErrUnknownEvent    = errors.New("unknown event type")
+)
+
+// MalformedEventError is returned when a known event is missing a parameter or has an invalid one
+type MalformedEventError struct {
+	Type  EventType
+	Param string
+	Err   error
+}
+
+func (e *MalformedEventError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("malformed %s event: missing param %s", e.Type, e.Param)
+	}
+	return fmt.Sprintf("malformed %s event: invalid param %s: %s", e.Type, e.Param, e.Err)
+}
+
+type CommitEvent struct {
+	Replica types.ReplicaID
+	// Height is 0 if the replica does not report it, common.GetCommit finds it from the observed messages
+	Height  int
+	BlockID string
+	// Time is the block time if the replica reports it, zero otherwise
+	Time time.Time
+}
+
+type NewProposalEvent struct {
+	Replica types.ReplicaID
+	Height  int
+	// Round is -1 if the replica does not report it
+	Round int
+	// BlockID is empty if the replica does not report it
+	BlockID   string
+	Timestamp time.Time
+}
+
+type eventParams struct {
+	t      EventType
+	params map[string]string
+}
+
+func (p eventParams) String(key string) (string, error) {
+	v, ok := p.params[key]
+	if !ok {
+		return "", &MalformedEventError{Type: p.t, Param: key}
+	}
+	return v, nil
+}
+
+func (p eventParams) Int(key string) (int, error) {
+	v, err := p.String(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &MalformedEventError{Type: p.t, Param: key, Err: err}
+	}
+	return i, nil
+}
+
+func (p eventParams) UnixTime(key string) (time.Time, error) {
+	v, err := p.String(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, &MalformedEventError{Type: p.t, Param: key, Err: err}
+	}
+	return time.Unix(i, 0), nil
+}
+
+func (p eventParams) Has(key string) bool {
+	_, ok := p.params[key]
+	return ok
+}
+
+func getEventParams(e *types.Event) (eventParams, error) {
+	eType, ok := e.Type.(*types.GenericEventType)
+	if !ok {
+		return eventParams{}, ErrNotReplicaEvent
+	}
+	return eventParams{t: EventType(eType.T), params: eType.Params}, nil
+}
+
+// EventTime returns the time of the event reported by the replica, in seconds. Bounds on the duration of a
+// testcase should use the time of the events rather than the clock, the events of a trace are evaluated later.
+func EventTime(e *types.Event) time.Time {
+	return time.Unix(e.Timestamp, 0)
+}
+
+// IsEventType returns true if e is a replica event of type t, irrespective of its parameters
+func IsEventType(e *types.Event, t EventType) bool {
+	p, err := getEventParams(e)
+	return err == nil && p.t == t
+}
+
+// ParseEvent returns the typed representation of the replica event.
+// ErrNotReplicaEvent is returned for message and timeout events, ErrUnknownEvent for
+// replica events that are not modelled and *MalformedEventError when parameters are missing or invalid.
+func ParseEvent(e *types.Event) (interface{}, error) {
+	p, err := getEventParams(e)
+	if err != nil {
+		return nil, err
+	}
+	switch p.t {
+	case CommitEventType:
+		return parseCommitEvent(e.Replica, p)
+	case NewProposalEventType:
+		return parseNewProposalEvent(e.Replica, p)
+	}
+	return nil, ErrUnknownEvent
+}
+
+// ValidateEvent returns an error if e is an unknown or malformed replica event
+func ValidateEvent(e *types.Event) error {
+	_, err := ParseEvent(e)
+	if err == ErrNotReplicaEvent {
+		return nil
+	}
+	return err
+}
+
+// parseCommitEvent requires the block_id, the only parameter of the commit events read by the testcases
+// before the events were typed. The height and block time are optional.
+func parseCommitEvent(replica types.ReplicaID, p eventParams) (*CommitEvent, error) {
+	blockID, err := p.String("block_id")
+	if err != nil {
+		return nil, err
+	}
+	commit := &CommitEvent{
+		Replica: replica,
+		BlockID: blockID,
+	}
+	if p.Has("height") {
+		commit.Height, err = p.Int("height")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.Has("block_time") {
+		commit.Time, err = p.UnixTime("block_time")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commit, nil
+}
+
+func parseNewProposalEvent(replica types.ReplicaID, p eventParams) (*NewProposalEvent, error) {
+	height, err := p.Int("height")
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := p.UnixTime("block_timestamp")
+	if err != nil {
+		return nil, err
+	}
+	proposal := &NewProposalEvent{
+		Replica:   replica,
+		Height:    height,
+		Round:     -1,
+		BlockID:   p.params["blockID"],
+		Timestamp: timestamp,
+	}
+	if p.Has("round") {
+		proposal.Round, err = p.Int("round")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proposal, nil
+}
+
+func GetCommitEvent(e *types.Event) (*CommitEvent, bool) {
+	p, err := getEventParams(e)
+	if err != nil || p.t != CommitEventType {
+		return nil, false
+	}
+	commit, err := parseCommitEvent(e.Replica, p)
+	return commit, err == nil
+}
+
+func GetNewProposalEvent(e *types.Event) (*NewProposalEvent, bool) {
+	p, err := getEventParams(e)
+	if err != nil || p.t != NewProposalEventType {
+		return nil, false
+	}
+	proposal, err := parseNewProposalEvent(e.Replica, p)
+	return proposal, err == nil
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+func TestParseEvent(t *testing.T) {
+	newEvent := func(eType string, params map[string]string) *types.Event {
+		return types.NewEvent("replica", types.NewGenericEventType(params, eType), eType, 1, 0)
+	}
+
+	cases := []struct {
+		name      string
+		event     *types.Event
+		malformed bool
+		err       error
+	}{
+		{
+			name:  "commit",
+			event: newEvent("Committing block", map[string]string{"height": "2", "block_id": "ABCD"}),
+		},
+		{
+			name:  "commit without height",
+			event: newEvent("Committing block", map[string]string{"block_id": "ABCD"}),
+		},
+		{
+			name:      "commit without block id",
+			event:     newEvent("Committing block", map[string]string{"height": "2"}),
+			malformed: true,
+		},
+		{
+			name: "new proposal",
+			event: newEvent("NewProposal", map[string]string{
+				"height":          "2",
+				"blockID":         "ABCD",
+				"block_timestamp": "1633461600",
+			}),
+		},
+		{
+			name: "new proposal without block id",
+			event: newEvent("NewProposal", map[string]string{
+				"height":          "2",
+				"block_timestamp": "1633461600",
+			}),
+		},
+		{
+			name: "new proposal with bad timestamp",
+			event: newEvent("NewProposal", map[string]string{
+				"height":          "2",
+				"blockID":         "ABCD",
+				"block_timestamp": "yesterday",
+			}),
+			malformed: true,
+		},
+		{
+			name:  "typo in event type",
+			event: newEvent("Comitting block", map[string]string{"height": "2", "block_id": "ABCD"}),
+			err:   ErrUnknownEvent,
+		},
+		{
+			name:  "message send",
+			event: types.NewEvent("replica", types.NewMessageSendEventType("1"), "MessageSend", 1, 0),
+			err:   ErrNotReplicaEvent,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := ParseEvent(c.event)
+		if c.malformed {
+			if _, ok := err.(*MalformedEventError); !ok {
+				t.Errorf("%s: expected malformed event error, got %v", c.name, err)
+			}
+			continue
+		}
+		if err != c.err {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+	}
+
+	commit, ok := GetCommitEvent(cases[0].event)
+	if !ok || commit.Height != 2 || commit.BlockID != "ABCD" {
+		t.Errorf("unexpected commit event %#v", commit)
+	}
+	commit, ok = GetCommitEvent(cases[1].event)
+	if !ok || commit.Height != 0 || commit.BlockID != "ABCD" {
+		t.Errorf("unexpected commit event without height %#v", commit)
+	}
+}
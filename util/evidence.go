@@ -0,0 +1,39 @@
+package util
+
+import (
+	"bytes"
+
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// EvidenceChannel is the channel of the evidence reactor
+	EvidenceChannel uint16 = 0x38
+)
+
+// GetEvidence returns the evidence gossiped in a message of the evidence channel
+func GetEvidence(msg *TMessage) ([]ttypes.Evidence, bool) {
+	if msg.ChannelID != EvidenceChannel {
+		return nil, false
+	}
+	list := prototypes.EvidenceList{}
+	if err := list.Unmarshal(msg.MsgB); err != nil {
+		return nil, false
+	}
+	evidence := make([]ttypes.Evidence, 0, len(list.Evidence))
+	for i := range list.Evidence {
+		ev, err := ttypes.EvidenceFromProto(&list.Evidence[i])
+		if err != nil {
+			return nil, false
+		}
+		evidence = append(evidence, ev)
+	}
+	return evidence, true
+}
+
+// IsDuplicateVoteOf returns true if the evidence is a duplicate vote of the validator
+func IsDuplicateVoteOf(ev ttypes.Evidence, addr []byte) bool {
+	dve, ok := ev.(*ttypes.DuplicateVoteEvidence)
+	return ok && dve.VoteA != nil && bytes.Equal(dve.VoteA.ValidatorAddress, addr)
+}
@@ -247,6 +247,8 @@ func ChangeVote(replica *types.Replica, tMsg *TMessage, blockID *ttypes.BlockID)
 
 	newVote.Signature = sig
 
+	// change a copy, the parsed message of the original message is shared with the message pool
+	tMsg = tMsg.Clone().(*TMessage)
 	tMsg.Data = &tmsg.Message{
 		Sum: &tmsg.Message_Vote{
 			Vote: &tmsg.Vote{
@@ -298,6 +300,8 @@ func ChangeVoteTime(replica *types.Replica, tMsg *TMessage, time time.Time) (*TM
 
 	newVote.Signature = sig
 
+	// change a copy, the parsed message of the original message is shared with the message pool
+	tMsg = tMsg.Clone().(*TMessage)
 	tMsg.Data = &tmsg.Message{
 		Sum: &tmsg.Message_Vote{
 			Vote: &tmsg.Vote{
@@ -455,3 +459,65 @@ func GetVoteValidator(msg *TMessage) ([]byte, bool) {
 	}
 	return msg.Data.GetVote().Vote.GetValidatorAddress(), true
 }
+
+// ChangeProposalBlock changes the block of the proposal and signs it with the replica key
+func ChangeProposalBlock(replica *types.Replica, pMsg *TMessage, blockID ttypes.BlockID) (*TMessage, error) {
+	privKey, err := GetPrivKey(replica)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := GetChainID(replica)
+	if err != nil {
+		return nil, err
+	}
+	propP := pMsg.Data.GetProposal().Proposal
+	prop, err := ttypes.ProposalFromProto(&propP)
+	if err != nil {
+		return nil, errors.New("failed converting proposal message")
+	}
+	newProp := &ttypes.Proposal{
+		Type:      prop.Type,
+		Height:    prop.Height,
+		Round:     prop.Round,
+		POLRound:  prop.POLRound,
+		BlockID:   blockID,
+		Timestamp: prop.Timestamp,
+	}
+	signB := ttypes.ProposalSignBytes(chainID, newProp.ToProto())
+	sig, err := privKey.Sign(signB)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign proposal: %s", err)
+	}
+	newProp.Signature = sig
+
+	newMsg := pMsg.Clone().(*TMessage)
+	newMsg.Data = &tmsg.Message{
+		Sum: &tmsg.Message_Proposal{
+			Proposal: &tmsg.Proposal{
+				Proposal: *newProp.ToProto(),
+			},
+		},
+	}
+	newMsg.Type = Proposal
+	return newMsg, nil
+}
+
+// NewBlockPartMessage creates a block part message on the channel of the template message
+func NewBlockPartMessage(template *TMessage, height, round int, part *ttypes.Part) (*TMessage, error) {
+	partP, err := part.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	newMsg := template.Clone().(*TMessage)
+	newMsg.Data = &tmsg.Message{
+		Sum: &tmsg.Message_BlockPart{
+			BlockPart: &tmsg.BlockPart{
+				Height: int64(height),
+				Round:  int32(round),
+				Part:   *partP,
+			},
+		},
+	}
+	newMsg.Type = BlockPart
+	return newMsg, nil
+}
@@ -2,11 +2,13 @@ package util
 
 import (
 	"bytes"
+	"encoding/base64"
 	"testing"
 	"time"
 
 	"github.com/ds-test-framework/scheduler/types"
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/crypto/tmhash"
 	tmsg "github.com/tendermint/tendermint/proto/tendermint/consensus"
 	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
@@ -37,15 +39,15 @@ func TestChangeVote(t *testing.T) {
 		ValidatorIndex:   56789,
 	}
 
-	replica := &types.Replica{
-		Info: map[string]interface{}{
-			"chain_id": "chain-6DYikF",
-			"private_key": map[string]interface{}{
-				"type": "ed25519",
-				"key":  "8O8n/xnT93YsfzvTdU35Wdsoht6FlWMjIPxZplbpGgUScImqzhPZc5LCAEGC5kt9a/MyJfMLwTklv4SKMC/ORA==",
-			},
-		},
+	key, err := base64.StdEncoding.DecodeString("8O8n/xnT93YsfzvTdU35Wdsoht6FlWMjIPxZplbpGgUScImqzhPZc5LCAEGC5kt9a/MyJfMLwTklv4SKMC/ORA==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := NewReplicaInfo(ed25519.PrivKey(key), "chain-6DYikF")
+	if err != nil {
+		t.Fatal(err)
 	}
+	replica := &types.Replica{Info: info}
 
 	chainID, err := GetChainID(replica)
 	if err != nil {
@@ -91,4 +93,17 @@ func TestChangeVote(t *testing.T) {
 	if newvote.BlockID.Hash != nil {
 		t.Error("Vote did not change to nil")
 	}
+
+	newTime := stamp.Add(time.Second)
+	newTimeMsg, err := ChangeVoteTime(replica, voteMsg, newTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voteTime, _ := GetVoteTime(newTimeMsg); !voteTime.Equal(newTime) {
+		t.Errorf("expected the vote time %s, got %s", newTime, voteTime)
+	}
+	// the original message is shared with the message pool and should not change
+	if voteMsg.Data.GetVote().Vote != vote || !bytes.Equal(vote.Signature, sig) {
+		t.Error("the original vote changed")
+	}
 }
@@ -3,6 +3,8 @@ package util
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"sync"
 
 	"github.com/ds-test-framework/scheduler/types"
@@ -66,6 +68,7 @@ func (p *Partition) String() string {
 
 type GenericPartitioner struct {
 	allReplicas *types.ReplicaStore
+	rand        *rand.Rand
 }
 
 func NewGenericPartitioner(replicasStore *types.ReplicaStore) *GenericPartitioner {
@@ -74,6 +77,25 @@ func NewGenericPartitioner(replicasStore *types.ReplicaStore) *GenericPartitione
 	}
 }
 
+// NewSeededPartitioner creates partitions that depend only on the seed and the replica IDs,
+// the replicas are sorted by ID and shuffled with the seed
+func NewSeededPartitioner(replicasStore *types.ReplicaStore, seed int64) *GenericPartitioner {
+	return &GenericPartitioner{
+		allReplicas: replicasStore,
+		rand:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (g *GenericPartitioner) replicas() []*types.Replica {
+	replicas := g.allReplicas.Iter()
+	if g.rand == nil {
+		return replicas
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].ID < replicas[j].ID })
+	g.rand.Shuffle(len(replicas), func(i, j int) { replicas[i], replicas[j] = replicas[j], replicas[i] })
+	return replicas
+}
+
 func (g *GenericPartitioner) CreatePartition(sizes []int, labels []string) (*Partition, error) {
 	if len(sizes) != len(labels) {
 		return nil, errors.New("sizes and labels should be of same length")
@@ -94,7 +116,7 @@ func (g *GenericPartitioner) CreatePartition(sizes []int, labels []string) (*Par
 		return nil, errors.New("total size is not the same as number of replicas")
 	}
 	curIndex := 0
-	for _, r := range g.allReplicas.Iter() {
+	for _, r := range g.replicas() {
 		part := parts[curIndex]
 		size := sizes[curIndex]
 		if part.Size() < size {
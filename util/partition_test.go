@@ -0,0 +1,38 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestSeededPartitioner(t *testing.T) {
+	replicas, _ := newTestReplicas(t, 7)
+	create := func(seed int64) *Partition {
+		partition, err := NewSeededPartitioner(replicas, seed).CreatePartition([]int{1, 2, 4}, []string{"h", "faulty", "rest"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return partition
+	}
+
+	differs := false
+	for seed := int64(0); seed < 10; seed++ {
+		a, b := create(seed), create(seed)
+		for _, label := range []string{"h", "faulty", "rest"} {
+			partA, _ := a.GetPart(label)
+			partB, _ := b.GetPart(label)
+			for _, r := range partA.ReplicaSet.Iter() {
+				if !partB.Contains(r) {
+					t.Errorf("seed %d: part %s differs between partitions with the same seed", seed, label)
+				}
+			}
+		}
+		h, _ := a.GetPart("h")
+		first, _ := create(0).GetPart("h")
+		if h.ReplicaSet.Iter()[0] != first.ReplicaSet.Iter()[0] {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("expected different seeds to create different partitions")
+	}
+}
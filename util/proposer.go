@@ -0,0 +1,80 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ds-test-framework/scheduler/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+var (
+	ErrNoValidators = errors.New("no validators")
+)
+
+// GetValidatorSet creates the validator set from the keys of the replicas.
+// Every replica is a validator with voting power 1, as in the genesis of the test network.
+func GetValidatorSet(replicas *types.ReplicaStore) (*ttypes.ValidatorSet, error) {
+	vals := make([]*ttypes.Validator, 0)
+	for _, r := range replicas.Iter() {
+		key, err := GetPrivKey(r)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, ttypes.NewValidator(key.PubKey(), 1))
+	}
+	if len(vals) == 0 {
+		return nil, ErrNoValidators
+	}
+	return ttypes.NewValidatorSet(vals), nil
+}
+
+// GetProposer returns the validator that is expected to propose in the height and round.
+// The genesis validator set is used for the first height, every height and every round increments
+// the proposer priorities once. This holds as long as the validator set does not change.
+func GetProposer(valSet *ttypes.ValidatorSet, height, round int) *ttypes.Validator {
+	times := (height - 1) + round
+	if times <= 0 {
+		return valSet.GetProposer()
+	}
+	return valSet.CopyIncrementProposerPriority(int32(times)).GetProposer()
+}
+
+// GetProposerReplica returns the replica that is expected to propose in the height and round
+func GetProposerReplica(replicas *types.ReplicaStore, valSet *ttypes.ValidatorSet, height, round int) (*types.Replica, bool) {
+	return GetReplicaByAddress(replicas, GetProposer(valSet, height, round).Address)
+}
+
+// GetProposalSigner returns the validator that signed the proposal
+func GetProposalSigner(chainID string, valSet *ttypes.ValidatorSet, msg *TMessage) (*ttypes.Validator, bool) {
+	for _, val := range valSet.Validators {
+		if VerifyProposal(chainID, val, msg) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// GetReplicaByAddress returns the replica with the validator address
+func GetReplicaByAddress(replicas *types.ReplicaStore, addr []byte) (*types.Replica, bool) {
+	for _, r := range replicas.Iter() {
+		rAddr, err := GetReplicaAddress(r)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(rAddr, addr) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// VerifyProposal returns true if the proposal is signed by the validator
+func VerifyProposal(chainID string, val *ttypes.Validator, msg *TMessage) bool {
+	if msg.Type != Proposal {
+		return false
+	}
+	prop := msg.Data.GetProposal().Proposal
+	signB := ttypes.ProposalSignBytes(chainID, &prop)
+	return val.PubKey.VerifySignature(signB, prop.Signature)
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	tmsg "github.com/tendermint/tendermint/proto/tendermint/consensus"
+	prototypes "github.com/tendermint/tendermint/proto/tendermint/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func newTestReplicas(t *testing.T, n int) (*types.ReplicaStore, []ed25519.PrivKey) {
+	replicas := types.NewReplicaStore(n)
+	keys := make([]ed25519.PrivKey, n)
+	for i := 0; i < n; i++ {
+		keys[i] = ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("replica-%d", i)))
+		info, err := NewReplicaInfo(keys[i], "test-chain")
+		if err != nil {
+			t.Fatal(err)
+		}
+		replicas.Add(&types.Replica{
+			ID:   types.ReplicaID(fmt.Sprintf("replica-%d", i)),
+			Info: info,
+		})
+	}
+	return replicas, keys
+}
+
+func TestGetProposer(t *testing.T) {
+	replicas, _ := newTestReplicas(t, 4)
+	valSet, err := GetValidatorSet(replicas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With equal voting power the validators propose in turn in the order of their addresses, starting from the
+	// lowest address: replica-1 (454A...), replica-3 (7A07...), replica-0 (A27C...) and replica-2 (D759...).
+	// Every height and every round moves to the next validator.
+	expected := [][]int{
+		{1, 3, 0, 2, 1},
+		{3, 0, 2, 1, 3},
+		{0, 2, 1, 3, 0},
+	}
+	for h, rounds := range expected {
+		for round, i := range rounds {
+			proposer, ok := GetProposerReplica(replicas, valSet, h+1, round)
+			if !ok {
+				t.Fatalf("height %d round %d: proposer replica not found", h+1, round)
+			}
+			if proposer.ID != types.ReplicaID(fmt.Sprintf("replica-%d", i)) {
+				t.Errorf("height %d round %d: expected proposer replica-%d, got %s", h+1, round, i, proposer.ID)
+			}
+		}
+	}
+}
+
+func TestGetProposalSigner(t *testing.T) {
+	replicas, keys := newTestReplicas(t, 4)
+	valSet, err := GetValidatorSet(replicas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposer := GetProposer(valSet, 1, 0)
+	blockID := ttypes.BlockID{Hash: []byte("blockhash_blockhash_blockhash_32")}
+
+	for _, key := range keys {
+		proposal := &prototypes.Proposal{
+			Type:      prototypes.ProposalType,
+			Height:    1,
+			Round:     0,
+			PolRound:  -1,
+			Timestamp: time.Now(),
+			BlockID:   blockID.ToProto(),
+		}
+		proposal.Signature, err = key.Sign(ttypes.ProposalSignBytes("test-chain", proposal))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &TMessage{
+			Type: Proposal,
+			Data: &tmsg.Message{
+				Sum: &tmsg.Message_Proposal{
+					Proposal: &tmsg.Proposal{Proposal: *proposal},
+				},
+			},
+		}
+
+		signer, ok := GetProposalSigner("test-chain", valSet, msg)
+		if !ok {
+			t.Fatal("signer not found")
+		}
+		if !bytes.Equal(signer.Address, key.PubKey().Address()) {
+			t.Errorf("expected signer %s, got %s", key.PubKey().Address(), signer.Address)
+		}
+		isProposer := bytes.Equal(key.PubKey().Address(), proposer.Address)
+		if VerifyProposal("test-chain", proposer, msg) != isProposer {
+			t.Errorf("expected proposal verification to be %v for %s", isProposer, signer.Address)
+		}
+	}
+}
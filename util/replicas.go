@@ -120,3 +120,20 @@ func GetReplicaAddress(r *types.Replica) ([]byte, error) {
 	}
 	return key.PubKey().Address().Bytes(), nil
 }
+
+// NewReplicaInfo returns the replica information with the private key and chain id as
+// expected by GetPrivKey and GetChainID
+func NewReplicaInfo(privKey crypto.PrivKey, chainID string) (map[string]interface{}, error) {
+	keyB, err := tmjson.Marshal(privval.FilePVKey{
+		Address: privKey.PubKey().Address(),
+		PubKey:  privKey.PubKey(),
+		PrivKey: privKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"privkey":  string(keyB),
+		"chain_id": chainID,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"github.com/ds-test-framework/scheduler/testlib"
+)
+
+// ReportLog is a message recorded for the report of a testcase, such as the reason of a failure
+type ReportLog struct {
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// AddReportLog keeps the message in the variables of the testcase, the runner writes them to the report
+// of the testcase. The log of the reports of the testing server is not used, the server does not create it
+// and c.AddReportLog panics. The message should also be logged with c.Logger().
+func AddReportLog(c *testlib.Context, message string, params map[string]interface{}) {
+	c.Vars.Set("reportLog", append(GetReportLog(c.Vars), &ReportLog{Message: message, Params: params}))
+}
+
+// GetReportLog returns the messages recorded for the report of the testcase
+func GetReportLog(vars *testlib.Vars) []*ReportLog {
+	logI, ok := vars.Get("reportLog")
+	if !ok {
+		return []*ReportLog{}
+	}
+	return logI.([]*ReportLog)
+}
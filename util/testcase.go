@@ -0,0 +1,108 @@
+package util
+
+import (
+	"sync"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+)
+
+// EndTestCase ends the testcase and records it in the variables of the testcase.
+// The testing server does not tell whether a testcase ended or timed out, the testcases should end with
+// EndTestCase and Abort rather than with c.EndTestCase and c.Abort so that the reports and traces can tell.
+func EndTestCase(c *testlib.Context) {
+	c.Vars.Set("ended", true)
+	c.EndTestCase()
+}
+
+// Abort aborts the testcase and records it in the variables of the testcase, see EndTestCase
+func Abort(c *testlib.Context) {
+	c.Vars.Set("ended", true)
+	c.Vars.Set("aborted", true)
+	c.Abort()
+}
+
+// Aborted is true once the testcase was aborted with Abort or has reached the fail state
+// of its state machine, the state machine handler aborts the testcase
+func Aborted(vars *testlib.Vars) bool {
+	if vars == nil {
+		return false
+	}
+	if aborted, ok := vars.GetBool("aborted"); ok && aborted {
+		return true
+	}
+	state, ok := vars.GetString("curState")
+	return ok && state == handlers.FailStateLabel
+}
+
+// Ended is true once the testcase has ended with EndTestCase or was aborted, the testing server
+// does not deliver the messages returned by the handler once the testcase has ended
+func Ended(vars *testlib.Vars) bool {
+	if vars == nil {
+		return false
+	}
+	if ended, ok := vars.GetBool("ended"); ok && ended {
+		return true
+	}
+	return Aborted(vars)
+}
+
+type testcaseFuncs struct {
+	setup  func(*testlib.Context) error
+	assert testlib.AssertFunc
+}
+
+var (
+	funcs     = make(map[*testlib.TestCase]*testcaseFuncs)
+	funcsLock = new(sync.Mutex)
+)
+
+func funcsOf(testcase *testlib.TestCase) *testcaseFuncs {
+	f, ok := funcs[testcase]
+	if !ok {
+		f = &testcaseFuncs{}
+		funcs[testcase] = f
+	}
+	return f
+}
+
+// SetupFunc sets the setup function of the testcase and records it.
+// The testcase does not return its setup and assert functions, only the testing server calls them. The testcases
+// should set them with SetupFunc and AssertFn rather than with testcase.SetupFunc and testcase.AssertFn so that
+// they can be evaluated without the testing server, see Setup and Assertion.
+func SetupFunc(testcase *testlib.TestCase, setup func(*testlib.Context) error) {
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	funcsOf(testcase).setup = setup
+	testcase.SetupFunc(setup)
+}
+
+// AssertFn sets the assert function of the testcase and records it, see SetupFunc
+func AssertFn(testcase *testlib.TestCase, assert testlib.AssertFunc) {
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	funcsOf(testcase).assert = assert
+	testcase.AssertFn(assert)
+}
+
+// Setup returns the setup function of the testcase set with SetupFunc, false if it was not set with SetupFunc
+func Setup(testcase *testlib.TestCase) (func(*testlib.Context) error, bool) {
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	f, ok := funcs[testcase]
+	if !ok || f.setup == nil {
+		return nil, false
+	}
+	return f.setup, true
+}
+
+// Assertion returns the assert function of the testcase set with AssertFn, false if it was not set with AssertFn
+func Assertion(testcase *testlib.TestCase) (testlib.AssertFunc, bool) {
+	funcsLock.Lock()
+	defer funcsLock.Unlock()
+	f, ok := funcs[testcase]
+	if !ok || f.assert == nil {
+		return nil, false
+	}
+	return f.assert, true
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/testlib"
+	"github.com/ds-test-framework/scheduler/testlib/handlers"
+)
+
+func TestTestCaseFuncs(t *testing.T) {
+	testcase := testlib.NewTestCase("funcs", time.Minute, &testlib.DoNothingHandler{})
+	if _, ok := Setup(testcase); ok {
+		t.Error("expected no setup function before SetupFunc")
+	}
+	if _, ok := Assertion(testcase); ok {
+		t.Error("expected no assert function before AssertFn")
+	}
+
+	setups := 0
+	SetupFunc(testcase, func(c *testlib.Context) error {
+		setups++
+		return nil
+	})
+	AssertFn(testcase, func(c *testlib.Context) bool {
+		return setups == 1
+	})
+	setup, ok := Setup(testcase)
+	if !ok {
+		t.Fatal("expected the setup function set with SetupFunc")
+	}
+	assert, ok := Assertion(testcase)
+	if !ok {
+		t.Fatal("expected the assert function set with AssertFn")
+	}
+	if err := setup(nil); err != nil || !assert(nil) {
+		t.Errorf("expected the recorded functions to run, got %v after %d setups", err, setups)
+	}
+}
+
+func TestEnded(t *testing.T) {
+	vars := testlib.NewVarSet()
+	if Ended(vars) || Aborted(vars) || Ended(nil) {
+		t.Error("expected the testcase neither ended nor aborted")
+	}
+	vars.Set("curState", handlers.FailStateLabel)
+	if !Aborted(vars) || !Ended(vars) {
+		t.Error("expected the fail state to abort the testcase")
+	}
+	vars = testlib.NewVarSet()
+	vars.Set("ended", true)
+	if !Ended(vars) || Aborted(vars) {
+		t.Error("expected the testcase ended and not aborted")
+	}
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+type TimeoutType string
+
+const (
+	TimeoutPropose   TimeoutType = "TimeoutPropose"
+	TimeoutPrevote   TimeoutType = "TimeoutPrevote"
+	TimeoutPrecommit TimeoutType = "TimeoutPrecommit"
+	TimeoutCommit    TimeoutType = "TimeoutCommit"
+	UnknownTimeout   TimeoutType = "Unknown"
+)
+
+var (
+	timeoutSteps = map[string]TimeoutType{
+		"RoundStepPropose":       TimeoutPropose,
+		"RoundStepPrevoteWait":   TimeoutPrevote,
+		"RoundStepPrecommitWait": TimeoutPrecommit,
+		"RoundStepNewHeight":     TimeoutCommit,
+		string(TimeoutPropose):   TimeoutPropose,
+		string(TimeoutPrevote):   TimeoutPrevote,
+		string(TimeoutPrecommit): TimeoutPrecommit,
+		string(TimeoutCommit):    TimeoutCommit,
+	}
+	heightRoundRegex = regexp.MustCompile(`(\d+)/(\d+)`)
+)
+
+// Timeout is a consensus timeout started or ended at a replica
+type Timeout struct {
+	Replica  types.ReplicaID
+	Type     TimeoutType
+	Height   int
+	Round    int
+	Duration time.Duration
+	// Ended is true for timeouts that fired
+	Ended bool
+}
+
+// ParseTimeout converts the timeout reported by the replica.
+// The type is expected to be the tendermint timeoutInfo string, for example `3s ; 1/0 RoundStepPropose`,
+// or just the step. Height and round are -1 if they are not part of the type.
+func ParseTimeout(t *types.ReplicaTimeout) *Timeout {
+	timeout := &Timeout{
+		Replica:  t.Replica,
+		Type:     UnknownTimeout,
+		Height:   -1,
+		Round:    -1,
+		Duration: t.Duration,
+	}
+	for _, field := range strings.Fields(t.Type) {
+		if tType, ok := timeoutSteps[field]; ok {
+			timeout.Type = tType
+		}
+	}
+	if hr := heightRoundRegex.FindStringSubmatch(t.Type); hr != nil {
+		timeout.Height, _ = strconv.Atoi(hr[1])
+		timeout.Round, _ = strconv.Atoi(hr[2])
+	}
+	return timeout
+}
+
+// GetTimeout returns the timeout if e is a timeout start or end event
+func GetTimeout(e *types.Event) (*Timeout, bool) {
+	t, ok := e.Timeout()
+	if !ok || t == nil {
+		return nil, false
+	}
+	timeout := ParseTimeout(t)
+	timeout.Ended = e.IsTimeoutEnd()
+	return timeout, true
+}
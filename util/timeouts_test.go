@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/ds-test-framework/scheduler/types"
+)
+
+func TestParseTimeout(t *testing.T) {
+	cases := []struct {
+		tType  string
+		expect TimeoutType
+		height int
+		round  int
+	}{
+		{"3s ; 1/0 RoundStepPropose", TimeoutPropose, 1, 0},
+		{"1s ; 2/3 RoundStepPrecommitWait", TimeoutPrecommit, 2, 3},
+		{"RoundStepPrevoteWait", TimeoutPrevote, -1, -1},
+		{"Timeout", UnknownTimeout, -1, -1},
+	}
+	for _, c := range cases {
+		timeout := ParseTimeout(&types.ReplicaTimeout{Replica: "replica", Type: c.tType})
+		if timeout.Type != c.expect || timeout.Height != c.height || timeout.Round != c.round {
+			t.Errorf("%s: unexpected timeout %#v", c.tType, timeout)
+		}
+	}
+}